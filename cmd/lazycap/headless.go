@@ -0,0 +1,564 @@
+package lazycap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/icarus-itcs/lazycap/internal/cap"
+	"github.com/icarus-itcs/lazycap/internal/debug"
+	"github.com/icarus-itcs/lazycap/internal/device"
+	"github.com/icarus-itcs/lazycap/internal/preflight"
+	"github.com/icarus-itcs/lazycap/internal/settings"
+	"github.com/icarus-itcs/lazycap/internal/shellenv"
+)
+
+// Exit codes of the headless commands. A command that exits non-zero passes
+// its own exit code through.
+const (
+	exitFailure  = 1
+	exitUsage    = 2
+	exitTimeout  = 124
+	exitCanceled = 130
+)
+
+// ExitError carries the exit code a failed headless command should end lazycap with
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	headlessProject string
+
+	runDevice string
+	runLive   bool
+
+	cleanYes bool
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the app on a device without the TUI",
+	Long: `Build and run the app on a device with cap run, streaming its output.
+The device is given by ID, alias from the deviceAliases setting, rule such as
+ios:simulator:booted or android:newest, or (fuzzy) name; offline simulators
+and emulators are booted first.
+With --live the dev server keeps running for live reload until interrupted.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		start := time.Now()
+		h, err := newHeadless()
+		if err != nil {
+			return writeCommandResult(cmd.Name(), start, err)
+		}
+		defer h.close()
+		return writeCommandResult("run", start, h.run(runDevice, runLive))
+	},
+}
+
+var buildCmd = &cobra.Command{
+	Use:          "build",
+	Short:        "Build the web assets without the TUI",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		start := time.Now()
+		h, err := newHeadless()
+		if err != nil {
+			return writeCommandResult(cmd.Name(), start, err)
+		}
+		defer h.close()
+		return writeCommandResult("build", start, h.pipeline("build", settings.HookPreBuild, settings.HookPostBuild, h.build))
+	},
+}
+
+var syncCmd = &cobra.Command{
+	Use:          "sync [platform]",
+	Short:        "Sync web assets and plugins to the native projects",
+	Args:         cobra.MaximumNArgs(1),
+	ValidArgs:    []string{"android", "ios"},
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		start := time.Now()
+		h, err := newHeadless()
+		if err != nil {
+			return writeCommandResult(cmd.Name(), start, err)
+		}
+		defer h.close()
+		platform := ""
+		if len(args) > 0 {
+			platform = args[0]
+		}
+		err = h.pipeline("sync", settings.HookPreSync, settings.HookPostSync, func() error {
+			return h.sync(platform)
+		})
+		return writeCommandResult("sync", start, err)
+	},
+}
+
+var openCmd = &cobra.Command{
+	Use:          "open <platform>",
+	Short:        "Open the native project in Xcode or Android Studio",
+	Args:         cobra.ExactArgs(1),
+	ValidArgs:    []string{"android", "ios"},
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		start := time.Now()
+		h, err := newHeadless()
+		if err != nil {
+			return writeCommandResult(cmd.Name(), start, err)
+		}
+		defer h.close()
+		err = h.pipeline("open", settings.HookPreOpen, settings.HookPostOpen, func() error {
+			e, err := cap.OpenAt(h.ctx, h.project.RootDir, args[0], h.execOptions(time.Minute))
+			return commandError("open", e, err)
+		})
+		return writeCommandResult("open", start, err)
+	},
+}
+
+var doctorCmd = &cobra.Command{
+	Use:          "doctor",
+	Short:        "Check the development environment (the preflight checks)",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userSettings, _ := settings.Load()
+		userSettings.ApplyToolEnv()
+
+		results := preflight.Run()
+		if structuredOutput() {
+			if err := writeDocument(preflightOutput(results)); err != nil {
+				return err
+			}
+			if results.HasErrors {
+				return &ExitError{Code: exitFailure, Err: fmt.Errorf("preflight checks failed")}
+			}
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, c := range results.Checks {
+			icon := "✓"
+			switch c.Status {
+			case preflight.StatusWarning:
+				icon = "!"
+			case preflight.StatusError:
+				icon = "✗"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", icon, c.Name, c.Message)
+		}
+		_ = w.Flush()
+
+		if len(results.Discoveries) > 0 {
+			fmt.Println()
+			for _, d := range results.Discoveries {
+				fmt.Printf("%s\t%s (%s)\n", d.Type, d.Name, d.Path)
+			}
+		}
+
+		fmt.Printf("\n%s\n", results.Summary())
+		if results.HasErrors {
+			return &ExitError{Code: exitFailure, Err: fmt.Errorf("preflight checks failed")}
+		}
+		return nil
+	},
+}
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean [debug-action-id]",
+	Short: "Run a debug/cleanup action, or list them",
+	Long: `Run one of the debug panel's cleanup actions by ID, e.g. lazycap clean node-modules.
+Without an ID the available actions are listed. Dangerous actions need --yes.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		actions := debug.GetActions()
+		if len(args) == 0 {
+			if structuredOutput() {
+				out := ActionsOutput{schemaHeader: newHeader("debugActions"), Actions: make([]ActionOutput, 0, len(actions))}
+				for _, a := range actions {
+					out.Actions = append(out.Actions, actionOutput(a))
+				}
+				return writeDocument(out)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tCATEGORY\tDESCRIPTION")
+			for _, a := range actions {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", a.ID, a.Category, a.Description)
+			}
+			return w.Flush()
+		}
+
+		var action *debug.Action
+		for i := range actions {
+			if actions[i].ID == args[0] {
+				action = &actions[i]
+				break
+			}
+		}
+		if action == nil {
+			return &ExitError{Code: exitUsage, Err: fmt.Errorf("unknown debug action %q (run lazycap clean to list them)", args[0])}
+		}
+		if action.Dangerous && !cleanYes {
+			return &ExitError{Code: exitUsage, Err: fmt.Errorf("%s is destructive: %s (pass --yes to run it)", action.Name, action.Description)}
+		}
+
+		result := debug.RunAction(action.ID)
+		if structuredOutput() {
+			err := writeDocument(ActionResultOutput{
+				schemaHeader: newHeader("debugActionResult"),
+				Action:       actionOutput(*action),
+				Success:      result.Success,
+				Message:      result.Message,
+				Details:      result.Details,
+			})
+			if err != nil {
+				return err
+			}
+		} else {
+			fmt.Println(result.Message)
+			if result.Details != "" {
+				fmt.Println(result.Details)
+			}
+		}
+		if !result.Success {
+			return &ExitError{Code: exitFailure, Err: fmt.Errorf("%s failed", action.Name)}
+		}
+		return nil
+	},
+}
+
+// headless runs commands for the CLI with the same project detection and
+// settings as the TUI, streaming output to stdout
+type headless struct {
+	ctx      context.Context
+	stop     context.CancelFunc
+	project  *cap.Project
+	settings *settings.Settings
+}
+
+func newHeadless() (*headless, error) {
+	project, err := resolveProject(headlessProject)
+	if err != nil {
+		return nil, &ExitError{Code: exitUsage, Err: err}
+	}
+
+	userSettings, _ := settings.Load()
+	userSettings.ApplyToolEnv()
+
+	// Ctrl+C cancels the running command, which stops its whole process tree
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	return &headless{ctx: ctx, stop: stop, project: project, settings: userSettings}, nil
+}
+
+func (h *headless) close() {
+	h.stop()
+}
+
+// resolveProject picks the project named by --project (a directory or a
+// project name), or the first discovered project like the TUI does
+func resolveProject(name string) (*cap.Project, error) {
+	if name != "" {
+		if info, err := os.Stat(name); err == nil && info.IsDir() {
+			return cap.LoadProjectAt(name)
+		}
+	}
+
+	projects, _ := cap.DiscoverProjects(4)
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("no Capacitor projects found")
+	}
+	if name == "" {
+		return projects[0], nil
+	}
+	for _, p := range projects {
+		if p.Name == name || p.WorkspacePackage == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no Capacitor project named %q", name)
+}
+
+// execOptions returns options for a command in the project directory with
+// output streamed to stdout (stderr when printing a JSON/YAML result)
+func (h *headless) execOptions(timeout time.Duration) cap.ExecOptions {
+	return cap.ExecOptions{
+		Dir:     h.project.RootDir,
+		Env:     h.settings.EnvList(),
+		Timeout: timeout,
+		Output:  commandOutput(),
+	}
+}
+
+// shellArgv runs a command line through the Shell Path setting, $SHELL or sh
+func (h *headless) shellArgv(command string) []string {
+	return shellenv.Command(h.settings.ShellPath, command)
+}
+
+// pipeline runs the pre hooks, main and then the post hooks, in the same
+// order and with the same failure policies as the TUI
+func (h *headless) pipeline(action, preStage, postStage string, main func() error) error {
+	if err := h.runHooks(action, preStage); err != nil {
+		return err
+	}
+	if err := main(); err != nil {
+		return err
+	}
+	return h.runHooks(action, postStage)
+}
+
+func (h *headless) runHooks(action, stage string) error {
+	for _, hook := range h.settings.HooksFor(stage) {
+		fmt.Fprintf(os.Stderr, "==> %s: %s\n", stage, hook.DisplayName())
+
+		opts := h.execOptions(hook.TimeoutDuration())
+		opts.Dir = hook.WorkDir(h.project.RootDir)
+		opts.Env = append(opts.Env, hook.EnvList()...)
+		e, err := cap.Start(h.ctx, h.shellArgv(hook.Command), opts)
+		if err == nil {
+			err = e.Wait()
+		}
+		if err == nil {
+			continue
+		}
+
+		if hook.ContinueOnFailure() && !errors.Is(err, cap.ErrCanceled) {
+			fmt.Fprintf(os.Stderr, "==> %s hook %s failed (%v), continuing\n", stage, hook.DisplayName(), err)
+			continue
+		}
+		return commandError(fmt.Sprintf("%s hook %s (stopping %s)", stage, hook.DisplayName(), action), e, err)
+	}
+	return nil
+}
+
+// build builds the web assets with the custom build command or the project's
+// build script, through the workspace runner for monorepo members
+func (h *headless) build() error {
+	opts := h.execOptions(h.settings.BuildTimeoutDuration())
+	opts.Env = h.settings.BuildEnvList()
+
+	var e *cap.Execution
+	var err error
+	if command := h.settings.ResolvedBuildCommand(); command != "" {
+		e, err = cap.Start(h.ctx, h.shellArgv(command), opts)
+	} else {
+		argv, dir := h.project.BuildCommand()
+		opts.Dir = dir
+		e, err = cap.Start(h.ctx, argv, opts)
+	}
+	if err == nil {
+		err = e.Wait()
+	}
+	return commandError("build", e, err)
+}
+
+func (h *headless) sync(platform string) error {
+	if h.settings.AutoBuild && h.settings.CopyWebDir {
+		if err := h.build(); err != nil {
+			return err
+		}
+	}
+	e, err := cap.SyncAt(h.ctx, h.project.RootDir, platform, h.settings.CopyWebDir, h.settings.UpdateNative, h.execOptions(h.settings.SyncTimeoutDuration()))
+	if err == nil {
+		err = e.Wait()
+	}
+	return commandError("sync", e, err)
+}
+
+func (h *headless) run(query string, live bool) error {
+	devices, err := cap.ListDevices()
+	if err != nil {
+		return err
+	}
+	dev, err := findDevice(devices, query, h.settings)
+	if err != nil {
+		return &ExitError{Code: exitUsage, Err: err}
+	}
+	if dev.IsWeb {
+		return &ExitError{Code: exitUsage, Err: fmt.Errorf("the web device has no cap run; start your dev server instead")}
+	}
+
+	if !dev.Online {
+		fmt.Fprintf(os.Stderr, "==> Booting %s\n", dev.Name)
+		if err := bootAndWait(h.ctx, dev); err != nil {
+			return commandError("boot", nil, err)
+		}
+	}
+
+	run := cap.RunOptions{
+		Flavor:        h.settings.ResolvedAndroidFlavor(),
+		Scheme:        h.settings.ResolvedIOSScheme(),
+		Configuration: h.settings.IOSConfiguration,
+	}
+
+	return h.pipeline("run", settings.HookPreRun, settings.HookPostRun, func() error {
+		if !live {
+			switch {
+			case h.settings.AutoSync:
+				// Sync with lazycap's sync settings, so cap run skips its own
+				run.NoSync = true
+				if cap.SyncArgs(dev.Platform, h.settings.CopyWebDir, h.settings.UpdateNative) != nil {
+					if err := h.sync(dev.Platform); err != nil {
+						return err
+					}
+				}
+			case h.settings.AutoBuild:
+				if err := h.build(); err != nil {
+					return err
+				}
+			}
+			e, err := cap.RunAt(h.ctx, h.project.RootDir, dev.ID, dev.Platform, run, h.execOptions(h.settings.BuildTimeoutDuration()))
+			if err == nil {
+				err = e.Wait()
+			}
+			return commandError("run", e, err)
+		}
+		return h.runLive(dev, run)
+	})
+}
+
+// runLive starts the dev server, runs the app against it and keeps the dev
+// server in the foreground until it exits or lazycap is interrupted
+func (h *headless) runLive(dev *device.Device, run cap.RunOptions) error {
+	run.Port = h.settings.ResolvedLiveReloadPort()
+	run.Host = h.settings.ResolvedLiveReloadHost()
+	lr, err := cap.NewLiveReload(h.project.RootDir, dev, run, cap.LiveReloadOptions{
+		DevCommand: h.settings.WebDevCommand,
+		ADBReverse: h.settings.ADBReverse(),
+		HTTPS:      h.settings.WebHttps,
+		HostHint:   "set externalHost to use another address",
+	})
+	if err != nil {
+		return commandError("dev server", nil, err)
+	}
+
+	cap.KillPort(lr.Run.Port)
+	opts := h.execOptions(0)
+	opts.Env = append(opts.Env, lr.Env...)
+	fmt.Fprintf(os.Stderr, "==> Starting dev server: %s\n", strings.Join(lr.Argv, " "))
+	server, err := cap.Start(h.ctx, lr.Argv, opts)
+	if err != nil {
+		return commandError("dev server", nil, err)
+	}
+	defer server.Cancel()
+
+	if err := lr.Ready(server); err != nil {
+		server.Cancel()
+		return commandError("live reload", server, err)
+	}
+	if lr.Proxy != nil {
+		fmt.Fprintf(os.Stderr, "==> Serving HTTPS on port %d for the dev server on port %d\n", lr.Run.Port, lr.Proxy.Backend)
+	}
+	if lr.Reverse != nil {
+		defer func() { _ = lr.Reverse.Remove() }()
+		fmt.Fprintf(os.Stderr, "==> Forwarding %s on %s with adb reverse\n", lr.Reverse, dev.Name)
+	}
+	if lr.HostReason != "" {
+		fmt.Fprintf(os.Stderr, "==> Live reload host %s: %s\n", lr.Run.Host, lr.HostReason)
+	}
+
+	e, err := cap.RunAt(h.ctx, h.project.RootDir, dev.ID, dev.Platform, lr.Run, h.execOptions(h.settings.BuildTimeoutDuration()))
+	if err == nil {
+		err = e.Wait()
+	}
+	if err != nil {
+		return commandError("run", e, err)
+	}
+
+	fmt.Fprintln(os.Stderr, "==> Live reload running, press Ctrl+C to stop")
+	if err := server.Wait(); err != nil && !errors.Is(err, cap.ErrCanceled) {
+		return commandError("dev server", server, err)
+	}
+	return nil
+}
+
+// findDevice resolves --device (an ID, alias, rule or name) with the device
+// aliases from the settings. Without a query the first default device from
+// the settings that resolves is used.
+func findDevice(devices []device.Device, query string, s *settings.Settings) (*device.Device, error) {
+	if query != "" {
+		dev, err := device.Resolve(devices, query, s.DeviceAliases)
+		if err != nil {
+			return nil, fmt.Errorf("%w (run lazycap devices to list them)", err)
+		}
+		return dev, nil
+	}
+
+	defaults := s.DefaultDeviceQueries()
+	if len(defaults) == 0 {
+		return nil, fmt.Errorf("no device given: pass --device <id|name|alias|rule> or set a default device in settings")
+	}
+	var lastErr error
+	for _, q := range defaults {
+		dev, err := device.Resolve(devices, q, s.DeviceAliases)
+		if err == nil {
+			return dev, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("default device: %w", lastErr)
+}
+
+// bootAndWait boots a simulator or emulator and waits up to a minute for it.
+// dev is then updated to the booted device, so an emulator is addressed by
+// its adb serial rather than its AVD name.
+func bootAndWait(ctx context.Context, dev *device.Device) error {
+	if err := cap.BootDevice(dev.ID, dev.Platform, dev.IsEmulator); err != nil {
+		return err
+	}
+	for i := 0; i < 60; i++ {
+		select {
+		case <-ctx.Done():
+			return cap.ErrCanceled
+		case <-time.After(time.Second):
+		}
+		if cap.IsDeviceBooted(dev.ID, dev.Platform) {
+			dev.ID = cap.BootedID(dev)
+			dev.Online = true
+			dev.State = device.StateBooted
+			return nil
+		}
+	}
+	return fmt.Errorf("%s did not boot within a minute: %w", dev.Name, cap.ErrTimeout)
+}
+
+// commandError turns a failed command into an ExitError with its exit code
+func commandError(what string, e *cap.Execution, err error) error {
+	if err == nil {
+		return nil
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return err
+	}
+
+	code := exitFailure
+	switch {
+	case errors.Is(err, cap.ErrCanceled):
+		code = exitCanceled
+	case errors.Is(err, cap.ErrTimeout):
+		code = exitTimeout
+	case e != nil && e.ExitCode() > 0:
+		code = e.ExitCode()
+	}
+	return &ExitError{Code: code, Err: fmt.Errorf("%s failed: %w", what, err)}
+}
@@ -0,0 +1,415 @@
+package lazycap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/icarus-itcs/lazycap/internal/cap"
+	"github.com/icarus-itcs/lazycap/internal/debug"
+	"github.com/icarus-itcs/lazycap/internal/device"
+	"github.com/icarus-itcs/lazycap/internal/preflight"
+)
+
+// schemaVersion is bumped whenever a field of the JSON/YAML output is renamed
+// or removed; adding fields does not change it
+const schemaVersion = 1
+
+// outputFormat is the global --output flag: table, json or yaml
+var outputFormat = "table"
+
+func checkOutputFormat() error {
+	switch outputFormat {
+	case "table", "json", "yaml":
+		return nil
+	}
+	return &ExitError{Code: exitUsage, Err: fmt.Errorf("unknown output format %q (use table, json or yaml)", outputFormat)}
+}
+
+// structuredOutput reports whether a command should print a document
+// instead of human-readable text
+func structuredOutput() bool {
+	return outputFormat == "json" || outputFormat == "yaml"
+}
+
+// schemaHeader starts every JSON/YAML document
+type schemaHeader struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Kind          string `json:"kind"`
+}
+
+func newHeader(kind string) schemaHeader {
+	return schemaHeader{SchemaVersion: schemaVersion, Kind: kind}
+}
+
+// commandOutput is where headless commands stream their output: stdout, or
+// stderr when stdout carries a JSON/YAML document
+func commandOutput() io.Writer {
+	if structuredOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// writeDocument prints doc to stdout in the selected structured format
+func writeDocument(doc interface{}) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if outputFormat == "yaml" {
+		return writeYAML(os.Stdout, data)
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	return err
+}
+
+// DeviceOutput is a device.Device in the output schema
+type DeviceOutput struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Platform     string   `json:"platform"`
+	Online       bool     `json:"online"`
+	State        string   `json:"state"` // booted, booting, shutting-down, shutdown, disconnected or unauthorized
+	Emulator     bool     `json:"emulator"`
+	Web          bool     `json:"web"`
+	APILevel     string   `json:"apiLevel,omitempty"`
+	ABI          string   `json:"abi,omitempty"`
+	OSVersion    string   `json:"osVersion,omitempty"`
+	Runtime      string   `json:"runtime,omitempty"`
+	Model        string   `json:"model,omitempty"`
+	ModelID      string   `json:"modelId,omitempty"`
+	Connection   string   `json:"connection,omitempty"`
+	Address      string   `json:"address,omitempty"`
+	BatteryLevel int      `json:"batteryLevel,omitempty"`
+	Aliases      []string `json:"aliases,omitempty"`
+}
+
+func deviceOutput(d device.Device, aliases map[string]string) DeviceOutput {
+	return DeviceOutput{
+		ID:           d.ID,
+		Name:         d.Name,
+		Platform:     d.Platform,
+		Online:       d.Online,
+		State:        string(d.CurrentState()),
+		Emulator:     d.IsEmulator,
+		Web:          d.IsWeb,
+		APILevel:     d.APILevel,
+		ABI:          d.ABI,
+		OSVersion:    d.OSVersion,
+		Runtime:      d.Runtime,
+		Model:        d.Model,
+		ModelID:      d.ModelID,
+		Connection:   d.Connection,
+		Address:      d.Address,
+		BatteryLevel: d.BatteryLevel,
+		Aliases:      device.AliasesFor(d, aliases),
+	}
+}
+
+// ProjectOutput is a cap.Project in the output schema
+type ProjectOutput struct {
+	Name             string                `json:"name"`
+	AppID            string                `json:"appId"`
+	WebDir           string                `json:"webDir,omitempty"`
+	RootDir          string                `json:"rootDir"`
+	ConfigPath       string                `json:"configPath"`
+	PackageManager   string                `json:"packageManager,omitempty"`
+	Android          bool                  `json:"android"`
+	IOS              bool                  `json:"ios"`
+	Workspace        string                `json:"workspace,omitempty"` // Monorepo root
+	WorkspaceTool    string                `json:"workspaceTool,omitempty"`
+	WorkspacePackage string                `json:"workspacePackage,omitempty"`
+	AndroidProject   *AndroidProjectOutput `json:"androidProject,omitempty"`
+	IOSProject       *IOSProjectOutput     `json:"iosProject,omitempty"`
+	ConfigWarnings   []string              `json:"configWarnings,omitempty"`
+}
+
+// AndroidProjectOutput is a cap.AndroidProject in the output schema
+type AndroidProjectOutput struct {
+	GradleFile    string   `json:"gradleFile"`
+	ApplicationID string   `json:"applicationId,omitempty"`
+	VersionName   string   `json:"versionName,omitempty"`
+	VersionCode   int      `json:"versionCode,omitempty"`
+	Flavors       []string `json:"flavors,omitempty"`
+	BuildTypes    []string `json:"buildTypes,omitempty"`
+}
+
+// IOSProjectOutput is a cap.IOSProject in the output schema
+type IOSProjectOutput struct {
+	ProjectFile      string   `json:"projectFile"`
+	InfoPlist        string   `json:"infoPlist,omitempty"`
+	BundleID         string   `json:"bundleId,omitempty"`
+	MarketingVersion string   `json:"marketingVersion,omitempty"`
+	BuildNumber      string   `json:"buildNumber,omitempty"`
+	Schemes          []string `json:"schemes,omitempty"`
+	Configurations   []string `json:"configurations,omitempty"`
+}
+
+func projectOutput(p *cap.Project) ProjectOutput {
+	out := ProjectOutput{
+		Name:             p.Name,
+		AppID:            p.AppID,
+		WebDir:           p.WebDir,
+		RootDir:          p.RootDir,
+		ConfigPath:       p.ConfigPath,
+		PackageManager:   string(p.PackageManager),
+		Android:          p.HasAndroid,
+		IOS:              p.HasIOS,
+		WorkspacePackage: p.WorkspacePackage,
+		ConfigWarnings:   p.ConfigWarnings,
+	}
+	if p.Workspace != nil {
+		out.Workspace = p.Workspace.Root
+		out.WorkspaceTool = p.Workspace.Tool
+	}
+	if a := p.Android; a != nil {
+		out.AndroidProject = &AndroidProjectOutput{
+			GradleFile:    a.GradleFile,
+			ApplicationID: a.ApplicationID,
+			VersionName:   a.VersionName,
+			VersionCode:   a.VersionCode,
+			Flavors:       a.Flavors,
+			BuildTypes:    a.BuildTypes,
+		}
+	}
+	if i := p.IOS; i != nil {
+		out.IOSProject = &IOSProjectOutput{
+			ProjectFile:      i.ProjectFile,
+			InfoPlist:        i.InfoPlist,
+			BundleID:         i.BundleID,
+			MarketingVersion: i.MarketingVersion,
+			BuildNumber:      i.BuildNumber,
+			Schemes:          i.Schemes,
+			Configurations:   i.Configurations,
+		}
+	}
+	return out
+}
+
+// CheckOutput is a preflight.CheckResult in the output schema
+type CheckOutput struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"` // ok, warning or error
+	Message string `json:"message,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+// DiscoveryOutput is a preflight.Discovery in the output schema
+type DiscoveryOutput struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Details   string `json:"details,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+}
+
+// PreflightOutput is preflight.Results in the output schema
+type PreflightOutput struct {
+	schemaHeader
+	Summary     string            `json:"summary"`
+	HasErrors   bool              `json:"hasErrors"`
+	HasWarnings bool              `json:"hasWarnings"`
+	Checks      []CheckOutput     `json:"checks"`
+	Discoveries []DiscoveryOutput `json:"discoveries"`
+}
+
+func preflightOutput(r *preflight.Results) PreflightOutput {
+	out := PreflightOutput{
+		schemaHeader: newHeader("preflight"),
+		Summary:      r.Summary(),
+		HasErrors:    r.HasErrors,
+		HasWarnings:  r.HasWarnings,
+		Checks:       make([]CheckOutput, 0, len(r.Checks)),
+		Discoveries:  make([]DiscoveryOutput, 0, len(r.Discoveries)),
+	}
+	for _, c := range r.Checks {
+		status := "ok"
+		switch c.Status {
+		case preflight.StatusWarning:
+			status = "warning"
+		case preflight.StatusError:
+			status = "error"
+		}
+		out.Checks = append(out.Checks, CheckOutput{Name: c.Name, Status: status, Message: c.Message, Path: c.Path})
+	}
+	for _, d := range r.Discoveries {
+		out.Discoveries = append(out.Discoveries, DiscoveryOutput(d))
+	}
+	return out
+}
+
+// ActionOutput is a debug.Action in the output schema
+type ActionOutput struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Platform    string `json:"platform"`
+	Dangerous   bool   `json:"dangerous"`
+}
+
+func actionOutput(a debug.Action) ActionOutput {
+	return ActionOutput(a)
+}
+
+// ActionResultOutput is the result of running a debug action
+type ActionResultOutput struct {
+	schemaHeader
+	Action  ActionOutput `json:"action"`
+	Success bool         `json:"success"`
+	Message string       `json:"message"`
+	Details string       `json:"details,omitempty"`
+}
+
+// CommandResultOutput is printed after run, build, sync and open, whose own
+// output goes to stderr in structured mode
+type CommandResultOutput struct {
+	schemaHeader
+	Command  string  `json:"command"`
+	Success  bool    `json:"success"`
+	ExitCode int     `json:"exitCode"`
+	Duration float64 `json:"durationSeconds"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// writeCommandResult prints the result document of a headless command and
+// passes err through for the exit code
+func writeCommandResult(command string, start time.Time, err error) error {
+	if !structuredOutput() {
+		return err
+	}
+	out := CommandResultOutput{
+		schemaHeader: newHeader("commandResult"),
+		Command:      command,
+		Success:      err == nil,
+		Duration:     time.Since(start).Round(time.Millisecond).Seconds(),
+	}
+	if err != nil {
+		out.ExitCode = exitFailure
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			out.ExitCode = exitErr.Code
+		}
+		out.Error = err.Error()
+	}
+	if writeErr := writeDocument(out); writeErr != nil && err == nil {
+		return writeErr
+	}
+	return err
+}
+
+// writeYAML converts JSON to block-style YAML, keeping key order. JSON is
+// YAML, so it is read as a YAML node tree and written back without the flow
+// and quoting styles of the JSON source; strings that would read as another
+// type (yes, 1e3, .inf, ...) stay quoted.
+func writeYAML(w io.Writer, data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	clearStyle(&doc)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// VersionOutput is the output of lazycap version
+type VersionOutput struct {
+	schemaHeader
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+// VersionBumpOutput is the output of lazycap version bump
+type VersionBumpOutput struct {
+	schemaHeader
+	Part       string   `json:"part"`
+	OldVersion string   `json:"oldVersion"`
+	NewVersion string   `json:"newVersion"`
+	OldBuild   int      `json:"oldBuild"`
+	NewBuild   int      `json:"newBuild"`
+	Files      []string `json:"files"`
+	Applied    bool     `json:"applied"`
+	Tag        string   `json:"tag,omitempty"`
+}
+
+// DevicesOutput is the output of lazycap devices
+type DevicesOutput struct {
+	schemaHeader
+	Devices []DeviceOutput `json:"devices"`
+}
+
+// ProjectsOutput is the output of lazycap projects
+type ProjectsOutput struct {
+	schemaHeader
+	Projects []ProjectOutput `json:"projects"`
+}
+
+// ActionsOutput is the output of lazycap clean without an action
+type ActionsOutput struct {
+	schemaHeader
+	Actions []ActionOutput `json:"actions"`
+}
+
+// PluginReportOutput is the output of lazycap plugins
+type PluginReportOutput struct {
+	schemaHeader
+	CoreVersion string         `json:"coreVersion,omitempty"`
+	Plugins     []PluginOutput `json:"plugins"`
+	IssueCount  int            `json:"issueCount"`
+}
+
+// PluginOutput is a cap.PluginInfo in the output schema
+type PluginOutput struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version,omitempty"`
+	Declared  string   `json:"declared,omitempty"`
+	Official  bool     `json:"official"`
+	Platform  bool     `json:"platform"`
+	Plugin    bool     `json:"plugin"`
+	Android   bool     `json:"android"`
+	IOS       bool     `json:"ios"`
+	CoreRange string   `json:"coreRange,omitempty"`
+	Issues    []string `json:"issues,omitempty"`
+}
+
+func pluginReportOutput(r *cap.PluginReport) PluginReportOutput {
+	out := PluginReportOutput{
+		schemaHeader: newHeader("pluginReport"),
+		CoreVersion:  r.CoreVersion,
+		Plugins:      make([]PluginOutput, 0, len(r.Plugins)),
+		IssueCount:   r.IssueCount,
+	}
+	for _, p := range r.Plugins {
+		out.Plugins = append(out.Plugins, PluginOutput{
+			Name:      p.Name,
+			Version:   p.Version,
+			Declared:  p.Declared,
+			Official:  p.Official,
+			Platform:  p.Platform,
+			Plugin:    p.Plugin,
+			Android:   p.Android,
+			IOS:       p.IOS,
+			CoreRange: p.CoreRange,
+			Issues:    p.Issues,
+		})
+	}
+	return out
+}
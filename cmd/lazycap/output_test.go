@@ -0,0 +1,227 @@
+package lazycap
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/icarus-itcs/lazycap/internal/cap"
+)
+
+func TestWriteYAMLRoundTrip(t *testing.T) {
+	doc := map[string]interface{}{
+		"schemaVersion": 1,
+		"strings": []interface{}{
+			"plain", "", " padded ", "yes", "no", "on", "off", "y", "n", "true", "False", "null", "~",
+			".inf", "-.inf", ".nan", ".NaN", "1e3", "0x1F", "0o17", "012", "1_000", "3.14", "-1", "+1",
+			"1:30", "2024-01-01", "a: b", "# comment", "- item", "? key", "[x]", "{x}", "&anchor", "*alias",
+			"!tag", "|", ">", "'single'", "\"double\"", "%percent", "@at", "`tick`", "line\nbreak", "tab\there",
+			"back\\slash", "ümlaut", "emoji 📱", "<<",
+		},
+		"numbers": []interface{}{0, -3, 1.5, 1e21, 123456789012},
+		"bools":   []interface{}{true, false},
+		"nothing": nil,
+		"empty":   map[string]interface{}{},
+		"none":    []interface{}{},
+		"nested": map[string]interface{}{
+			"list": []interface{}{map[string]interface{}{"id": "emulator-5554", "online": true}, []interface{}{"a", []interface{}{}}},
+		},
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := writeYAML(&out, data); err != nil {
+		t.Fatal(err)
+	}
+
+	var fromYAML, fromJSON interface{}
+	if err := yaml.Unmarshal(out.Bytes(), &fromYAML); err != nil {
+		t.Fatalf("output is not YAML: %v\n%s", err, out.String())
+	}
+	if err := json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(normalize(fromYAML), normalize(fromJSON)) {
+		t.Errorf("YAML doesn't round-trip:\n%s", out.String())
+	}
+}
+
+func TestWriteYAMLKeepsKeyOrder(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeYAML(&out, []byte(`{"schemaVersion": 1, "command": "devices", "devices": [{"id": "a", "name": "Pixel"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	want := "schemaVersion: 1\ncommand: devices\ndevices:\n  - id: a\n    name: Pixel\n"
+	if out.String() != want {
+		t.Errorf("writeYAML() =\n%s\nwant\n%s", out.String(), want)
+	}
+	if strings.Contains(out.String(), "{") {
+		t.Errorf("writeYAML() used flow style:\n%s", out.String())
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite testdata/schema.golden")
+
+// TestOutputSchema pins the fields of every JSON/YAML document. A change to
+// testdata/schema.golden that renames or removes a field needs a
+// schemaVersion bump; regenerate it with go test -run OutputSchema -update.
+func TestOutputSchema(t *testing.T) {
+	documents := []struct {
+		kind string
+		doc  interface{}
+	}{
+		{"version", VersionOutput{}},
+		{"versionBump", VersionBumpOutput{}},
+		{"devices", DevicesOutput{}},
+		{"projects", ProjectsOutput{}},
+		{"pluginReport", PluginReportOutput{}},
+		{"preflight", PreflightOutput{}},
+		{"debugActions", ActionsOutput{}},
+		{"debugActionResult", ActionResultOutput{}},
+		{"commandResult", CommandResultOutput{}},
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "schemaVersion %d\n", schemaVersion)
+	for _, d := range documents {
+		fmt.Fprintf(&sb, "\n%s\n", d.kind)
+		describeSchema(t, &sb, "", reflect.TypeOf(d.doc))
+	}
+	got := sb.String()
+
+	golden := filepath.Join("testdata", "schema.golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("output schema changed; run go test -run OutputSchema -update if intended\ngot:\n%s", got)
+	}
+}
+
+func TestProjectOutputCopiesNativeProjects(t *testing.T) {
+	p := &cap.Project{
+		Name:    "App",
+		AppID:   "io.ionic.starter",
+		Android: &cap.AndroidProject{GradleFile: "android/app/build.gradle", ApplicationID: "io.ionic.starter", VersionCode: 3, BuildTypes: []string{"debug", "release"}},
+		IOS:     &cap.IOSProject{ProjectFile: "ios/App/App.xcodeproj/project.pbxproj", MarketingVersion: "1.2", Schemes: []string{"App"}},
+	}
+	data, err := json.Marshal(projectOutput(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"androidProject":{"gradleFile":"android/app/build.gradle","applicationId":"io.ionic.starter","versionCode":3,"buildTypes":["debug","release"]}`,
+		`"iosProject":{"projectFile":"ios/App/App.xcodeproj/project.pbxproj","marketingVersion":"1.2","schemes":["App"]}`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("projectOutput() = %s, want %s", data, want)
+		}
+	}
+
+	data, err = json.Marshal(pluginReportOutput(&cap.PluginReport{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"schemaVersion":1,"kind":"pluginReport","plugins":[],"issueCount":0}`; string(data) != want {
+		t.Errorf("pluginReportOutput() = %s, want %s", data, want)
+	}
+}
+
+// describeSchema writes one line per JSON field of typ: its path, JSON type
+// and whether it may be omitted. Output structs must be defined here so a
+// change in an internal package can't change the schema.
+func describeSchema(t *testing.T, sb *strings.Builder, prefix string, typ reflect.Type) {
+	t.Helper()
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.PkgPath() != "" && typ.PkgPath() != reflect.TypeOf(schemaHeader{}).PkgPath() {
+		t.Errorf("%s is %s; define an output struct for it", prefix, typ)
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" {
+			describeSchema(t, sb, prefix, field.Type)
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" || name == "-" {
+			t.Errorf("%s%s has no JSON name", prefix, field.Name)
+			continue
+		}
+		path := prefix + name
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		for ft.Kind() == reflect.Slice {
+			path += "[]"
+			ft = ft.Elem()
+		}
+		optional := ""
+		if opts == "omitempty" || field.Type.Kind() == reflect.Ptr {
+			optional = " (optional)"
+		}
+		switch ft.Kind() {
+		case reflect.Struct:
+			fmt.Fprintf(sb, "  %s object%s\n", path, optional)
+			describeSchema(t, sb, path+".", ft)
+		case reflect.String:
+			fmt.Fprintf(sb, "  %s string%s\n", path, optional)
+		case reflect.Bool:
+			fmt.Fprintf(sb, "  %s boolean%s\n", path, optional)
+		case reflect.Int, reflect.Int64, reflect.Float64:
+			fmt.Fprintf(sb, "  %s number%s\n", path, optional)
+		default:
+			t.Errorf("%s has unsupported type %s", path, field.Type)
+		}
+	}
+}
+
+// normalize makes decoded YAML and JSON comparable: numbers become float64
+// and maps map[string]interface{}
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, v := range t {
+			m[k] = normalize(v)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, v := range t {
+			s[i] = normalize(v)
+		}
+		return s
+	case int:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	default:
+		return v
+	}
+}
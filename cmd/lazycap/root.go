@@ -2,12 +2,15 @@ package lazycap
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/mattn/go-isatty"
@@ -15,6 +18,7 @@ import (
 
 	"github.com/icarus-itcs/lazycap/internal/cap"
 	"github.com/icarus-itcs/lazycap/internal/debug"
+	"github.com/icarus-itcs/lazycap/internal/device"
 	"github.com/icarus-itcs/lazycap/internal/plugin"
 	"github.com/icarus-itcs/lazycap/internal/plugins"
 	"github.com/icarus-itcs/lazycap/internal/settings"
@@ -26,6 +30,9 @@ var (
 	appCommit  string
 	appDate    string
 	demoMode   bool
+
+	bumpTag    bool
+	bumpDryRun bool
 )
 
 var rootCmd = &cobra.Command{
@@ -35,6 +42,14 @@ var rootCmd = &cobra.Command{
 Manage devices, emulators, builds, and live reload from one beautiful interface.
 
 Navigate to your Capacitor project directory and run 'lazycap' to get started.`,
+	SilenceErrors: true, // main prints the error once and picks the exit code
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkOutputFormat(); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if demoMode {
 			return runDemoMode()
@@ -46,10 +61,77 @@ Navigate to your Capacitor project directory and run 'lazycap' to get started.`,
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if structuredOutput() {
+			return writeDocument(VersionOutput{
+				schemaHeader: newHeader("version"),
+				Version:      appVersion,
+				Commit:       appCommit,
+				Date:         appDate,
+			})
+		}
 		fmt.Printf("lazycap %s\n", appVersion)
 		fmt.Printf("  commit: %s\n", appCommit)
 		fmt.Printf("  built:  %s\n", appDate)
+		return nil
+	},
+}
+
+var versionBumpCmd = &cobra.Command{
+	Use:       "bump [major|minor|patch|build]",
+	Short:     "Bump the app version in package.json, Gradle and Xcode",
+	Long:      "Bump the app version in package.json, android/app/build.gradle and the Xcode project together.\nEvery bump also increments the native build number (versionCode / CURRENT_PROJECT_VERSION).",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: cap.BumpParts,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := cap.LoadProject()
+		if err != nil {
+			return err
+		}
+
+		bump, err := cap.PlanVersionBump(project, args[0])
+		if err != nil {
+			return err
+		}
+
+		out := VersionBumpOutput{
+			schemaHeader: newHeader("versionBump"),
+			Part:         bump.Part,
+			OldVersion:   bump.OldVersion,
+			NewVersion:   bump.NewVersion,
+			OldBuild:     bump.OldBuild,
+			NewBuild:     bump.NewBuild,
+			Files:        make([]string, 0, len(bump.Changes)),
+		}
+		for _, change := range bump.Changes {
+			out.Files = append(out.Files, change.Path)
+		}
+
+		if !structuredOutput() {
+			fmt.Print(bump.Diff())
+			fmt.Printf("\n%s (%d) -> %s (%d)\n", bump.OldVersion, bump.OldBuild, bump.NewVersion, bump.NewBuild)
+		}
+		if !bumpDryRun {
+			if err := bump.Apply(); err != nil {
+				return err
+			}
+			out.Applied = true
+			if bumpTag {
+				tag, err := cap.TagVersion(project.RootDir, bump)
+				if err != nil {
+					return err
+				}
+				out.Tag = tag
+				if !structuredOutput() {
+					fmt.Printf("Tagged %s\n", tag)
+				}
+			}
+		}
+
+		if structuredOutput() {
+			return writeDocument(out)
+		}
+		return nil
 	},
 }
 
@@ -61,17 +143,110 @@ var devicesCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		userSettings, _ := settings.Load()
+		if structuredOutput() {
+			out := DevicesOutput{schemaHeader: newHeader("devices"), Devices: make([]DeviceOutput, 0, len(devices))}
+			for _, d := range devices {
+				out.Devices = append(out.Devices, deviceOutput(d, userSettings.DeviceAliases))
+			}
+			return writeDocument(out)
+		}
+		// Scripts read these columns; the detailed state is in the JSON output
 		for _, d := range devices {
 			status := "offline"
 			if d.Online {
 				status = "online"
 			}
-			fmt.Printf("%s\t%s\t%s\t%s\n", d.ID, d.Name, d.Platform, status)
+			line := fmt.Sprintf("%s\t%s\t%s\t%s", d.ID, d.Name, d.Platform, status)
+			if aliases := device.AliasesFor(d, userSettings.DeviceAliases); len(aliases) > 0 {
+				line += "\t" + strings.Join(aliases, ",")
+			}
+			fmt.Println(line)
+		}
+		return nil
+	},
+}
+
+var pluginsCmd = &cobra.Command{
+	Use:          "plugins",
+	Short:        "List Capacitor plugins and check their compatibility",
+	SilenceUsage: true, // Issues are reported through the exit code
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, err := cap.LoadProject()
+		if err != nil {
+			return err
+		}
+		report, err := cap.GetPluginReport(project)
+		if err != nil {
+			return err
+		}
+		if structuredOutput() {
+			if err := writeDocument(pluginReportOutput(report)); err != nil {
+				return err
+			}
+			if report.IssueCount > 0 {
+				return fmt.Errorf("%d plugin issue(s) found", report.IssueCount)
+			}
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PLUGIN\tVERSION\tPLATFORMS\tISSUES")
+		for _, p := range report.Plugins {
+			var platforms []string
+			if p.Android {
+				platforms = append(platforms, "android")
+			}
+			if p.IOS {
+				platforms = append(platforms, "ios")
+			}
+			version := p.Version
+			if version == "" {
+				version = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, version, strings.Join(platforms, ","), strings.Join(p.Issues, "; "))
+		}
+		_ = w.Flush()
+
+		if report.IssueCount > 0 {
+			return fmt.Errorf("%d plugin issue(s) found", report.IssueCount)
 		}
 		return nil
 	},
 }
 
+var projectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "List the Capacitor projects found from the current directory",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projects, err := cap.DiscoverProjects(4)
+		if err != nil && len(projects) == 0 {
+			return err
+		}
+		if structuredOutput() {
+			out := ProjectsOutput{schemaHeader: newHeader("projects"), Projects: make([]ProjectOutput, 0, len(projects))}
+			for _, p := range projects {
+				out.Projects = append(out.Projects, projectOutput(p))
+			}
+			return writeDocument(out)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tAPP ID\tPLATFORMS\tPATH")
+		for _, p := range projects {
+			var platforms []string
+			if p.HasAndroid {
+				platforms = append(platforms, "android")
+			}
+			if p.HasIOS {
+				platforms = append(platforms, "ios")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, p.AppID, strings.Join(platforms, ","), p.RootDir)
+		}
+		return w.Flush()
+	},
+}
+
 var mcpCmd = &cobra.Command{
 	Use:   "mcp",
 	Short: "Run MCP server for AI assistant integration",
@@ -95,13 +270,28 @@ Add to your Claude Code settings (~/.claude/settings.json):
 
 func init() {
 	rootCmd.AddCommand(versionCmd)
+	versionCmd.AddCommand(versionBumpCmd)
 	rootCmd.AddCommand(devicesCmd)
+	rootCmd.AddCommand(pluginsCmd)
+	rootCmd.AddCommand(projectsCmd)
 	rootCmd.AddCommand(mcpCmd)
+	rootCmd.AddCommand(runCmd, buildCmd, syncCmd, openCmd, doctorCmd, cleanCmd)
 
 	// Global flags
 	rootCmd.PersistentFlags().StringP("config", "c", "", "config file (default: .lazycap.yaml)")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "output format: table, json or yaml")
 	rootCmd.Flags().BoolVar(&demoMode, "demo", false, "run in demo mode with mock data (for screenshots)")
+
+	versionBumpCmd.Flags().BoolVar(&bumpTag, "tag", false, "commit the bumped files and create a git tag")
+	versionBumpCmd.Flags().BoolVar(&bumpDryRun, "dry-run", false, "print the diff without writing files")
+
+	for _, cmd := range []*cobra.Command{runCmd, buildCmd, syncCmd, openCmd} {
+		cmd.Flags().StringVarP(&headlessProject, "project", "p", "", "project directory or name (default: first discovered)")
+	}
+	runCmd.Flags().StringVarP(&runDevice, "device", "d", "", "device ID or name (default: the default device from settings)")
+	runCmd.Flags().BoolVarP(&runLive, "live", "l", false, "run with live reload and keep the dev server running")
+	cleanCmd.Flags().BoolVarP(&cleanYes, "yes", "y", false, "confirm dangerous actions")
 }
 
 func Execute(version, commit, date string) error {
@@ -168,7 +358,7 @@ func runApp() error {
 
 	// Initialize and run the TUI with plugin support (pass all discovered projects)
 	model := ui.NewModelWithProjects(projects, pluginManager, appContext, appVersion)
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithReportFocus())
 
 	// Handle graceful shutdown for plugins
 	sigCh := make(chan os.Signal, 1)
@@ -233,6 +423,7 @@ func runMCPServer() error {
 
 	// Load settings
 	userSettings, _ := settings.Load()
+	userSettings.ApplyToolEnv()
 
 	// Create a simple MCP context that doesn't need the full UI
 	mcpCtx := &mcpContext{
@@ -374,7 +565,7 @@ func handleToolsList(ctx *mcpContext) map[string]interface{} {
 		},
 		{
 			"name":        "run_on_device",
-			"description": "[Capacitor] Deploy and run the app using 'npx cap run'. Builds web assets, syncs to native platform, compiles with Xcode/Gradle, and launches on the target device/emulator.",
+			"description": "[Capacitor] Deploy and run the app using 'cap run' via the project's package manager (npx, pnpm exec, yarn, bunx). Builds web assets, syncs to native platform, compiles with Xcode/Gradle, and launches on the target device/emulator.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -384,23 +575,23 @@ func handleToolsList(ctx *mcpContext) map[string]interface{} {
 					},
 					"deviceId": map[string]interface{}{
 						"type":        "string",
-						"description": "Device ID from list_devices (e.g., 'iPhone-15-Pro' or 'emulator-5554')",
+						"description": "Device ID, alias or name from list_devices (e.g., 'emulator-5554', 'pixel' or 'iPhone 15'), or a rule like 'ios:simulator:booted' or 'android:newest'",
 					},
 					"platform": map[string]interface{}{
 						"type":        "string",
-						"description": "'ios' (uses Xcode), 'android' (uses Gradle), or 'web' (dev server)",
+						"description": "'ios' (uses Xcode) or 'android' (uses Gradle). Defaults to the device's platform.",
 					},
 					"liveReload": map[string]interface{}{
 						"type":        "boolean",
 						"description": "Enable live reload - app auto-refreshes when web code changes",
 					},
 				},
-				"required": []string{"deviceId", "platform"},
+				"required": []string{"deviceId"},
 			},
 		},
 		{
 			"name":        "sync",
-			"description": "[Capacitor] Run 'npx cap sync' to copy web assets (HTML/CSS/JS) to native iOS/Android projects and update native plugins. Required after npm install or web changes before native build.",
+			"description": "[Capacitor] Run 'cap sync' to copy web assets (HTML/CSS/JS) to native iOS/Android projects and update native plugins. Required after npm install or web changes before native build.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -417,7 +608,7 @@ func handleToolsList(ctx *mcpContext) map[string]interface{} {
 		},
 		{
 			"name":        "build",
-			"description": "[Web Build] Run 'npm run build' to compile and bundle the web application (Vue/React/Angular). Creates production-ready assets that get synced to native platforms.",
+			"description": "[Web Build] Run the project's build script to compile and bundle the web application (Vue/React/Angular). Creates production-ready assets that get synced to native platforms.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -430,7 +621,7 @@ func handleToolsList(ctx *mcpContext) map[string]interface{} {
 		},
 		{
 			"name":        "open_ide",
-			"description": "[Capacitor] Run 'npx cap open' to open the native project in its IDE. Opens Xcode for iOS (to edit Swift/Obj-C, configure signing, etc.) or Android Studio for Android (to edit Kotlin/Java, configure Gradle, etc.).",
+			"description": "[Capacitor] Run 'cap open' to open the native project in its IDE. Opens Xcode for iOS (to edit Swift/Obj-C, configure signing, etc.) or Android Studio for Android (to edit Kotlin/Java, configure Gradle, etc.).",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -446,6 +637,98 @@ func handleToolsList(ctx *mcpContext) map[string]interface{} {
 				"required": []string{"platform"},
 			},
 		},
+		{
+			"name":        "list_simulator_options",
+			"description": "[iOS Simulator] List installed iOS simulator runtimes, newest first, with the device types (iPhone/iPad models) each one supports. Use the names with create_simulator.",
+			"inputSchema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "create_simulator",
+			"description": "[iOS Simulator] Create a new iOS simulator with 'xcrun simctl create'. Returns the new simulator's UDID.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Simulator name. Defaults to the device type name.",
+					},
+					"runtime": map[string]interface{}{
+						"type":        "string",
+						"description": "Runtime name, version or identifier from list_simulator_options (e.g., 'iOS 17.2' or '17.2'). Defaults to the newest.",
+					},
+					"deviceType": map[string]interface{}{
+						"type":        "string",
+						"description": "Device type name or identifier from list_simulator_options (e.g., 'iPhone 15 Pro'). Defaults to the runtime's newest iPhone.",
+					},
+				},
+			},
+		},
+		{
+			"name":        "manage_simulator",
+			"description": "[iOS Simulator] Boot, shut down, clone, erase (all content and settings), rename or delete an iOS simulator. Cloning needs the simulator shut down.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"deviceId": map[string]interface{}{
+						"type":        "string",
+						"description": "Simulator UDID, alias or name from list_devices",
+					},
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"boot", "shutdown", "clone", "erase", "rename", "delete"},
+						"description": "What to do with the simulator",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "New name for clone and rename",
+					},
+				},
+				"required": []string{"deviceId", "action"},
+			},
+		},
+		{
+			"name":        "configure_simulator",
+			"description": "[iOS Simulator] Change a booted iOS simulator: dark/light appearance, locale (restarts the simulator), status bar overrides for screenshots, and simulated location. Only the given settings change.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"deviceId": map[string]interface{}{
+						"type":        "string",
+						"description": "Simulator UDID, alias or name from list_devices",
+					},
+					"appearance": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"dark", "light"},
+						"description": "Dark or light mode",
+					},
+					"locale": map[string]interface{}{
+						"type":        "string",
+						"description": "Language and region, e.g. 'de_DE' or 'ja_JP'",
+					},
+					"statusBar": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"clean", "clear"},
+						"description": "'clean' shows 9:41 with full battery and signal, 'clear' removes overrides",
+					},
+					"time": map[string]interface{}{
+						"type":        "string",
+						"description": "Status bar time for statusBar 'clean' (default 9:41)",
+					},
+					"batteryLevel": map[string]interface{}{
+						"type":        "integer",
+						"description": "Status bar battery percentage for statusBar 'clean' (default 100)",
+					},
+					"location": map[string]interface{}{
+						"type":        "string",
+						"description": "Simulated location as 'latitude,longitude' (e.g., '37.3349,-122.0090'), or 'clear'",
+					},
+				},
+				"required": []string{"deviceId"},
+			},
+		},
 		{
 			"name":        "get_project",
 			"description": "[Project Info] Get Capacitor project details: app name, app ID (bundle identifier), platforms configured, project root path, web directory, and capacitor.config settings.",
@@ -459,6 +742,19 @@ func handleToolsList(ctx *mcpContext) map[string]interface{} {
 				},
 			},
 		},
+		{
+			"name":        "get_plugin_report",
+			"description": "[Capacitor] List installed @capacitor/* and community Capacitor plugins with versions and supported platforms. Flags plugins whose major version doesn't match @capacitor/core or that are missing from the native Android/iOS projects (needs cap sync).",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"project": map[string]interface{}{
+						"type":        "string",
+						"description": "Project name or path from list_projects. Optional if only one project.",
+					},
+				},
+			},
+		},
 		{
 			"name":        "get_debug_actions",
 			"description": "[Debug Tools] List available debug/cleanup actions for troubleshooting: clear Xcode derived data, reset Android build cache, reinstall node_modules, clean Capacitor platforms, kill port processes.",
@@ -564,6 +860,11 @@ func handleToolsCall(ctx *mcpContext, params json.RawMessage) (interface{}, *mcp
 				"hasIOS":     p.HasIOS,
 				"hasAndroid": p.HasAndroid,
 			}
+			if p.Workspace != nil {
+				result[i]["workspace"] = p.Workspace.Root
+				result[i]["workspaceTool"] = p.Workspace.Tool
+				result[i]["workspacePackage"] = p.WorkspacePackage
+			}
 		}
 		return mcpContent(toJSON(result)), nil
 
@@ -579,7 +880,21 @@ func handleToolsCall(ctx *mcpContext, params json.RawMessage) (interface{}, *mcp
 				"name":       d.Name,
 				"platform":   d.Platform,
 				"online":     d.Online,
+				"state":      d.CurrentState(),
 				"isEmulator": d.IsEmulator,
+				"osVersion":  d.OSVersion,
+				"apiLevel":   d.APILevel,
+				"model":      d.Model,
+				"connection": d.Connection,
+			}
+			if d.BatteryLevel > 0 {
+				result[i]["batteryLevel"] = d.BatteryLevel
+			}
+			if d.Address != "" {
+				result[i]["address"] = d.Address
+			}
+			if aliases := device.AliasesFor(d, ctx.settings.DeviceAliases); len(aliases) > 0 {
+				result[i]["aliases"] = aliases
 			}
 		}
 		return mcpContent(toJSON(result)), nil
@@ -589,17 +904,46 @@ func handleToolsCall(ctx *mcpContext, params json.RawMessage) (interface{}, *mcp
 		deviceID, _ := call.Arguments["deviceId"].(string)
 		platform, _ := call.Arguments["platform"].(string)
 		liveReload, _ := call.Arguments["liveReload"].(bool)
-		if deviceID == "" || platform == "" {
-			return nil, &mcpError{Code: -32602, Message: "deviceId and platform required"}
+		if deviceID == "" {
+			return nil, &mcpError{Code: -32602, Message: "deviceId required"}
+		}
+		devices, err := cap.ListDevices()
+		if err != nil {
+			return nil, &mcpError{Code: -32000, Message: err.Error()}
+		}
+		dev, err := device.Resolve(devices, deviceID, ctx.settings.DeviceAliases)
+		if err != nil {
+			return nil, &mcpError{Code: -32000, Message: err.Error() + ". Use list_devices to see available devices."}
+		}
+		if platform == "" {
+			platform = dev.Platform
 		}
 		project := ctx.getProject(projectName)
 		if project == nil {
 			return nil, &mcpError{Code: -32000, Message: "No Capacitor project found. Use list_projects to see available projects."}
 		}
-		if err := cap.RunAt(project.RootDir, deviceID, platform, liveReload); err != nil {
-			return nil, &mcpError{Code: -32000, Message: err.Error()}
+		run := cap.RunOptions{
+			LiveReload:    liveReload,
+			Host:          ctx.settings.ResolvedLiveReloadHost(),
+			Port:          ctx.settings.ResolvedLiveReloadPort(),
+			Https:         ctx.settings.WebHttps,
+			Flavor:        ctx.settings.ResolvedAndroidFlavor(),
+			Scheme:        ctx.settings.ResolvedIOSScheme(),
+			Configuration: ctx.settings.IOSConfiguration,
+		}
+		switch {
+		case liveReload && ctx.settings.ADBReverse() && cap.CanReverse(dev):
+			reverse := cap.ADBReverse{Serial: dev.ID, Port: run.Port}
+			if err := reverse.Add(); err != nil {
+				return nil, &mcpError{Code: -32000, Message: err.Error()}
+			}
+			defer func() { _ = reverse.Remove() }()
+			run.Host = "localhost"
+		case liveReload && run.Host == "":
+			run.Host = cap.LiveReloadHost(dev)
 		}
-		return mcpContent(fmt.Sprintf("Started app '%s' on device %s", project.Name, deviceID)), nil
+		e, err := cap.RunAt(context.Background(), project.RootDir, dev.ID, platform, run, ctx.execOptions(ctx.settings.BuildTimeoutDuration()))
+		return mcpWait(e, err, fmt.Sprintf("Started app '%s' on %s (%s)", project.Name, dev.Name, dev.ID))
 
 	case "sync":
 		projectName, _ := call.Arguments["project"].(string)
@@ -608,14 +952,12 @@ func handleToolsCall(ctx *mcpContext, params json.RawMessage) (interface{}, *mcp
 		if project == nil {
 			return nil, &mcpError{Code: -32000, Message: "No Capacitor project found. Use list_projects to see available projects."}
 		}
-		if err := cap.SyncAt(project.RootDir, platform); err != nil {
-			return nil, &mcpError{Code: -32000, Message: err.Error()}
-		}
+		e, err := cap.SyncAt(context.Background(), project.RootDir, platform, ctx.settings.CopyWebDir, ctx.settings.UpdateNative, ctx.execOptions(ctx.settings.SyncTimeoutDuration()))
 		msg := fmt.Sprintf("Sync completed for '%s'", project.Name)
 		if platform != "" {
 			msg = fmt.Sprintf("Sync completed for '%s' (%s)", project.Name, platform)
 		}
-		return mcpContent(msg), nil
+		return mcpWait(e, err, msg)
 
 	case "build":
 		projectName, _ := call.Arguments["project"].(string)
@@ -623,10 +965,10 @@ func handleToolsCall(ctx *mcpContext, params json.RawMessage) (interface{}, *mcp
 		if project == nil {
 			return nil, &mcpError{Code: -32000, Message: "No Capacitor project found. Use list_projects to see available projects."}
 		}
-		if err := cap.BuildAt(project.RootDir); err != nil {
-			return nil, &mcpError{Code: -32000, Message: err.Error()}
-		}
-		return mcpContent(fmt.Sprintf("Build completed for '%s'", project.Name)), nil
+		opts := ctx.execOptions(ctx.settings.BuildTimeoutDuration())
+		opts.Env = ctx.settings.BuildEnvList()
+		e, err := cap.BuildAt(context.Background(), project.RootDir, opts)
+		return mcpWait(e, err, fmt.Sprintf("Build completed for '%s'", project.Name))
 
 	case "open_ide":
 		projectName, _ := call.Arguments["project"].(string)
@@ -638,10 +980,20 @@ func handleToolsCall(ctx *mcpContext, params json.RawMessage) (interface{}, *mcp
 		if project == nil {
 			return nil, &mcpError{Code: -32000, Message: "No Capacitor project found. Use list_projects to see available projects."}
 		}
-		if err := cap.OpenAt(project.RootDir, platform); err != nil {
-			return nil, &mcpError{Code: -32000, Message: err.Error()}
-		}
-		return mcpContent(fmt.Sprintf("Opened %s IDE for '%s'", platform, project.Name)), nil
+		e, err := cap.OpenAt(context.Background(), project.RootDir, platform, ctx.execOptions(time.Minute))
+		return mcpWait(e, err, fmt.Sprintf("Opened %s IDE for '%s'", platform, project.Name))
+
+	case "list_simulator_options":
+		return handleListSimulatorOptions()
+
+	case "create_simulator":
+		return handleCreateSimulator(call.Arguments)
+
+	case "manage_simulator":
+		return handleManageSimulator(ctx, call.Arguments)
+
+	case "configure_simulator":
+		return handleConfigureSimulator(ctx, call.Arguments)
 
 	case "get_project":
 		projectName, _ := call.Arguments["project"].(string)
@@ -650,15 +1002,39 @@ func handleToolsCall(ctx *mcpContext, params json.RawMessage) (interface{}, *mcp
 			return nil, &mcpError{Code: -32000, Message: "No Capacitor project found. Use list_projects to see available projects."}
 		}
 		result := map[string]interface{}{
-			"name":       project.Name,
-			"appId":      project.AppID,
-			"rootDir":    project.RootDir,
-			"webDir":     project.WebDir,
-			"hasIOS":     project.HasIOS,
-			"hasAndroid": project.HasAndroid,
+			"name":           project.Name,
+			"appId":          project.AppID,
+			"rootDir":        project.RootDir,
+			"webDir":         project.WebDir,
+			"hasIOS":         project.HasIOS,
+			"hasAndroid":     project.HasAndroid,
+			"configPath":     project.ConfigPath,
+			"config":         project.Config,
+			"packageManager": project.PackageManager,
+		}
+		if project.Android != nil {
+			result["android"] = project.Android
+		}
+		if project.IOS != nil {
+			result["ios"] = project.IOS
+		}
+		if len(project.ConfigWarnings) > 0 {
+			result["configWarnings"] = project.ConfigWarnings
 		}
 		return mcpContent(toJSON(result)), nil
 
+	case "get_plugin_report":
+		projectName, _ := call.Arguments["project"].(string)
+		project := ctx.getProject(projectName)
+		if project == nil {
+			return nil, &mcpError{Code: -32000, Message: "No Capacitor project found. Use list_projects to see available projects."}
+		}
+		report, err := cap.GetPluginReport(project)
+		if err != nil {
+			return nil, &mcpError{Code: -32000, Message: err.Error()}
+		}
+		return mcpContent(toJSON(report)), nil
+
 	case "get_debug_actions":
 		actions := debug.GetActions()
 		result := make([]map[string]interface{}, len(actions))
@@ -695,6 +1071,125 @@ func handleToolsCall(ctx *mcpContext, params json.RawMessage) (interface{}, *mcp
 	}
 }
 
+// resolveSimulator finds the iOS simulator a deviceId argument refers to
+func resolveSimulator(ctx *mcpContext, args map[string]interface{}) (*device.Device, *mcpError) {
+	deviceID, _ := args["deviceId"].(string)
+	if deviceID == "" {
+		return nil, &mcpError{Code: -32602, Message: "deviceId required"}
+	}
+	devices, err := cap.ListDevices()
+	if err != nil {
+		return nil, &mcpError{Code: -32000, Message: err.Error()}
+	}
+	dev, err := device.Resolve(devices, deviceID, ctx.settings.DeviceAliases)
+	if err != nil {
+		return nil, &mcpError{Code: -32000, Message: err.Error() + ". Use list_devices to see available devices."}
+	}
+	if dev.Platform != "ios" || !dev.IsEmulator {
+		return nil, &mcpError{Code: -32000, Message: fmt.Sprintf("%s is not an iOS simulator", dev.Name)}
+	}
+	return dev, nil
+}
+
+func handleListSimulatorOptions() (interface{}, *mcpError) {
+	runtimes, err := cap.ListSimRuntimes()
+	if err != nil {
+		return nil, &mcpError{Code: -32000, Message: err.Error()}
+	}
+	result := make([]map[string]interface{}, len(runtimes))
+	for i := range runtimes {
+		types, err := cap.ListSimDeviceTypes(&runtimes[i])
+		if err != nil {
+			return nil, &mcpError{Code: -32000, Message: err.Error()}
+		}
+		result[i] = map[string]interface{}{
+			"name":        runtimes[i].Name,
+			"version":     runtimes[i].Version,
+			"identifier":  runtimes[i].Identifier,
+			"deviceTypes": types,
+		}
+	}
+	return mcpContent(toJSON(result)), nil
+}
+
+func handleCreateSimulator(args map[string]interface{}) (interface{}, *mcpError) {
+	name, _ := args["name"].(string)
+	runtimeName, _ := args["runtime"].(string)
+	typeName, _ := args["deviceType"].(string)
+	runtime, err := cap.FindSimRuntime(runtimeName)
+	if err != nil {
+		return nil, &mcpError{Code: -32000, Message: err.Error()}
+	}
+	deviceType, err := cap.FindSimDeviceType(runtime, typeName)
+	if err != nil {
+		return nil, &mcpError{Code: -32000, Message: err.Error() + ". Use list_simulator_options to see device types."}
+	}
+	if name == "" {
+		name = deviceType.Name
+	}
+	udid, err := cap.CreateSimulator(name, deviceType.Identifier, runtime.Identifier)
+	if err != nil {
+		return nil, &mcpError{Code: -32000, Message: err.Error()}
+	}
+	return mcpContent(fmt.Sprintf("Created %s (%s, %s): %s", name, deviceType.Name, runtime.Name, udid)), nil
+}
+
+func handleManageSimulator(ctx *mcpContext, args map[string]interface{}) (interface{}, *mcpError) {
+	action, _ := args["action"].(string)
+	name, _ := args["name"].(string)
+	dev, mcpErr := resolveSimulator(ctx, args)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	msg, err := cap.ManageSimulator(dev.ID, action, name)
+	if err != nil {
+		return nil, &mcpError{Code: -32000, Message: err.Error()}
+	}
+	return mcpContent(fmt.Sprintf("%s: %s", dev.Name, msg)), nil
+}
+
+func handleConfigureSimulator(ctx *mcpContext, args map[string]interface{}) (interface{}, *mcpError) {
+	dev, mcpErr := resolveSimulator(ctx, args)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+
+	var s cap.SimulatorSettings
+	s.Appearance, _ = args["appearance"].(string)
+	s.Locale, _ = args["locale"].(string)
+	switch bar, _ := args["statusBar"].(string); bar {
+	case "":
+	case "clear":
+		s.ClearStatusBar = true
+	case "clean":
+		clean := cap.CleanStatusBar
+		if t, _ := args["time"].(string); t != "" {
+			clean.Time = t
+		}
+		if level, ok := args["batteryLevel"].(float64); ok {
+			clean.BatteryLevel = int(level)
+		}
+		s.StatusBar = &clean
+	default:
+		return nil, &mcpError{Code: -32602, Message: "statusBar must be 'clean' or 'clear'"}
+	}
+	if location, _ := args["location"].(string); location == "clear" {
+		s.ClearLocation = true
+	} else {
+		s.Location = location
+	}
+
+	done, err := cap.ConfigureSimulator(dev.ID, s)
+	if err != nil {
+		msg := err.Error()
+		if len(done) > 0 {
+			msg = fmt.Sprintf("%s (after: %s)", msg, strings.Join(done, ", "))
+		}
+		return nil, &mcpError{Code: -32000, Message: msg}
+	}
+	return mcpContent(fmt.Sprintf("%s: %s", dev.Name, strings.Join(done, ", "))), nil
+}
+
 // handleGetAllLogs reads and filters the lazycap debug log
 func handleGetAllLogs(args map[string]interface{}) (interface{}, *mcpError) {
 	logPath := "/tmp/lazycap-debug.log"
@@ -792,6 +1287,26 @@ func containsIgnoreCase(s, substr string) bool {
 	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
 
+// execOptions applies the active environment profile and a timeout to a tool command
+func (c *mcpContext) execOptions(timeout time.Duration) cap.ExecOptions {
+	return cap.ExecOptions{Timeout: timeout, Env: c.settings.EnvList()}
+}
+
+// mcpWait waits for a tool command to finish and returns the tail of its output.
+// Output is captured rather than written to stdout, which carries the protocol.
+func mcpWait(e *cap.Execution, err error, success string) (interface{}, *mcpError) {
+	if err != nil {
+		return nil, &mcpError{Code: -32000, Message: err.Error()}
+	}
+	if err := e.Wait(); err != nil {
+		return nil, &mcpError{Code: -32000, Message: fmt.Sprintf("%s failed: %v\n\n%s", e, err, e.Tail(50))}
+	}
+	if tail := e.Tail(50); tail != "" {
+		success += "\n\n" + tail
+	}
+	return mcpContent(success), nil
+}
+
 func mcpContent(text string) map[string]interface{} {
 	return map[string]interface{}{
 		"content": []map[string]interface{}{
@@ -0,0 +1,55 @@
+package cap
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/icarus-itcs/lazycap/internal/device"
+)
+
+// ADBReverse forwards a port on an Android device to the same port on this
+// machine, so the app reaches the live reload dev server at localhost over
+// USB or wireless adb without the dev server listening on the network
+type ADBReverse struct {
+	Serial string
+	Port   int
+}
+
+// CanReverse reports whether dev is an Android device or emulator adb
+// reverse works with
+func CanReverse(dev *device.Device) bool {
+	return dev != nil && dev.Platform == "android" && !dev.IsWeb
+}
+
+// String returns the forward as adb shows it, e.g. "tcp:8100"
+func (r ADBReverse) String() string {
+	return fmt.Sprintf("tcp:%d", r.Port)
+}
+
+// Add sets up the forward, replacing any existing one for the port
+func (r ADBReverse) Add() error {
+	return r.adb(r.String(), r.String())
+}
+
+// Remove tears the forward down
+func (r ADBReverse) Remove() error {
+	return r.adb("--remove", r.String())
+}
+
+func (r ADBReverse) adb(args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	args = append([]string{"-s", r.Serial, "reverse"}, args...)
+	output, err := exec.CommandContext(ctx, "adb", args...).CombinedOutput()
+	if err != nil {
+		text := strings.TrimSpace(string(output))
+		if text == "" {
+			text = err.Error()
+		}
+		return fmt.Errorf("adb reverse %s: %s", r, text)
+	}
+	return nil
+}
@@ -0,0 +1,417 @@
+package cap
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/icarus-itcs/lazycap/internal/device"
+)
+
+var avdNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// AVD is an Android Virtual Device
+type AVD struct {
+	Name          string
+	Path          string // The <name>.avd directory
+	APILevel      string
+	ABI           string   // e.g. arm64-v8a, x86_64
+	Tag           string   // System image tag, e.g. google_apis_playstore
+	SystemImage   string   // sdkmanager package, e.g. system-images;android-34;google_apis;arm64-v8a
+	DeviceProfile string   // Hardware profile, e.g. pixel_8
+	Snapshots     []string // Saved emulator snapshots
+}
+
+// SystemImage is an installed emulator system image
+type SystemImage struct {
+	Package  string // e.g. system-images;android-34;google_apis;arm64-v8a
+	APILevel string
+	Tag      string
+	ABI      string
+}
+
+// AVDBootOptions control how an emulator starts
+type AVDBootOptions struct {
+	ColdBoot bool   // Ignore the quick-boot snapshot
+	WipeData bool   // Factory reset the emulator's user data
+	Snapshot string // Start from this saved snapshot
+}
+
+// AndroidSDKRoot returns the Android SDK directory from ANDROID_HOME or
+// ANDROID_SDK_ROOT, or the default install location if it exists
+func AndroidSDKRoot() string {
+	for _, env := range []string{"ANDROID_HOME", "ANDROID_SDK_ROOT"} {
+		if dir := os.Getenv(env); dir != "" {
+			return dir
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	var dir string
+	switch runtime.GOOS {
+	case "darwin":
+		dir = filepath.Join(home, "Library", "Android", "sdk")
+	case "windows":
+		dir = filepath.Join(os.Getenv("LOCALAPPDATA"), "Android", "Sdk")
+	default:
+		dir = filepath.Join(home, "Android", "Sdk")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return ""
+	}
+	return dir
+}
+
+// androidTool finds an SDK tool on PATH or in the SDK (emulator,
+// avdmanager, sdkmanager), returning the bare name if it isn't found
+func androidTool(name string) string {
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	sdk := AndroidSDKRoot()
+	if sdk == "" {
+		return name
+	}
+	candidates := []string{
+		filepath.Join(sdk, "emulator", name),
+		filepath.Join(sdk, "cmdline-tools", "latest", "bin", name),
+		filepath.Join(sdk, "tools", "bin", name),
+	}
+	for _, path := range candidates {
+		if runtime.GOOS == "windows" {
+			if name != "emulator" {
+				path += ".bat"
+			} else {
+				path += ".exe"
+			}
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return name
+}
+
+// AVDHome returns the directory AVDs are stored in
+func AVDHome() string {
+	if dir := os.Getenv("ANDROID_AVD_HOME"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("ANDROID_USER_HOME"); dir != "" {
+		return filepath.Join(dir, "avd")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".android", "avd")
+}
+
+// ListAVDs reads the AVDs from the AVD home, sorted by name
+func ListAVDs() ([]AVD, error) {
+	home := AVDHome()
+	inis, err := filepath.Glob(filepath.Join(home, "*.ini"))
+	if err != nil {
+		return nil, err
+	}
+
+	avds := make([]AVD, 0, len(inis))
+	for _, ini := range inis {
+		name := strings.TrimSuffix(filepath.Base(ini), ".ini")
+		pointer := readINI(ini)
+		avd := AVD{Name: name, Path: pointer["path"]}
+		if avd.Path == "" || !dirExists(avd.Path) {
+			avd.Path = filepath.Join(home, name+".avd")
+		}
+		avd.APILevel = strings.TrimPrefix(pointer["target"], "android-")
+
+		config := readINI(filepath.Join(avd.Path, "config.ini"))
+		avd.ABI = config["abi.type"]
+		avd.Tag = config["tag.id"]
+		avd.DeviceProfile = config["hw.device.name"]
+		if sysdir := strings.Trim(filepath.ToSlash(config["image.sysdir.1"]), "/"); sysdir != "" {
+			avd.SystemImage = strings.ReplaceAll(sysdir, "/", ";")
+			if parts := strings.Split(sysdir, "/"); len(parts) >= 2 && avd.APILevel == "" {
+				avd.APILevel = strings.TrimPrefix(parts[1], "android-")
+			}
+		}
+
+		if entries, err := os.ReadDir(filepath.Join(avd.Path, "snapshots")); err == nil {
+			for _, e := range entries {
+				if e.IsDir() {
+					avd.Snapshots = append(avd.Snapshots, e.Name())
+				}
+			}
+		}
+		avds = append(avds, avd)
+	}
+
+	sort.Slice(avds, func(i, j int) bool { return avds[i].Name < avds[j].Name })
+	return avds, nil
+}
+
+// FindAVD returns the AVD with the given name
+func FindAVD(name string) (*AVD, error) {
+	avds, err := ListAVDs()
+	if err != nil {
+		return nil, err
+	}
+	for i := range avds {
+		if avds[i].Name == name {
+			return &avds[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no AVD named %s", name)
+}
+
+// readINI reads the key=value lines of an AVD .ini file
+func readINI(path string) map[string]string {
+	values := make(map[string]string)
+	f, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// ListSystemImages lists the installed system images, newest API first. The
+// SDK's system-images directory is read directly, so Java isn't needed.
+func ListSystemImages() ([]SystemImage, error) {
+	sdk := AndroidSDKRoot()
+	if sdk == "" {
+		return nil, fmt.Errorf("Android SDK not found: set ANDROID_HOME or the SDK Path setting")
+	}
+
+	dirs, err := filepath.Glob(filepath.Join(sdk, "system-images", "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	var images []SystemImage //nolint:prealloc // directories without an image are skipped
+	for _, dir := range dirs {
+		if !fileExists(filepath.Join(dir, "system.img")) && !fileExists(filepath.Join(dir, "source.properties")) {
+			continue
+		}
+		rel, _ := filepath.Rel(sdk, dir)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		images = append(images, SystemImage{
+			Package:  strings.Join(parts, ";"),
+			APILevel: strings.TrimPrefix(parts[1], "android-"),
+			Tag:      parts[2],
+			ABI:      parts[3],
+		})
+	}
+
+	sort.SliceStable(images, func(i, j int) bool {
+		return device.CompareVersions(images[i].APILevel, images[j].APILevel) > 0
+	})
+	return images, nil
+}
+
+// ListDeviceProfiles lists the hardware profile IDs avdmanager can create
+// AVDs for (pixel_8, medium_tablet, Galaxy Nexus, ...)
+func ListDeviceProfiles() ([]string, error) {
+	output, err := exec.Command(androidTool("avdmanager"), "list", "device").Output()
+	if err != nil {
+		return nil, fmt.Errorf("avdmanager list device: %w", err)
+	}
+	return parseDeviceProfiles(string(output)), nil
+}
+
+// deviceProfileLine matches the `id: 12 or "pixel_8"` line of a profile. The
+// quoted ID may contain spaces.
+var deviceProfileLine = regexp.MustCompile(`^\s*id:\s*\d+\s+or\s+"(.+)"\s*$`)
+
+func parseDeviceProfiles(output string) []string {
+	var profiles []string
+	for _, line := range strings.Split(output, "\n") {
+		if m := deviceProfileLine.FindStringSubmatch(strings.TrimRight(line, "\r")); m != nil {
+			profiles = append(profiles, m[1])
+		}
+	}
+	return profiles
+}
+
+// ValidAVDName reports whether name can be used for a new AVD
+func ValidAVDName(name string) error {
+	if !avdNamePattern.MatchString(name) {
+		return fmt.Errorf("AVD names may only contain letters, digits, '.', '_' and '-'")
+	}
+	if dirExists(filepath.Join(AVDHome(), name+".avd")) {
+		return fmt.Errorf("an AVD named %s already exists", name)
+	}
+	return nil
+}
+
+// CreateAVD creates an AVD from an installed system image and hardware profile
+func CreateAVD(ctx context.Context, name, systemImage, profile string, opts ExecOptions) (*Execution, error) {
+	if err := ValidAVDName(name); err != nil {
+		return nil, err
+	}
+	argv := []string{androidTool("avdmanager"), "create", "avd", "-n", name, "-k", systemImage}
+	if profile != "" {
+		argv = append(argv, "-d", profile)
+	}
+	// avdmanager asks whether to create a custom hardware profile; the closed
+	// stdin answers with the default (no)
+	return Start(ctx, argv, opts)
+}
+
+// DeleteAVD deletes an AVD and its data
+func DeleteAVD(ctx context.Context, name string, opts ExecOptions) (*Execution, error) {
+	return Start(ctx, []string{androidTool("avdmanager"), "delete", "avd", "-n", name}, opts)
+}
+
+// CloneAVD copies an AVD under a new name the way Android Studio's duplicate
+// does: the .avd directory is copied without its snapshots and lock files
+func CloneAVD(source, name string) error {
+	if err := ValidAVDName(name); err != nil {
+		return err
+	}
+	src, err := FindAVD(source)
+	if err != nil {
+		return err
+	}
+
+	home := AVDHome()
+	dst := filepath.Join(home, name+".avd")
+	if err := copyAVDDir(src.Path, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+
+	// Point the copy at its own name and directory
+	configPath := filepath.Join(dst, "config.ini")
+	if data, err := os.ReadFile(configPath); err == nil {
+		lines := strings.Split(string(data), "\n")
+		for i, line := range lines {
+			switch {
+			case strings.HasPrefix(line, "AvdId="):
+				lines[i] = "AvdId=" + name
+			case strings.HasPrefix(line, "avd.ini.displayname="):
+				lines[i] = "avd.ini.displayname=" + strings.ReplaceAll(name, "_", " ")
+			}
+		}
+		if err := os.WriteFile(configPath, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+			return err
+		}
+	}
+
+	pointer := fmt.Sprintf("avd.ini.encoding=UTF-8\npath=%s\npath.rel=avd/%s.avd\ntarget=android-%s\n", dst, name, src.APILevel)
+	return os.WriteFile(filepath.Join(home, name+".ini"), []byte(pointer), 0o644)
+}
+
+func copyAVDDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if info.IsDir() && rel == "snapshots" {
+			return filepath.SkipDir
+		}
+		if strings.HasSuffix(info.Name(), ".lock") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// EmulatorArgs returns the emulator arguments to start an AVD
+func EmulatorArgs(name string, opts AVDBootOptions) []string {
+	args := []string{"-avd", name}
+	if opts.ColdBoot {
+		args = append(args, "-no-snapshot-load")
+	}
+	if opts.WipeData {
+		args = append(args, "-wipe-data")
+	}
+	if opts.Snapshot != "" {
+		args = append(args, "-snapshot", opts.Snapshot)
+	}
+	return args
+}
+
+// BootAVD starts an emulator in the background without waiting for it to boot
+func BootAVD(name string, opts AVDBootOptions) error {
+	cmd := exec.Command(androidTool("emulator"), EmulatorArgs(name, opts)...)
+	return cmd.Start()
+}
+
+// SaveEmulatorSnapshot saves the state of a running emulator (by adb serial)
+// as a named snapshot
+func SaveEmulatorSnapshot(ctx context.Context, serial, snapshot string, opts ExecOptions) (*Execution, error) {
+	return Start(ctx, []string{"adb", "-s", serial, "emu", "avd", "snapshot", "save", snapshot}, opts)
+}
+
+// EmulatorSerial returns the adb serial (emulator-5554) of the running
+// emulator for an AVD, or "" when it isn't running
+func EmulatorSerial(avdName string) string {
+	output, err := exec.Command("adb", "devices").Output()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "emulator-") {
+			continue
+		}
+		name, err := exec.Command("adb", "-s", fields[0], "emu", "avd", "name").Output()
+		if err == nil && strings.TrimSpace(strings.SplitN(string(name), "\n", 2)[0]) == avdName {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+// StopEmulator shuts down a running emulator (by adb serial)
+func StopEmulator(ctx context.Context, serial string, opts ExecOptions) (*Execution, error) {
+	return Start(ctx, []string{"adb", "-s", serial, "emu", "kill"}, opts)
+}
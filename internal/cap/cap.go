@@ -1,12 +1,15 @@
 package cap
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,13 +32,24 @@ type Project struct {
 	HasIOS     bool
 	ConfigPath string
 	RootDir    string
-}
 
-// CapacitorConfig represents the capacitor.config.json/ts structure
-type CapacitorConfig struct {
-	AppID   string `json:"appId"`
-	AppName string `json:"appName"`
-	WebDir  string `json:"webDir"`
+	// PackageManager is the detected JS package manager (npm, yarn, pnpm, bun)
+	PackageManager PackageManager
+
+	// Workspace is the monorepo this project is a member of (nil if standalone)
+	Workspace *Workspace
+	// WorkspacePackage is the project's package name within Workspace
+	WorkspacePackage string
+
+	// Android and IOS hold details read from the native projects (nil if missing)
+	Android *AndroidProject
+	IOS     *IOSProject
+
+	// Config is the full parsed capacitor config (nil if it couldn't be read)
+	Config *CapacitorConfig
+	// ConfigWarnings lists config values that could not be resolved statically
+	// or had an unexpected type
+	ConfigWarnings []string
 }
 
 // IsCapacitorProject checks if current directory is a Capacitor project
@@ -71,7 +85,8 @@ func IsCapacitorProjectAt(dir string) bool {
 }
 
 // DiscoverProjects finds all Capacitor projects in the current directory and subdirectories
-// It searches up to maxDepth levels deep (default 3)
+// It searches up to maxDepth levels deep (default 3). Members of npm/yarn/pnpm/bun
+// workspaces, Nx and Turborepo monorepos are found at any depth.
 func DiscoverProjects(maxDepth int) ([]*Project, error) {
 	if maxDepth <= 0 {
 		maxDepth = 3
@@ -83,14 +98,40 @@ func DiscoverProjects(maxDepth int) ([]*Project, error) {
 	}
 
 	var projects []*Project
+	var workspaces []*Workspace
 	visited := make(map[string]bool)
 
-	// Check current directory first
-	if IsCapacitorProject() {
-		if p, err := LoadProject(); err == nil {
-			projects = append(projects, p)
-			visited[cwd] = true
+	// pm is the workspace's package manager for members, detected when empty
+	addProject := func(dir string, pm PackageManager) bool {
+		if visited[dir] || !IsCapacitorProjectAt(dir) {
+			return false
+		}
+		p, err := loadProjectAt(dir, pm)
+		if err != nil {
+			return false
+		}
+		projects = append(projects, p)
+		visited[dir] = true
+		return true
+	}
+	addWorkspace := func(ws *Workspace) {
+		for _, existing := range workspaces {
+			if existing.Root == ws.Root {
+				return
+			}
 		}
+		workspaces = append(workspaces, ws)
+		for _, pkg := range ws.Packages {
+			addProject(pkg.Dir, ws.PackageManager)
+		}
+	}
+
+	// Check current directory first
+	addProject(cwd, "")
+
+	// The current directory may be inside (or be the root of) a monorepo
+	if ws := FindWorkspace(cwd); ws != nil {
+		addWorkspace(ws)
 	}
 
 	// Search subdirectories
@@ -107,22 +148,24 @@ func DiscoverProjects(maxDepth int) ([]*Project, error) {
 			return filepath.SkipDir
 		}
 
-		fullPath := path
-		if visited[fullPath] {
-			return nil
+		if visited[path] {
+			return filepath.SkipDir
 		}
 
-		if IsCapacitorProjectAt(fullPath) {
-			if p, err := LoadProjectAt(fullPath); err == nil {
-				projects = append(projects, p)
-				visited[fullPath] = true
-				return filepath.SkipDir // Don't search inside found projects
-			}
+		// Nested workspace roots list their members explicitly
+		if ws, err := LoadWorkspace(path); err == nil {
+			addWorkspace(ws)
+		}
+
+		if addProject(path, "") {
+			return filepath.SkipDir // Don't search inside found projects
 		}
 
 		return nil
 	})
 
+	attachWorkspaces(projects, workspaces)
+
 	if err != nil {
 		return projects, err // Return what we found even if walk had errors
 	}
@@ -130,6 +173,53 @@ func DiscoverProjects(maxDepth int) ([]*Project, error) {
 	return projects, nil
 }
 
+// attachWorkspaces records which workspace package each project belongs to and
+// groups projects of the same workspace together, keeping discovery order otherwise
+func attachWorkspaces(projects []*Project, workspaces []*Workspace) {
+	for _, p := range projects {
+		for _, ws := range workspaces {
+			if pkg := ws.PackageFor(p.RootDir); pkg != nil {
+				p.Workspace = ws
+				p.WorkspacePackage = pkg.Name
+				break
+			}
+		}
+	}
+
+	groupOrder := make(map[string]int)
+	for _, p := range projects {
+		key := p.WorkspaceRoot()
+		if _, ok := groupOrder[key]; !ok {
+			groupOrder[key] = len(groupOrder)
+		}
+	}
+	sort.SliceStable(projects, func(i, j int) bool {
+		return groupOrder[projects[i].WorkspaceRoot()] < groupOrder[projects[j].WorkspaceRoot()]
+	})
+}
+
+// WorkspaceRoot returns the monorepo root the project belongs to ("" if standalone)
+func (p *Project) WorkspaceRoot() string {
+	if p.Workspace == nil {
+		return ""
+	}
+	return p.Workspace.Root
+}
+
+// BuildCommand returns the command line and working directory that build the
+// project's web assets, going through the workspace runner for monorepo members
+func (p *Project) BuildCommand() ([]string, string) {
+	if p.Workspace != nil && p.WorkspacePackage != "" {
+		pkg := &WorkspacePackage{Name: p.WorkspacePackage, Dir: p.RootDir}
+		return p.Workspace.RunScript(pkg, "build"), p.Workspace.Root
+	}
+	pm := p.PackageManager
+	if pm == "" {
+		pm = DetectPackageManager(p.RootDir)
+	}
+	return pm.RunScript("build"), p.RootDir
+}
+
 // walkDirWithDepth walks a directory tree with depth tracking
 func walkDirWithDepth(root string, currentDepth, maxDepth int, fn func(path string, d os.DirEntry, depth int) error) error {
 	if currentDepth > maxDepth {
@@ -171,23 +261,27 @@ func LoadProject() (*Project, error) {
 
 // LoadProjectAt loads a Capacitor project configuration from a specific directory
 func LoadProjectAt(dir string) (*Project, error) {
+	return loadProjectAt(dir, "")
+}
+
+// loadProjectAt loads the project at dir with package manager pm, detecting
+// it when empty. Workspace members pass the one detected at the root.
+func loadProjectAt(dir string, pm PackageManager) (*Project, error) {
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve path: %w", err)
 	}
+	if pm == "" {
+		pm = DetectPackageManager(absDir)
+	}
 
 	project := &Project{
-		RootDir: absDir,
+		RootDir:        absDir,
+		PackageManager: pm,
 	}
 
 	// Find and load config
-	configFiles := []string{
-		"capacitor.config.json",
-		"capacitor.config.ts",
-		"capacitor.config.js",
-	}
-
-	for _, f := range configFiles {
+	for _, f := range ConfigFiles {
 		path := filepath.Join(absDir, f)
 		if _, err := os.Stat(path); err == nil {
 			project.ConfigPath = path
@@ -199,24 +293,22 @@ func LoadProjectAt(dir string) (*Project, error) {
 		return nil, fmt.Errorf("no capacitor config found in %s", absDir)
 	}
 
-	// Try to get config via Capacitor CLI (handles ts/js configs)
-	config, err := getCapacitorConfigAt(absDir)
+	// Read the config statically; only fall back to the Capacitor CLI when
+	// the exported object can't be located at all
+	config, warnings, err := ParseConfigFile(project.ConfigPath)
 	if err != nil {
-		// Fallback to reading JSON directly
 		if strings.HasSuffix(project.ConfigPath, ".json") {
-			data, err := os.ReadFile(project.ConfigPath)
-			if err != nil {
-				return nil, fmt.Errorf("failed to read config: %w", err)
-			}
-			var cfg CapacitorConfig
-			if err := json.Unmarshal(data, &cfg); err != nil {
-				return nil, fmt.Errorf("failed to parse config: %w", err)
-			}
-			project.Name = cfg.AppName
-			project.AppID = cfg.AppID
-			project.WebDir = cfg.WebDir
+			return nil, err
 		}
-	} else {
+		config, warnings, err = getCapacitorConfigAt(absDir, pm)
+		if err != nil {
+			warnings = []string{fmt.Sprintf("%s: could not be parsed", filepath.Base(project.ConfigPath))}
+		}
+	}
+	project.Config = config
+	project.ConfigWarnings = warnings
+
+	if config != nil {
 		project.Name = config.AppName
 		project.AppID = config.AppID
 		project.WebDir = config.WebDir
@@ -230,6 +322,14 @@ func LoadProjectAt(dir string) (*Project, error) {
 		project.HasIOS = true
 	}
 
+	// Native project details (IDs, versions, flavors, schemes)
+	if project.HasAndroid {
+		project.Android, _ = LoadAndroidProject(filepath.Join(absDir, project.Config.AndroidDir()))
+	}
+	if project.HasIOS {
+		project.IOS, _ = LoadIOSProject(filepath.Join(absDir, project.Config.IOSDir()))
+	}
+
 	// Default name if not set
 	if project.Name == "" {
 		project.Name = filepath.Base(absDir)
@@ -238,21 +338,23 @@ func LoadProjectAt(dir string) (*Project, error) {
 	return project, nil
 }
 
-// getCapacitorConfigAt gets config using the Capacitor CLI from a specific directory
-func getCapacitorConfigAt(dir string) (*CapacitorConfig, error) {
-	cmd := exec.Command("npx", "cap", "config", "--json")
+// getCapacitorConfigAt gets config using the Capacitor CLI from a specific directory.
+// This requires Node and is only used when static parsing fails.
+func getCapacitorConfigAt(dir string, pm PackageManager) (*CapacitorConfig, []string, error) {
+	argv := pm.Exec("cap", "config", "--json")
+	cmd := exec.Command(argv[0], argv[1:]...)
 	cmd.Dir = dir
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	var config CapacitorConfig
-	if err := json.Unmarshal(output, &config); err != nil {
-		return nil, err
+	var raw map[string]interface{}
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, nil, err
 	}
-
-	return &config, nil
+	config, warnings := configFromMap(raw)
+	return config, warnings, nil
 }
 
 // ListDevices returns all available devices/emulators
@@ -299,6 +401,7 @@ func listAndroidDevices() ([]device.Device, error) {
 		return nil, err
 	}
 
+	var mdns map[string]string // Addresses of wireless debugging devices, looked up once
 	lines := strings.Split(string(output), "\n")
 	for _, line := range lines[1:] { // Skip header
 		line = strings.TrimSpace(line)
@@ -317,21 +420,52 @@ func listAndroidDevices() ([]device.Device, error) {
 		d := device.Device{
 			ID:         id,
 			Platform:   "android",
-			Online:     status == "device",
 			IsEmulator: strings.HasPrefix(id, "emulator"),
 		}
+		switch status {
+		case "device":
+			d.State = device.StateBooted
+		case "unauthorized":
+			d.State = device.StateUnauthorized
+		default: // offline, connecting, ...
+			if d.IsEmulator {
+				d.State = device.StateBooting
+			} else {
+				d.State = device.StateDisconnected
+			}
+		}
+		d.Online = d.State == device.StateBooted
+		if !d.IsEmulator {
+			d.Connection = "usb"
+			if isNetworkSerial(id) {
+				d.Connection = "wifi"
+				d.Address = id
+				if strings.Contains(id, "._tcp") {
+					if mdns == nil {
+						mdns = adbMDNSAddresses()
+					}
+					d.Address = mdns[strings.TrimSuffix(id, ".")]
+				}
+			}
+		}
 
 		// Try to get device name
 		for _, part := range parts[2:] {
 			if strings.HasPrefix(part, "model:") {
+				d.Model = strings.ReplaceAll(strings.TrimPrefix(part, "model:"), "_", " ")
 				d.Name = strings.TrimPrefix(part, "model:")
-				break
 			}
 			if strings.HasPrefix(part, "device:") {
-				d.Name = strings.TrimPrefix(part, "device:")
+				d.ModelID = strings.TrimPrefix(part, "device:")
+				if d.Name == "" {
+					d.Name = d.ModelID
+				}
 			}
 		}
 
+		if d.Online {
+			addAndroidProperties(&d)
+		}
 		if d.Name == "" {
 			d.Name = id
 		}
@@ -345,9 +479,10 @@ func listAndroidDevices() ([]device.Device, error) {
 	// Add offline emulators that aren't already running
 	for _, emu := range emulators {
 		found := false
-		for _, d := range devices {
+		for i, d := range devices {
 			if d.Name == emu.Name {
 				found = true
+				devices[i].ABI = emu.ABI
 				break
 			}
 		}
@@ -359,179 +494,212 @@ func listAndroidDevices() ([]device.Device, error) {
 	return devices, nil
 }
 
-// listAndroidEmulators lists available Android emulators
+// addAndroidProperties fills in the OS version, API level and model of an
+// online device, the AVD name of a running emulator and the battery level of
+// a physical device
+func addAndroidProperties(d *device.Device) {
+	script := "getprop ro.build.version.sdk; getprop ro.build.version.release; getprop ro.product.model"
+	if !d.IsEmulator {
+		script += "; dumpsys battery | grep level"
+	}
+	output, err := exec.Command("adb", "-s", d.ID, "shell", script).Output()
+	if err == nil {
+		lines := strings.Split(strings.ReplaceAll(string(output), "\r", ""), "\n")
+		for i, line := range lines {
+			line = strings.TrimSpace(line)
+			switch {
+			case i == 0:
+				d.APILevel = line
+			case i == 1:
+				d.OSVersion = line
+			case i == 2 && line != "":
+				d.Model = line
+			case strings.HasPrefix(line, "level:"):
+				d.BatteryLevel, _ = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "level:")))
+			}
+		}
+	}
+
+	// Running emulators are named after their AVD so they match -list-avds
+	if d.IsEmulator {
+		output, err := exec.Command("adb", "-s", d.ID, "emu", "avd", "name").Output()
+		if err == nil {
+			if name := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0]); name != "" && name != "OK" {
+				d.Name = name
+			}
+		}
+	}
+}
+
+// listAndroidEmulators lists available Android emulators with the API level,
+// ABI and hardware profile from their AVD config
 func listAndroidEmulators() ([]device.Device, error) { //nolint:unparam // error kept for API consistency
 	var devices []device.Device //nolint:prealloc // size unknown until parsing output
 
-	cmd := exec.Command("emulator", "-list-avds")
-	output, err := cmd.Output()
-	if err != nil {
-		return devices, nil // emulator command not available
+	avds, _ := ListAVDs()
+	byName := make(map[string]AVD, len(avds))
+	for _, avd := range avds {
+		byName[avd.Name] = avd
 	}
 
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		name := strings.TrimSpace(line)
-		if name == "" {
-			continue
+	var names []string
+	cmd := exec.Command(androidTool("emulator"), "-list-avds")
+	if output, err := cmd.Output(); err == nil {
+		for _, line := range strings.Split(string(output), "\n") {
+			// Newer emulators print INFO lines before the names
+			if name := strings.TrimSpace(line); name != "" && !strings.Contains(name, " ") {
+				names = append(names, name)
+			}
+		}
+	} else {
+		for _, avd := range avds {
+			names = append(names, avd.Name)
 		}
+	}
 
+	for _, name := range names {
+		avd := byName[name]
 		devices = append(devices, device.Device{
 			ID:         name,
 			Name:       name,
 			Platform:   "android",
 			Online:     false,
+			State:      device.StateShutdown,
 			IsEmulator: true,
+			APILevel:   avd.APILevel,
+			ABI:        avd.ABI,
+			ModelID:    avd.DeviceProfile,
 		})
 	}
 
 	return devices, nil
 }
 
-// listIOSDevices lists iOS devices and simulators
-func listIOSDevices() ([]device.Device, error) { //nolint:unparam // error kept for API consistency
-	var devices []device.Device
-
-	// Check if xcrun is available (macOS only)
-	if _, err := exec.LookPath("xcrun"); err != nil {
-		return devices, nil
-	}
-
-	// Get simulators
-	cmd := exec.Command("xcrun", "simctl", "list", "devices", "-j")
-	output, err := cmd.Output()
-	if err != nil {
-		return devices, nil
-	}
-
-	var result struct {
-		Devices map[string][]struct {
-			UDID        string `json:"udid"`
-			Name        string `json:"name"`
-			State       string `json:"state"`
-			IsAvailable bool   `json:"isAvailable"`
-		} `json:"devices"`
-	}
+// RunOptions are the cap run flags lazycap sets from its settings
+type RunOptions struct {
+	LiveReload    bool
+	Host          string // Live reload host (--host)
+	Port          int    // Live reload port (--port)
+	Https         bool   // Live reload server uses HTTPS (--https)
+	Flavor        string // Android product flavor (--flavor)
+	Scheme        string // iOS scheme (--scheme)
+	Configuration string // iOS build configuration (--configuration)
+	NoSync        bool   // The project was just synced (--no-sync)
+}
 
-	if err := json.Unmarshal(output, &result); err != nil {
-		return devices, nil
-	}
+// RunArgs returns the cap run arguments for a device
+func RunArgs(platform string, deviceID string, run RunOptions) []string {
+	args := []string{"cap", "run", platform, "--target", deviceID}
 
-	for runtime, sims := range result.Devices {
-		// Only include iOS simulators
-		if !strings.Contains(runtime, "iOS") {
-			continue
+	switch platform {
+	case "android":
+		if run.Flavor != "" {
+			args = append(args, "--flavor", run.Flavor)
 		}
-
-		for _, sim := range sims {
-			if !sim.IsAvailable {
-				continue
-			}
-
-			devices = append(devices, device.Device{
-				ID:         sim.UDID,
-				Name:       sim.Name,
-				Platform:   "ios",
-				Online:     sim.State == "Booted",
-				IsEmulator: true,
-			})
+	case "ios":
+		if run.Scheme != "" {
+			args = append(args, "--scheme", run.Scheme)
+		}
+		// Debug is Xcode's default; older CLIs don't know the flag
+		if run.Configuration != "" && run.Configuration != "Debug" {
+			args = append(args, "--configuration", run.Configuration)
 		}
 	}
 
-	return devices, nil
-}
-
-// Run runs the app on a device
-func Run(deviceID string, platform string, liveReload bool) error {
-	return RunAt("", deviceID, platform, liveReload)
-}
-
-// RunAt runs the app on a device from a specific project directory
-func RunAt(projectDir string, deviceID string, platform string, liveReload bool) error {
-	args := []string{"cap", "run", platform, "--target", deviceID}
-	if liveReload {
+	if run.LiveReload {
 		args = append(args, "-l")
+		if run.Port > 0 {
+			args = append(args, "--port", fmt.Sprintf("%d", run.Port))
+		}
+		if run.Host != "" {
+			args = append(args, "--host", run.Host)
+		}
+		if run.Https {
+			args = append(args, "--https")
+		}
 	}
-
-	cmd := exec.Command("npx", args...)
-	if projectDir != "" {
-		cmd.Dir = projectDir
+	if run.NoSync {
+		args = append(args, "--no-sync")
 	}
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return args
 }
 
-// Sync syncs web assets to native projects
-func Sync(platform string) error {
-	return SyncAt("", platform)
-}
-
-// SyncAt syncs web assets to native projects from a specific directory
-func SyncAt(projectDir string, platform string) error {
-	args := []string{"cap", "sync"}
+// SyncArgs returns the cap command that copies web assets and/or updates
+// native plugins: sync does both, copy and update one each. It returns nil
+// when both are off.
+func SyncArgs(platform string, copyWeb, updateNative bool) []string {
+	var args []string
+	switch {
+	case copyWeb && updateNative:
+		args = []string{"cap", "sync"}
+	case copyWeb:
+		args = []string{"cap", "copy"}
+	case updateNative:
+		args = []string{"cap", "update"}
+	default:
+		return nil
+	}
 	if platform != "" {
 		args = append(args, platform)
 	}
+	return args
+}
 
-	cmd := exec.Command("npx", args...)
-	if projectDir != "" {
-		cmd.Dir = projectDir
-	}
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// RunAt starts `cap run` for a device from a project directory
+func RunAt(ctx context.Context, projectDir string, deviceID string, platform string, run RunOptions, opts ExecOptions) (*Execution, error) {
+	return startAt(ctx, projectDir, DetectPackageManager(projectDir).Exec(RunArgs(platform, deviceID, run)...), opts)
 }
 
-// Build builds the web assets
-func Build() error {
-	return BuildAt("")
+// SyncAt starts `cap sync` (or copy/update) to bring web assets and plugins
+// into the native projects
+func SyncAt(ctx context.Context, projectDir string, platform string, copyWeb, updateNative bool, opts ExecOptions) (*Execution, error) {
+	args := SyncArgs(platform, copyWeb, updateNative)
+	if args == nil {
+		return nil, fmt.Errorf("nothing to sync: enable copyWebDir or updateNative")
+	}
+	return startAt(ctx, projectDir, DetectPackageManager(projectDir).Exec(args...), opts)
 }
 
-// BuildAt builds the web assets from a specific directory
-func BuildAt(projectDir string) error {
-	// Try common build commands
-	buildCmds := [][]string{
-		{"npm", "run", "build"},
-		{"yarn", "build"},
-		{"pnpm", "build"},
+// BuildAt starts the web build for a project directory
+func BuildAt(ctx context.Context, projectDir string, opts ExecOptions) (*Execution, error) {
+	argv, dir := buildCommandAt(projectDir)
+	if _, err := exec.LookPath(argv[0]); err != nil {
+		return nil, fmt.Errorf("%s not found in PATH", argv[0])
 	}
+	return startAt(ctx, dir, argv, opts)
+}
 
-	for _, args := range buildCmds {
-		if _, err := exec.LookPath(args[0]); err == nil {
-			cmd := exec.Command(args[0], args[1:]...)
-			if projectDir != "" {
-				cmd.Dir = projectDir
-			}
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			if err := cmd.Run(); err == nil {
-				return nil
-			}
+// buildCommandAt resolves the build command for a directory, using the
+// workspace runner when the directory is a monorepo member
+func buildCommandAt(projectDir string) ([]string, string) {
+	if ws := FindWorkspace(projectDir); ws != nil {
+		if pkg := ws.PackageFor(projectDir); pkg != nil {
+			return ws.RunScript(pkg, "build"), ws.Root
 		}
 	}
-
-	return fmt.Errorf("no build command found")
+	return DetectPackageManager(projectDir).RunScript("build"), projectDir
 }
 
-// Open opens the native project in the IDE
-func Open(platform string) error {
-	return OpenAt("", platform)
+// OpenAt starts `cap open` to open the native project in its IDE
+func OpenAt(ctx context.Context, projectDir string, platform string, opts ExecOptions) (*Execution, error) {
+	return startAt(ctx, projectDir, DetectPackageManager(projectDir).Exec("cap", "open", platform), opts)
 }
 
-// OpenAt opens the native project in the IDE from a specific directory
-func OpenAt(projectDir string, platform string) error {
-	cmd := exec.Command("npx", "cap", "open", platform)
-	if projectDir != "" {
-		cmd.Dir = projectDir
+// startAt starts argv in dir unless the options already name a directory
+func startAt(ctx context.Context, dir string, argv []string, opts ExecOptions) (*Execution, error) {
+	if opts.Dir == "" {
+		opts.Dir = dir
 	}
-	return cmd.Run()
+	return Start(ctx, argv, opts)
 }
 
 // BootDevice boots a simulator/emulator
 func BootDevice(deviceID string, platform string, isEmulator bool) error {
 	switch platform {
 	case "ios":
+		if !isEmulator {
+			return fmt.Errorf("cannot boot physical iOS device - please connect and unlock it")
+		}
 		// Boot iOS simulator using simctl
 		cmd := exec.Command("xcrun", "simctl", "boot", deviceID)
 		return cmd.Run()
@@ -539,10 +707,7 @@ func BootDevice(deviceID string, platform string, isEmulator bool) error {
 		if isEmulator {
 			// Start Android emulator in background
 			// deviceID for emulators is the AVD name
-			cmd := exec.Command("emulator", "-avd", deviceID, "-no-snapshot-load")
-			cmd.Stdout = nil
-			cmd.Stderr = nil
-			return cmd.Start() // Start in background, don't wait
+			return BootAVD(deviceID, AVDBootOptions{ColdBoot: true})
 		}
 		// Physical Android devices can't be "booted" from here
 		return fmt.Errorf("cannot boot physical Android device - please connect and enable USB debugging")
@@ -554,59 +719,56 @@ func BootDevice(deviceID string, platform string, isEmulator bool) error {
 func IsDeviceBooted(deviceID string, platform string) bool {
 	switch platform {
 	case "ios":
-		cmd := exec.Command("xcrun", "simctl", "list", "devices", "-j")
-		output, err := cmd.Output()
-		if err != nil {
-			return false
+		for _, sim := range listSimulators() {
+			if sim.ID == deviceID {
+				return sim.State == device.StateBooted
+			}
 		}
-		// Quick check - if deviceID and "Booted" appear near each other
-		return strings.Contains(string(output), deviceID) &&
-			strings.Contains(string(output), `"state" : "Booted"`)
 	case "android":
-		cmd := exec.Command("adb", "devices")
-		output, err := cmd.Output()
-		if err != nil {
-			return false
-		}
-		// Check if device ID appears with "device" status
-		lines := strings.Split(string(output), "\n")
-		for _, line := range lines {
-			if strings.Contains(line, deviceID) && strings.Contains(line, "device") {
-				return true
-			}
+		// Offline emulators are known by their AVD name
+		serial := deviceID
+		if s := EmulatorSerial(deviceID); s != "" {
+			serial = s
 		}
+		output, err := exec.Command("adb", "-s", serial, "shell", "getprop", "sys.boot_completed").Output()
+		return err == nil && strings.TrimSpace(string(output)) == "1"
 	}
 	return false
 }
 
-// CheckForUpgrade checks if a Capacitor upgrade is available
-func CheckForUpgrade() (*UpgradeInfo, error) {
-	info := &UpgradeInfo{}
-
-	// Get current installed version
-	cmd := exec.Command("npm", "list", "@capacitor/core", "--json")
-	output, err := cmd.Output()
-	if err != nil {
-		// Try to parse anyway, npm list returns error if not found
-		if len(output) == 0 {
-			return nil, fmt.Errorf("capacitor not installed")
+// BootedID returns the ID that addresses dev once it has booted. Android
+// emulators are listed by AVD name while shut down, but adb and cap run need
+// the running emulator's serial (emulator-5554).
+func BootedID(dev *device.Device) string {
+	if dev.Platform == "android" && dev.IsEmulator && !strings.HasPrefix(dev.ID, "emulator-") {
+		if serial := EmulatorSerial(dev.ID); serial != "" {
+			return serial
 		}
 	}
+	return dev.ID
+}
 
+// CheckForUpgrade checks if a Capacitor upgrade is available for the
+// project in dir. The installed version is read from node_modules and the
+// latest one is asked of the registry with the project's package manager.
+func CheckForUpgrade(dir string) (*UpgradeInfo, error) {
+	info := &UpgradeInfo{}
+
+	moduleDir := resolveModuleDir(dir, "@capacitor/core")
+	if moduleDir == "" {
+		return nil, fmt.Errorf("capacitor not installed")
+	}
 	var installed struct {
-		Dependencies map[string]struct {
-			Version string `json:"version"`
-		} `json:"dependencies"`
+		Version string `json:"version"`
 	}
-	if err := json.Unmarshal(output, &installed); err == nil {
-		if dep, ok := installed.Dependencies["@capacitor/core"]; ok {
-			info.CurrentVersion = dep.Version
-		}
+	if data, err := os.ReadFile(filepath.Join(moduleDir, "package.json")); err == nil && json.Unmarshal(data, &installed) == nil {
+		info.CurrentVersion = installed.Version
 	}
 
-	// Get latest version from npm
-	cmd = exec.Command("npm", "view", "@capacitor/core", "version")
-	output, err = cmd.Output()
+	argv := DetectPackageManager(dir).View("@capacitor/core", "version")
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
 	if err != nil {
 		return info, nil // Return what we have
 	}
@@ -620,15 +782,6 @@ func CheckForUpgrade() (*UpgradeInfo, error) {
 	return info, nil
 }
 
-// PerformUpgrade runs the Capacitor upgrade process
-func PerformUpgrade() error {
-	// Use npm to update capacitor packages
-	cmd := exec.Command("npm", "install", "@capacitor/core@latest", "@capacitor/cli@latest")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
 // WebDevOptions contains options for running the web dev server
 type WebDevOptions struct {
 	Command     string
@@ -641,90 +794,7 @@ type WebDevOptions struct {
 
 // DetectWebDevCommand detects the appropriate dev server command
 func DetectWebDevCommand() string {
-	// Check package.json for scripts
-	data, err := os.ReadFile("package.json")
-	if err != nil {
-		// No package.json, try common direct commands
-		return detectFallbackCommand()
-	}
-
-	var pkg struct {
-		Scripts      map[string]string `json:"scripts"`
-		Dependencies map[string]string `json:"dependencies"`
-		DevDeps      map[string]string `json:"devDependencies"`
-	}
-	if err := json.Unmarshal(data, &pkg); err != nil {
-		return detectFallbackCommand()
-	}
-
-	// Priority order of common dev scripts
-	scriptPriority := []string{
-		"dev",         // Vite, Nuxt, common
-		"serve",       // Vue CLI
-		"start",       // Create React App, general
-		"develop",     // Gatsby
-		"dev:web",     // Ionic
-		"ionic:serve", // Ionic
-		"watch",       // Some setups
-	}
-
-	for _, script := range scriptPriority {
-		if _, ok := pkg.Scripts[script]; ok {
-			return "npm run " + script
-		}
-	}
-
-	// No known script found, try to detect framework and use npx directly
-	allDeps := make(map[string]bool)
-	for k := range pkg.Dependencies {
-		allDeps[k] = true
-	}
-	for k := range pkg.DevDeps {
-		allDeps[k] = true
-	}
-
-	// Check for common frameworks
-	if allDeps["vite"] {
-		return "npx vite"
-	}
-	if allDeps["@ionic/cli"] {
-		return "npx ionic serve"
-	}
-	if allDeps["webpack-dev-server"] {
-		return "npx webpack serve"
-	}
-	if allDeps["parcel"] {
-		return "npx parcel"
-	}
-
-	return detectFallbackCommand()
-}
-
-// detectFallbackCommand tries to find a working dev command
-func detectFallbackCommand() string {
-	// Check if vite.config exists
-	viteConfigs := []string{"vite.config.js", "vite.config.ts", "vite.config.mjs"}
-	for _, cfg := range viteConfigs {
-		if _, err := os.Stat(cfg); err == nil {
-			return "npx vite"
-		}
-	}
-
-	// Check for ionic.config.json
-	if _, err := os.Stat("ionic.config.json"); err == nil {
-		return "npx ionic serve"
-	}
-
-	// Check for webpack.config
-	webpackConfigs := []string{"webpack.config.js", "webpack.config.ts"}
-	for _, cfg := range webpackConfigs {
-		if _, err := os.Stat(cfg); err == nil {
-			return "npx webpack serve"
-		}
-	}
-
-	// Default to vite as it's most common with Capacitor
-	return "npx vite"
+	return DetectDevServer("", "").String()
 }
 
 // RunWebDev starts the web development server
@@ -848,11 +918,10 @@ func KillPort(port int) bool {
 
 // RunShellCommand runs a shell command in the specified directory and returns output
 func RunShellCommand(dir string, command string) (string, error) {
-	cmd := exec.Command("sh", "-c", command)
-	if dir != "" {
-		cmd.Dir = dir
+	e, err := Start(context.Background(), []string{"sh", "-c", command}, ExecOptions{Dir: dir})
+	if err != nil {
+		return "", err
 	}
-
-	output, err := cmd.CombinedOutput()
-	return string(output), err
+	err = e.Wait()
+	return strings.Join(e.Output(), "\n"), err
 }
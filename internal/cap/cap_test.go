@@ -0,0 +1,509 @@
+package cap
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icarus-itcs/lazycap/internal/device"
+)
+
+func TestRunArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform string
+		run      RunOptions
+		want     []string
+	}{
+		{"android", "android", RunOptions{},
+			[]string{"cap", "run", "android", "--target", "dev1"}},
+		{"androidFlavor", "android", RunOptions{Flavor: "staging"},
+			[]string{"cap", "run", "android", "--target", "dev1", "--flavor", "staging"}},
+		{"flavor ignored on iOS", "ios", RunOptions{Flavor: "staging"},
+			[]string{"cap", "run", "ios", "--target", "dev1"}},
+		{"iosScheme", "ios", RunOptions{Scheme: "App Staging"},
+			[]string{"cap", "run", "ios", "--target", "dev1", "--scheme", "App Staging"}},
+		{"iosConfiguration Debug is the default", "ios", RunOptions{Configuration: "Debug"},
+			[]string{"cap", "run", "ios", "--target", "dev1"}},
+		{"iosConfiguration Release", "ios", RunOptions{Configuration: "Release"},
+			[]string{"cap", "run", "ios", "--target", "dev1", "--configuration", "Release"}},
+		{"scheme ignored on Android", "android", RunOptions{Scheme: "App", Configuration: "Release"},
+			[]string{"cap", "run", "android", "--target", "dev1"}},
+		{"live reload", "android", RunOptions{LiveReload: true},
+			[]string{"cap", "run", "android", "--target", "dev1", "-l"}},
+		{"liveReloadPort and externalHost", "android", RunOptions{LiveReload: true, Port: 8100, Host: "192.168.1.2"},
+			[]string{"cap", "run", "android", "--target", "dev1", "-l", "--port", "8100", "--host", "192.168.1.2"}},
+		{"webHttps", "ios", RunOptions{LiveReload: true, Port: 8100, Https: true},
+			[]string{"cap", "run", "ios", "--target", "dev1", "-l", "--port", "8100", "--https"}},
+		{"autoSync", "android", RunOptions{NoSync: true},
+			[]string{"cap", "run", "android", "--target", "dev1", "--no-sync"}},
+		{"host and port need live reload", "ios", RunOptions{Port: 8100, Host: "10.0.0.1", Https: true},
+			[]string{"cap", "run", "ios", "--target", "dev1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RunArgs(tt.platform, "dev1", tt.run); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RunArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncArgs(t *testing.T) {
+	tests := []struct {
+		name                  string
+		platform              string
+		copyWeb, updateNative bool
+		want                  []string
+	}{
+		{"copyWebDir and updateNative", "", true, true, []string{"cap", "sync"}},
+		{"copyWebDir only", "", true, false, []string{"cap", "copy"}},
+		{"updateNative only", "", false, true, []string{"cap", "update"}},
+		{"neither", "", false, false, nil},
+		{"platform", "ios", true, true, []string{"cap", "sync", "ios"}},
+		{"platform with copy", "android", true, false, []string{"cap", "copy", "android"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SyncArgs(tt.platform, tt.copyWeb, tt.updateNative); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SyncArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConfigSourceMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{"stray paren in object", "export default { ) }", map[string]interface{}{}, false},
+		{"stray paren in array", "export default { appId: [ ) ] }", map[string]interface{}{"appId": []interface{}{}}, false},
+		{"stray bracket in object", "export default { appId: 'a', ] webDir: 'www' }", map[string]interface{}{"appId": "a", "webDir": "www"}, false},
+		{"stray brace in array", "export default { plugins: [1, }, 2] }", map[string]interface{}{"plugins": []interface{}{1.0, 2.0}}, false},
+		{"semicolon in array", "export default { a: [ ; ] }", map[string]interface{}{"a": []interface{}{}}, false},
+		{"unclosed object", "export default { appId: 'a', server: { url: 'x'", map[string]interface{}{"appId": "a", "server": map[string]interface{}{"url": "x"}}, false},
+		{"unclosed array", "export default { a: [1, 2", map[string]interface{}{"a": []interface{}{1.0, 2.0}}, false},
+		{"unclosed string", "export default { appId: 'a", map[string]interface{}{}, false},
+		{"closers only", "export default { ) ] ) ] ", map[string]interface{}{}, false},
+		{"self reference", "export default config;\nconst config = config;", nil, true},
+		{"empty", "", nil, true},
+		{"not an object", "export default 42", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan struct{})
+			var got map[string]interface{}
+			var err error
+			go func() {
+				defer close(done)
+				got, _, err = ParseConfigSource(tt.src)
+			}()
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("ParseConfigSource(%q) did not return", tt.src)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseConfigSource(%q) error = %v, wantErr %v", tt.src, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseConfigSource(%q) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConfigSourceScaffolds(t *testing.T) {
+	app := map[string]interface{}{"appId": "com.example.app", "appName": "Example", "webDir": "dist"}
+	tests := []struct {
+		name     string
+		src      string
+		want     map[string]interface{}
+		warnings int
+	}{
+		{"ionic scaffold without semicolons", `import type { CapacitorConfig } from '@capacitor/cli'
+
+const config: CapacitorConfig = {
+  appId: 'com.example.app',
+  appName: 'Example',
+  webDir: 'dist'
+}
+
+export default config
+`, app, 0},
+		{"ionic scaffold with semicolons", `import type { CapacitorConfig } from '@capacitor/cli';
+
+const config: CapacitorConfig = {
+  appId: 'com.example.app',
+  appName: 'Example',
+  webDir: 'dist',
+};
+
+export default config;
+`, app, 0},
+		{"defineConfig", `import { defineConfig } from '@capacitor/cli'
+
+export default defineConfig({
+  appId: 'com.example.app',
+  appName: 'Example',
+  webDir: 'dist',
+})
+`, app, 0},
+		{"constant references without semicolons", `const appId = 'com.example.app'
+const appName = "Example" // shown on the home screen
+const port = 8100
+
+export default {
+  appId,
+  appName: appName,
+  webDir: 'dist',
+  server: { port }
+} satisfies CapacitorConfig
+`, map[string]interface{}{"appId": "com.example.app", "appName": "Example", "webDir": "dist", "server": map[string]interface{}{"port": 8100.0}}, 0},
+		{"commonjs", `/** @type {import('@capacitor/cli').CapacitorConfig} */
+const config = {
+  appId: 'com.example.app',
+  appName: 'Example',
+  webDir: 'dist'
+}
+
+module.exports = config
+`, app, 0},
+		{"expression continued on the next line", `const webDir = 'di'
+  + 'st'
+export default { appId: 'com.example.app', webDir }
+`, map[string]interface{}{"appId": "com.example.app"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings, err := ParseConfigSource(tt.src)
+			if err != nil {
+				t.Fatalf("ParseConfigSource() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseConfigSource() = %v, want %v", got, tt.want)
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("warnings = %v, want %d", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestMigrationClone(t *testing.T) {
+	mig := &Migration{
+		Packages: []string{"@capacitor/core"},
+		Steps:    []MigrationStep{{ID: StepCleanTree, Status: StepPending}},
+		Changes:  []string{"ios/App/Podfile"},
+	}
+	clone := mig.Clone()
+	clone.Packages[0] = "@capacitor/cli"
+	clone.Steps[0].Status = StepDone
+	clone.Changes[0] = "android/build.gradle"
+
+	if mig.Packages[0] != "@capacitor/core" || mig.Steps[0].Status != StepPending || mig.Changes[0] != "ios/App/Podfile" {
+		t.Errorf("changing the clone changed the original: %+v", mig)
+	}
+}
+
+func TestInspectPlugin(t *testing.T) {
+	root := t.TempDir()
+	packages := map[string]string{
+		"@capacitor/core":         `{"version": "6.1.0"}`,
+		"@capacitor/camera":       `{"version": "6.0.0", "capacitor": {"android": {}, "ios": {}}, "peerDependencies": {"@capacitor/core": "^6.0.0"}}`,
+		"@capacitor/assets":       `{"version": "3.0.5"}`,
+		"@capacitor/docgen":       `{"version": "0.2.2"}`,
+		"capacitor-web-only":      `{"version": "1.0.0", "peerDependencies": {"@capacitor/core": ">=5.0.0"}}`,
+		"@capawesome/capacitor-x": `{"version": "2.0.0", "capacitor": {"ios": {}}}`,
+		"left-pad":                `{"version": "1.3.0"}`,
+	}
+	for name, pkg := range packages {
+		dir := filepath.Join(root, "node_modules", filepath.FromSlash(name))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(pkg), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry := "include ':capacitor-haptics'\nproject(':capacitor-haptics').projectDir = new File('../node_modules/@capacitor/haptics/android')\n"
+
+	tests := []struct {
+		name                   string
+		ok, official, platform bool
+		plugin, android, ios   bool
+	}{
+		{"@capacitor/core", true, true, true, false, false, false},
+		{"@capacitor/camera", true, true, false, true, true, true},
+		{"@capacitor/assets", false, true, false, false, false, false},
+		{"@capacitor/docgen", false, true, false, false, false, false},
+		{"capacitor-web-only", true, false, false, true, false, false},
+		{"@capawesome/capacitor-x", true, false, false, true, false, true},
+		{"left-pad", false, false, false, false, false, false},
+		{"@capacitor/haptics", true, true, false, true, false, false},  // Not installed, but registered
+		{"@capacitor/splash", false, true, false, false, false, false}, // Not installed or registered
+		{"@capacitor/ios", true, true, true, false, false, false},      // Not installed
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, ok := inspectPlugin(root, tt.name, "*", registry)
+			got := []bool{ok, info.Official, info.Platform, info.Plugin, info.Android, info.IOS}
+			want := []bool{tt.ok, tt.official, tt.platform, tt.plugin, tt.android, tt.ios}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("inspectPlugin(%s) ok/official/platform/plugin/android/ios = %v, want %v", tt.name, got, want)
+			}
+		})
+	}
+}
+
+func TestParseDeviceProfiles(t *testing.T) {
+	output := `Available devices definitions:
+id: 0 or "automotive_1024p_landscape"
+    Name: Automotive (1024p landscape)
+    OEM : Google
+    Tag : android-automotive-playstore
+---------
+id: 5 or "Galaxy Nexus"
+    Name: Galaxy Nexus
+    OEM : Google
+---------
+id: 31 or "pixel_8"
+    Name: Pixel 8
+    OEM : Google
+---------
+id: 40 or "Nexus 7 2013"
+    Name: Nexus 7 (2013)
+`
+	want := []string{"automotive_1024p_landscape", "Galaxy Nexus", "pixel_8", "Nexus 7 2013"}
+	if got := parseDeviceProfiles(strings.ReplaceAll(output, "\n", "\r\n")); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDeviceProfiles() = %q, want %q", got, want)
+	}
+	if got := parseDeviceProfiles("Error: no SDK\n"); got != nil {
+		t.Errorf("parseDeviceProfiles(error) = %q, want none", got)
+	}
+}
+
+func TestPlanVersionBumpTopLevelVersion(t *testing.T) {
+	dir := t.TempDir()
+	pkg := `{
+  "name": "app",
+  "engines": { "node": ">=18" },
+  "overrides": {
+    "left-pad": {
+      "version": "1.3.0"
+    }
+  },
+  "description": "has { and [ in a string",
+  "version": "2.4.1",
+  "config": {
+    "version": "9.9.9"
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(pkg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	bump, err := PlanVersionBump(&Project{RootDir: dir}, BumpMinor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bump.OldVersion != "2.4.1" || bump.NewVersion != "2.5.0" {
+		t.Errorf("version %s → %s, want 2.4.1 → 2.5.0", bump.OldVersion, bump.NewVersion)
+	}
+	want := []LineChange{{Line: 10, Old: `  "version": "2.4.1",`, New: `  "version": "2.5.0",`}}
+	if len(bump.Changes) != 1 || !reflect.DeepEqual(bump.Changes[0].Lines, want) {
+		t.Errorf("changes = %+v, want %+v", bump.Changes, want)
+	}
+}
+
+func TestTagVersionCommitsOnlyBumpedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("package.json", "{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\"\n}\n")
+	write("notes.txt", "draft\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	write("notes.txt", "work in progress\n")
+	git("add", "notes.txt")
+
+	bump, err := PlanVersionBump(&Project{RootDir: dir}, BumpPatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bump.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := TagVersion(dir, bump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag != "v1.0.1" {
+		t.Errorf("tag = %s, want v1.0.1", tag)
+	}
+	if files := git("show", "--name-only", "--format=", "HEAD"); files != "package.json" {
+		t.Errorf("release commit has %q, want only package.json", files)
+	}
+	if staged := git("diff", "--cached", "--name-only"); staged != "notes.txt" {
+		t.Errorf("staged after release = %q, want notes.txt", staged)
+	}
+}
+
+func TestNewLiveReload(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"devDependencies": {"vite": "^5.0.0"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	android := &device.Device{ID: "emulator-5554", Platform: "android", IsEmulator: true}
+	ios := &device.Device{ID: "ABCD", Platform: "ios"}
+
+	tests := []struct {
+		name    string
+		dev     *device.Device
+		run     RunOptions
+		opts    LiveReloadOptions
+		argv    []string
+		run2    RunOptions
+		reverse bool
+	}{
+		{"default port", ios, RunOptions{}, LiveReloadOptions{},
+			[]string{"npx", "vite", "--host", "0.0.0.0", "--port", "8100", "--strictPort"},
+			RunOptions{LiveReload: true, Port: 8100}, false},
+		{"host and port", ios, RunOptions{Host: "192.168.1.2", Port: 5173}, LiveReloadOptions{},
+			[]string{"npx", "vite", "--host", "0.0.0.0", "--port", "5173", "--strictPort"},
+			RunOptions{LiveReload: true, Host: "192.168.1.2", Port: 5173}, false},
+		{"adb reverse", android, RunOptions{Host: "192.168.1.2", Port: 8100}, LiveReloadOptions{ADBReverse: true},
+			[]string{"npx", "vite", "--host", "127.0.0.1", "--port", "8100", "--strictPort"},
+			RunOptions{LiveReload: true, Host: "localhost", Port: 8100}, true},
+		{"adb reverse needs android", ios, RunOptions{Port: 8100}, LiveReloadOptions{ADBReverse: true},
+			[]string{"npx", "vite", "--host", "0.0.0.0", "--port", "8100", "--strictPort"},
+			RunOptions{LiveReload: true, Port: 8100}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lr, err := NewLiveReload(dir, tt.dev, tt.run, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(lr.Argv, tt.argv) {
+				t.Errorf("Argv = %q, want %q", lr.Argv, tt.argv)
+			}
+			if lr.Run != tt.run2 {
+				t.Errorf("Run = %+v, want %+v", lr.Run, tt.run2)
+			}
+			if (lr.Reverse != nil) != tt.reverse {
+				t.Errorf("Reverse = %v, want %v", lr.Reverse, tt.reverse)
+			}
+			if lr.Proxy != nil {
+				t.Errorf("Proxy = %+v without HTTPS", lr.Proxy)
+			}
+		})
+	}
+}
+
+func TestConfigFromMapTolerant(t *testing.T) {
+	raw, _, err := ParseConfigSource(`export default {
+  appId: 'com.example.app',
+  appName: 42,
+  webDir: 'dist',
+  bundledWebRuntime: 'no',
+  server: { url: 'http://10.0.0.2:8100', cleartext: 'yes', allowNavigation: ['a.com', 3] },
+  android: { flavor: 'dev', buildOptions: 'release' },
+  ios: { webContentsDebuggingEnabled: true, scheme: ['App'] },
+  plugins: { SplashScreen: { launchShowDuration: 0 } },
+  cordova: { preferences: { ScrollEnabled: 'false' } },
+  includePlugins: ['@capacitor/camera'],
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, warnings := configFromMap(raw)
+
+	if config.AppID != "com.example.app" || config.WebDir != "dist" || config.Server.URL != "http://10.0.0.2:8100" || config.Android.Flavor != "dev" {
+		t.Errorf("good values lost: %+v", config)
+	}
+	if config.IOS.WebContentsDebuggingEnabled == nil || !*config.IOS.WebContentsDebuggingEnabled {
+		t.Errorf("ios.webContentsDebuggingEnabled lost")
+	}
+	if config.AppName != "" || config.Server.Cleartext || config.Server.AllowNavigation != nil || config.IOS.Scheme != "" {
+		t.Errorf("bad values kept: %+v", config)
+	}
+	if config.PluginConfig("SplashScreen")["launchShowDuration"] != 0.0 {
+		t.Errorf("plugins = %v", config.Plugins)
+	}
+	if _, ok := config.Extra["cordova"].(map[string]interface{}); !ok || config.Extra["includePlugins"] == nil || len(config.Extra) != 2 {
+		t.Errorf("Extra = %v, want cordova and includePlugins", config.Extra)
+	}
+
+	want := []string{
+		"android.buildOptions: expected an object, got a string; ignored",
+		"appName: expected a string, got a number; ignored",
+		"bundledWebRuntime: expected a boolean, got a string; ignored",
+		"ios.scheme: expected a string, got a list; ignored",
+		"server.allowNavigation: expected a list of strings, got a list; ignored",
+		"server.cleartext: expected a boolean, got a string; ignored",
+	}
+	sort.Strings(warnings)
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings =\n%s\nwant\n%s", strings.Join(warnings, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLockfile(t *testing.T) {
+	tests := []struct {
+		pm    PackageManager
+		files []string
+		want  string
+	}{
+		{Bun, nil, "bun.lock"},
+		{Bun, []string{"bun.lockb"}, "bun.lockb"},
+		{Bun, []string{"bun.lock"}, "bun.lock"},
+		{NPM, nil, "package-lock.json"},
+		{NPM, []string{"npm-shrinkwrap.json"}, "npm-shrinkwrap.json"},
+		{PNPM, []string{"pnpm-workspace.yaml"}, "pnpm-lock.yaml"},
+		{Yarn, nil, "yarn.lock"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for _, name := range tt.files {
+			if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := tt.pm.Lockfile(dir); got != tt.want {
+			t.Errorf("%s.Lockfile(%v) = %q, want %q", tt.pm, tt.files, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package cap
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icarus-itcs/lazycap/internal/device"
+)
+
+// File names in CertDir
+const (
+	caCertFile  = "lazycap-ca.pem"
+	caKeyFile   = "lazycap-ca-key.pem"
+	devCertFile = "dev.pem"
+	devKeyFile  = "dev-key.pem"
+)
+
+// devCertValidity stays under the 398 days Apple platforms accept for
+// server certificates
+const devCertValidity = 397 * 24 * time.Hour
+
+// DevCert is a certificate and key for an HTTPS dev server, issued by
+// lazycap's local CA
+type DevCert struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string   // The local CA, to trust on simulators and emulators
+	Hosts    []string // Names and addresses the certificate is valid for
+}
+
+// CertDir returns where the local CA and dev certificate are kept
+func CertDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "lazycap", "certs"), nil
+}
+
+// CAFile returns the local CA certificate, creating the CA on first use
+func CAFile() (string, error) {
+	dir, err := CertDir()
+	if err != nil {
+		return "", err
+	}
+	if _, _, err := loadCA(dir); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, caCertFile), nil
+}
+
+// DevCertHosts returns the hosts a dev certificate needs: localhost, this
+// machine's network addresses and any extra hosts that are set
+func DevCertHosts(extra ...string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	for _, iface := range LANInterfaces() {
+		hosts = append(hosts, iface.IP)
+	}
+	for _, h := range extra {
+		if h != "" && h != "0.0.0.0" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+// IssueDevCert returns a certificate for hosts signed by the local CA. The one
+// issued before is reused while it covers the hosts and has a month left, so
+// devices that trust the CA keep working across runs.
+func IssueDevCert(hosts []string) (*DevCert, error) {
+	dir, err := CertDir()
+	if err != nil {
+		return nil, err
+	}
+	caCert, caKey, err := loadCA(dir)
+	if err != nil {
+		return nil, err
+	}
+	cert := &DevCert{
+		CertFile: filepath.Join(dir, devCertFile),
+		KeyFile:  filepath.Join(dir, devKeyFile),
+		CAFile:   filepath.Join(dir, caCertFile),
+	}
+
+	if existing, err := readCert(cert.CertFile); err == nil && fileExists(cert.KeyFile) &&
+		existing.CheckSignatureFrom(caCert) == nil && time.Until(existing.NotAfter) > 30*24*time.Hour {
+		covered := hostsOf(existing)
+		if coversHosts(covered, hosts) {
+			cert.Hosts = covered
+			return cert, nil
+		}
+		// Keep the old hosts so a network change doesn't drop them
+		hosts = append(append([]string{}, hosts...), covered...)
+	}
+
+	cert.Hosts = uniqueHosts(hosts)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"lazycap development certificate"}, CommonName: cert.Hosts[0]},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(devCertValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range cert.Hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeKeyPair(cert.CertFile, cert.KeyFile, der, key); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// loadCA reads the local CA from dir, creating it when there is none
+func loadCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPath, keyPath := filepath.Join(dir, caCertFile), filepath.Join(dir, caKeyFile)
+	if cert, err := readCert(certPath); err == nil {
+		key, err := readKey(keyPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("local CA key: %w", err)
+		}
+		return cert, key, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	name := "lazycap local CA"
+	if host, err := os.Hostname(); err == nil {
+		name += " (" + host + ")"
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"lazycap development CA"}, CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, nil, err
+	}
+	if err := writeKeyPair(certPath, keyPath, der, key); err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func readCert(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s: no certificate", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func readKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no private key", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ECDSA key", path)
+	}
+	return ecKey, nil
+}
+
+// writeKeyPair writes a certificate and its PKCS#8 key, which every dev
+// server's TLS stack reads, as PEM files
+func writeKeyPair(certPath, keyPath string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+}
+
+func hostsOf(cert *x509.Certificate) []string {
+	hosts := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		hosts = append(hosts, ip.String())
+	}
+	return hosts
+}
+
+func coversHosts(covered, hosts []string) bool {
+	have := map[string]bool{}
+	for _, h := range covered {
+		have[h] = true
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			h = ip.String()
+		}
+		if !have[h] {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueHosts drops duplicates, keeping localhost first as the common name
+func uniqueHosts(hosts []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			h = ip.String()
+		}
+		if h != "" && !seen[h] {
+			seen[h] = true
+			result = append(result, h)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i] == "localhost" && result[j] != "localhost"
+	})
+	return result
+}
+
+// TrustCA installs the local CA on a booted simulator or emulator, so its web
+// view accepts the HTTPS dev server. iOS simulators trust it right away.
+// Android only lets users add CAs, so it is copied to the emulator's
+// Download folder and the security settings are opened to install it.
+func TrustCA(dev *device.Device) error {
+	caFile, err := CAFile()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var commands [][]string
+	switch dev.Platform {
+	case "ios":
+		commands = [][]string{{"xcrun", "simctl", "keychain", dev.ID, "add-root-cert", caFile}}
+	case "android":
+		commands = [][]string{
+			{"adb", "-s", dev.ID, "push", caFile, "/sdcard/Download/lazycap-ca.crt"},
+			{"adb", "-s", dev.ID, "shell", "am", "start", "-a", "android.settings.SECURITY_SETTINGS"},
+		}
+	default:
+		return fmt.Errorf("%s can't trust certificates", dev.Name)
+	}
+	for _, argv := range commands {
+		if output, err := exec.CommandContext(ctx, argv[0], argv[1:]...).CombinedOutput(); err != nil {
+			text := strings.TrimSpace(string(output))
+			if text == "" {
+				text = err.Error()
+			}
+			return fmt.Errorf("%s: %s", argv[0], text)
+		}
+	}
+	return nil
+}
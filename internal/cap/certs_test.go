@@ -0,0 +1,130 @@
+package cap
+
+import (
+	"bytes"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIssueDevCert(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	dir := filepath.Join(home, ".config", "lazycap", "certs")
+
+	// verify checks the dev certificate against the CA on disk for each host
+	verify := func(cert *DevCert, hosts ...string) *x509.Certificate {
+		t.Helper()
+		ca, err := readCert(cert.CAFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := readCert(cert.CertFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readKey(cert.KeyFile); err != nil {
+			t.Fatal(err)
+		}
+		roots := x509.NewCertPool()
+		roots.AddCert(ca)
+		for _, host := range hosts {
+			opts := x509.VerifyOptions{DNSName: host, Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}
+			if _, err := leaf.Verify(opts); err != nil {
+				t.Errorf("verify %s: %v", host, err)
+			}
+		}
+		return leaf
+	}
+	read := func(path string) []byte {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	hosts := []string{"127.0.0.1", "localhost", "::1", "192.168.1.5", "10.0.2.2", "localhost"}
+	cert, err := IssueDevCert(hosts)
+	if err != nil {
+		t.Fatalf("IssueDevCert: %v", err)
+	}
+	want := DevCert{
+		CertFile: filepath.Join(dir, "dev.pem"),
+		KeyFile:  filepath.Join(dir, "dev-key.pem"),
+		CAFile:   filepath.Join(dir, "lazycap-ca.pem"),
+		Hosts:    []string{"localhost", "127.0.0.1", "::1", "192.168.1.5", "10.0.2.2"},
+	}
+	if !reflect.DeepEqual(*cert, want) {
+		t.Fatalf("IssueDevCert() = %+v, want %+v", *cert, want)
+	}
+	leaf := verify(cert, "localhost", "127.0.0.1", "::1", "192.168.1.5", "10.0.2.2")
+	if leaf.Subject.CommonName != "localhost" || !reflect.DeepEqual(leaf.DNSNames, []string{"localhost"}) || len(leaf.IPAddresses) != 4 {
+		t.Errorf("certificate is for %q, DNS %v, IPs %v", leaf.Subject.CommonName, leaf.DNSNames, leaf.IPAddresses)
+	}
+	if err := leaf.VerifyHostname("192.168.1.6"); err == nil {
+		t.Error("certificate verified for a host it doesn't cover")
+	}
+
+	ca, err := readCert(cert.CAFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ca.IsCA || !ca.MaxPathLenZero || ca.KeyUsage&x509.KeyUsageCertSign == 0 {
+		t.Errorf("CA: IsCA=%v MaxPathLenZero=%v KeyUsage=%v", ca.IsCA, ca.MaxPathLenZero, ca.KeyUsage)
+	}
+	caPEM, devPEM := read(cert.CAFile), read(cert.CertFile)
+
+	// Covered hosts reuse the certificate
+	again, err := IssueDevCert([]string{"10.0.2.2", "localhost"})
+	if err != nil {
+		t.Fatalf("IssueDevCert again: %v", err)
+	}
+	if !bytes.Equal(read(again.CertFile), devPEM) || !reflect.DeepEqual(again.Hosts, want.Hosts) {
+		t.Errorf("covered hosts reissued the certificate for %v", again.Hosts)
+	}
+
+	// A new LAN address reissues it from the same CA, keeping the old hosts
+	moved, err := IssueDevCert([]string{"localhost", "10.0.0.7", "10.0.2.2"})
+	if err != nil {
+		t.Fatalf("IssueDevCert on another network: %v", err)
+	}
+	if bytes.Equal(read(moved.CertFile), devPEM) {
+		t.Error("new host didn't reissue the certificate")
+	}
+	if !bytes.Equal(read(moved.CAFile), caPEM) {
+		t.Error("reissuing replaced the CA")
+	}
+	verify(moved, "localhost", "10.0.0.7", "10.0.2.2", "192.168.1.5", "127.0.0.1")
+
+	// A certificate from another CA is replaced
+	for _, name := range []string{"lazycap-ca.pem", "lazycap-ca-key.pem"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	renewed, err := IssueDevCert([]string{"localhost", "10.0.2.2"})
+	if err != nil {
+		t.Fatalf("IssueDevCert with a new CA: %v", err)
+	}
+	if bytes.Equal(read(renewed.CAFile), caPEM) {
+		t.Error("CA wasn't recreated")
+	}
+	verify(renewed, "localhost", "10.0.2.2")
+}
+
+func TestDevCertHosts(t *testing.T) {
+	hosts := DevCertHosts("10.0.2.2", "0.0.0.0", "")
+	if len(hosts) < 4 || !reflect.DeepEqual(hosts[:3], []string{"localhost", "127.0.0.1", "::1"}) || hosts[len(hosts)-1] != "10.0.2.2" {
+		t.Errorf("DevCertHosts() = %v", hosts)
+	}
+	for _, h := range hosts {
+		if h == "" || h == "0.0.0.0" {
+			t.Errorf("DevCertHosts() = %v, includes %q", hosts, h)
+		}
+	}
+}
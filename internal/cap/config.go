@@ -0,0 +1,659 @@
+package cap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ConfigFiles lists the Capacitor config file names in lookup order
+var ConfigFiles = []string{
+	"capacitor.config.json",
+	"capacitor.config.ts",
+	"capacitor.config.js",
+}
+
+// CapacitorConfig represents the capacitor.config.json/ts structure
+type CapacitorConfig struct {
+	AppID             string                 `json:"appId"`
+	AppName           string                 `json:"appName"`
+	WebDir            string                 `json:"webDir"`
+	BundledWebRuntime bool                   `json:"bundledWebRuntime,omitempty"`
+	LoggingBehavior   string                 `json:"loggingBehavior,omitempty"`
+	Server            ServerConfig           `json:"server,omitempty"`
+	Android           AndroidConfig          `json:"android,omitempty"`
+	IOS               IOSConfig              `json:"ios,omitempty"`
+	Plugins           map[string]interface{} `json:"plugins,omitempty"`
+
+	// Extra holds the top-level sections lazycap doesn't model, e.g. cordova
+	Extra map[string]interface{} `json:"-"`
+}
+
+// ServerConfig represents the `server` block of the capacitor config
+type ServerConfig struct {
+	URL             string   `json:"url,omitempty"`
+	Hostname        string   `json:"hostname,omitempty"`
+	Cleartext       bool     `json:"cleartext,omitempty"`
+	AndroidScheme   string   `json:"androidScheme,omitempty"`
+	IOSScheme       string   `json:"iosScheme,omitempty"`
+	AllowNavigation []string `json:"allowNavigation,omitempty"`
+	ErrorPath       string   `json:"errorPath,omitempty"`
+}
+
+// AndroidConfig represents the `android` block of the capacitor config
+type AndroidConfig struct {
+	Path                        string              `json:"path,omitempty"`
+	Flavor                      string              `json:"flavor,omitempty"`
+	AllowMixedContent           bool                `json:"allowMixedContent,omitempty"`
+	WebContentsDebuggingEnabled *bool               `json:"webContentsDebuggingEnabled,omitempty"`
+	BuildOptions                AndroidBuildOptions `json:"buildOptions,omitempty"`
+}
+
+// AndroidBuildOptions represents `android.buildOptions` used by `cap build android`
+type AndroidBuildOptions struct {
+	KeystorePath  string `json:"keystorePath,omitempty"`
+	KeystoreAlias string `json:"keystoreAlias,omitempty"`
+	ReleaseType   string `json:"releaseType,omitempty"` // "AAB" or "APK"
+}
+
+// IOSConfig represents the `ios` block of the capacitor config
+type IOSConfig struct {
+	Path                        string `json:"path,omitempty"`
+	Scheme                      string `json:"scheme,omitempty"`
+	ContentInset                string `json:"contentInset,omitempty"`
+	WebContentsDebuggingEnabled *bool  `json:"webContentsDebuggingEnabled,omitempty"`
+}
+
+// PluginConfig returns the config block for a plugin (e.g. "SplashScreen"), or nil
+func (c *CapacitorConfig) PluginConfig(name string) map[string]interface{} {
+	if c == nil || c.Plugins == nil {
+		return nil
+	}
+	cfg, _ := c.Plugins[name].(map[string]interface{})
+	return cfg
+}
+
+// AndroidDir returns the native Android project directory relative to the project root
+func (c *CapacitorConfig) AndroidDir() string {
+	if c == nil || c.Android.Path == "" {
+		return "android"
+	}
+	return c.Android.Path
+}
+
+// IOSDir returns the native iOS project directory relative to the project root
+func (c *CapacitorConfig) IOSDir() string {
+	if c == nil || c.IOS.Path == "" {
+		return "ios"
+	}
+	return c.IOS.Path
+}
+
+// ParseConfigFile reads a capacitor.config.json/ts/js file without running Node.
+// For ts/js configs the exported object literal is extracted statically; any value
+// that is computed at runtime is skipped and reported in the returned warnings.
+func ParseConfigFile(path string) (*CapacitorConfig, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var raw map[string]interface{}
+	var warnings []string
+
+	if strings.HasSuffix(path, ".json") {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	} else {
+		raw, warnings, err = ParseConfigSource(string(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	config, typeWarnings := configFromMap(raw)
+	return config, append(warnings, typeWarnings...), nil
+}
+
+// ParseConfigSource extracts the exported config object from capacitor.config.ts/js source
+func ParseConfigSource(src string) (map[string]interface{}, []string, error) {
+	p := &configParser{src: src}
+
+	start := p.findConfigObject()
+	if start < 0 {
+		return nil, nil, fmt.Errorf("no exported config object found")
+	}
+
+	p.pos = start
+	value, ok := p.parseValue("")
+	if !ok {
+		return nil, p.warnings, fmt.Errorf("exported config is not an object literal")
+	}
+	obj, isObj := value.(map[string]interface{})
+	if !isObj {
+		return nil, p.warnings, fmt.Errorf("exported config is not an object literal")
+	}
+	return obj, p.warnings, nil
+}
+
+// configFromMap converts a parsed config map into the typed config. A value
+// of the wrong type is skipped with a warning rather than failing the whole
+// config, and sections lazycap doesn't model are kept in Extra.
+func configFromMap(raw map[string]interface{}) (*CapacitorConfig, []string) {
+	var config CapacitorConfig
+	v := reflect.ValueOf(&config).Elem()
+	warnings := decodeFields(v, raw, "")
+
+	known := make(map[string]bool)
+	for i := 0; i < v.NumField(); i++ {
+		known[jsonName(v.Type().Field(i))] = true
+	}
+	for key, value := range raw {
+		if !known[key] {
+			if config.Extra == nil {
+				config.Extra = make(map[string]interface{})
+			}
+			config.Extra[key] = value
+		}
+	}
+	return &config, warnings
+}
+
+// decodeFields sets each field of the struct v from the value under its JSON
+// name in raw, recursing into nested blocks so one bad value only loses itself
+func decodeFields(v reflect.Value, raw map[string]interface{}, path string) []string {
+	var warnings []string
+	for i := 0; i < v.NumField(); i++ {
+		name := jsonName(v.Type().Field(i))
+		value, ok := raw[name]
+		if name == "" || !ok || value == nil {
+			continue
+		}
+		fieldPath := joinPath(path, name)
+		field := v.Field(i)
+
+		if obj, isObj := value.(map[string]interface{}); isObj && field.Kind() == reflect.Struct {
+			warnings = append(warnings, decodeFields(field, obj, fieldPath)...)
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err == nil {
+			err = json.Unmarshal(data, field.Addr().Interface())
+		}
+		if err != nil {
+			field.Set(reflect.Zero(field.Type()))
+			warnings = append(warnings, fmt.Sprintf("%s: expected %s, got %s; ignored", fieldPath, kindName(field.Type()), valueKind(value)))
+		}
+	}
+	return warnings
+}
+
+// jsonName returns the JSON key of a struct field, or "" when it has none
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// kindName names a Go type the way config values are described in JS
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return kindName(t.Elem())
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return "a number"
+	case reflect.Slice:
+		return "a list of " + strings.TrimPrefix(strings.TrimPrefix(kindName(t.Elem()), "a "), "an ") + "s"
+	default:
+		return "an object"
+	}
+}
+
+func valueKind(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	case []interface{}:
+		return "a list"
+	default:
+		return "an object"
+	}
+}
+
+var (
+	exportDefaultRe  = regexp.MustCompile(`export\s+default\s+`)
+	moduleExportsRe  = regexp.MustCompile(`module\.exports\s*=\s*`)
+	identifierRe     = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*`)
+	firstDeclRe      = regexp.MustCompile(`(?:const|let|var)\s+[A-Za-z_$][A-Za-z0-9_$]*\s*(?::\s*[A-Za-z_$][A-Za-z0-9_$.<>]*\s*)?=\s*\{`)
+	maxResolveDepth  = 4
+	runtimeValueNote = "value is computed at runtime and cannot be resolved statically"
+)
+
+// configParser is a minimal parser for the object-literal subset of JS/TS
+// used by Capacitor config files
+type configParser struct {
+	src      string
+	pos      int
+	warnings []string
+	depth    int // identifier resolution depth
+}
+
+// findConfigObject returns the offset of the exported object literal, or -1
+func (p *configParser) findConfigObject() int {
+	for _, re := range []*regexp.Regexp{exportDefaultRe, moduleExportsRe} {
+		loc := re.FindStringIndex(p.src)
+		if loc == nil {
+			continue
+		}
+		p.pos = loc[1]
+		if start := p.objectStart(); start >= 0 {
+			return start
+		}
+	}
+
+	// Fall back to the first object literal assigned to a variable
+	if loc := firstDeclRe.FindStringIndex(p.src); loc != nil {
+		return loc[1] - 1
+	}
+	return -1
+}
+
+// objectStart resolves the expression at pos to the offset of an object literal.
+// It follows `defineConfig({...})`-style wrappers and references to declared variables.
+func (p *configParser) objectStart() int {
+	p.skipSpace()
+	if p.peek() == '{' {
+		return p.pos
+	}
+
+	name := identifierRe.FindString(p.src[p.pos:])
+	if name == "" {
+		return -1
+	}
+	p.pos += len(name)
+	p.skipSpace()
+
+	// Wrapper call, e.g. export default defineConfig({ ... })
+	if p.peek() == '(' {
+		p.pos++
+		p.skipSpace()
+		if p.peek() == '{' {
+			return p.pos
+		}
+		return -1
+	}
+
+	// Reference to a variable declared elsewhere in the file. The depth bounds
+	// self-referencing declarations such as `const config = config`.
+	if start, ok := p.declarationValue(name); ok && p.depth < maxResolveDepth {
+		p.depth++
+		defer func() { p.depth-- }()
+		p.pos = start
+		return p.objectStart()
+	}
+	return -1
+}
+
+// declarationValue finds `const|let|var name[: Type] =` and returns the offset of its value
+func (p *configParser) declarationValue(name string) (int, bool) {
+	re := regexp.MustCompile(`(?:const|let|var)\s+` + regexp.QuoteMeta(name) + `\s*(?::\s*[^=]+?)?=\s*`)
+	loc := re.FindStringIndex(p.src)
+	if loc == nil {
+		return 0, false
+	}
+	return loc[1], true
+}
+
+func (p *configParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// skipSpace skips whitespace and comments
+func (p *configParser) skipSpace() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			if idx := strings.IndexByte(p.src[p.pos:], '\n'); idx >= 0 {
+				p.pos += idx + 1
+			} else {
+				p.pos = len(p.src)
+			}
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			if idx := strings.Index(p.src[p.pos+2:], "*/"); idx >= 0 {
+				p.pos += idx + 4
+			} else {
+				p.pos = len(p.src)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *configParser) warn(path string) {
+	if path == "" {
+		path = "(root)"
+	}
+	p.warnings = append(p.warnings, fmt.Sprintf("%s: %s", path, runtimeValueNote))
+}
+
+// parseValue parses a literal value at pos. It returns ok=false when the value
+// could not be resolved statically; in that case the expression is skipped.
+func (p *configParser) parseValue(path string) (interface{}, bool) {
+	p.skipSpace()
+	start := p.pos
+
+	var value interface{}
+	ok := true
+
+	switch c := p.peek(); {
+	case c == '{':
+		value = p.parseObject(path)
+	case c == '[':
+		value = p.parseArray(path)
+	case c == '\'' || c == '"':
+		value, ok = p.parseString(c)
+	case c == '`':
+		var s string
+		s, ok = p.parseString('`')
+		if ok && strings.Contains(s, "${") {
+			ok = false
+		}
+		value = s
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		value, ok = p.parseNumber()
+	default:
+		name := identifierRe.FindString(p.src[p.pos:])
+		p.pos += len(name)
+		switch name {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null", "undefined":
+			value = nil
+		case "":
+			ok = false
+		default:
+			value, ok = p.resolveIdentifier(name)
+		}
+	}
+
+	// Anything other than a terminator or a type assertion after the value
+	// means it is part of a larger runtime expression
+	end := p.pos
+	p.skipSpace()
+	if ok && !p.atTerminator() && !p.statementEnded(end) {
+		if kw := identifierRe.FindString(p.src[p.pos:]); kw == "as" || kw == "satisfies" {
+			p.skipExpression()
+		} else {
+			ok = false
+		}
+	}
+
+	if !ok {
+		p.pos = start
+		p.skipExpression()
+		p.warn(path)
+		return nil, false
+	}
+	return value, true
+}
+
+// resolveIdentifier resolves a reference to a top-level constant with a literal value
+func (p *configParser) resolveIdentifier(name string) (interface{}, bool) {
+	if p.depth >= maxResolveDepth {
+		return nil, false
+	}
+	start, found := p.declarationValue(name)
+	if !found {
+		return nil, false
+	}
+
+	saved, savedWarnings := p.pos, len(p.warnings)
+	p.depth++
+	p.pos = start
+	value, ok := p.parseValue(name)
+	p.depth--
+	p.pos = saved
+	// Warnings are reported against the config path, not the constant
+	p.warnings = p.warnings[:savedWarnings]
+	return value, ok
+}
+
+func (p *configParser) atTerminator() bool {
+	c := p.peek()
+	return c == ',' || c == '}' || c == ']' || c == ')' || c == ';' || c == 0
+}
+
+// statementEnded reports whether a line break between end and pos ends the
+// statement the way automatic semicolon insertion does: the next token starts
+// a new statement unless it can only continue the previous expression.
+// `as` and `satisfies` don't continue across a line break in TypeScript either.
+func (p *configParser) statementEnded(end int) bool {
+	if !strings.ContainsAny(p.src[end:p.pos], "\n\r") {
+		return false
+	}
+	return strings.IndexByte(".([`+-*/%?&|^=<>!", p.peek()) < 0
+}
+
+// skipExpression skips to the next top-level ',', '}', ']' or ';'
+func (p *configParser) skipExpression() {
+	depth := 0
+	for p.pos < len(p.src) {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return
+		}
+		switch c := p.src[p.pos]; c {
+		case '\'', '"', '`':
+			p.parseString(c)
+			continue
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			if depth == 0 {
+				return
+			}
+			depth--
+		case ',', ';':
+			if depth == 0 {
+				return
+			}
+		}
+		p.pos++
+	}
+}
+
+// stalled reports whether an object or array entry starts where the previous
+// one did, which happens when unbalanced input leaves a stray closer or
+// separator the entry parsers stop at. The byte is skipped so the loop always
+// makes progress.
+func (p *configParser) stalled(last *int) bool {
+	if p.pos == *last {
+		p.pos++
+		return true
+	}
+	*last = p.pos
+	return false
+}
+
+func (p *configParser) parseObject(path string) map[string]interface{} {
+	obj := make(map[string]interface{})
+	p.pos++ // {
+
+	for last := -1; ; {
+		p.skipSpace()
+		switch p.peek() {
+		case 0:
+			return obj
+		case '}':
+			p.pos++
+			return obj
+		case ',':
+			p.pos++
+			continue
+		}
+		if p.stalled(&last) {
+			continue
+		}
+
+		// Spread and computed keys can't be resolved
+		if strings.HasPrefix(p.src[p.pos:], "...") || p.peek() == '[' {
+			p.skipExpression()
+			p.warn(joinPath(path, "..."))
+			continue
+		}
+
+		key, ok := p.parseKey()
+		if !ok {
+			p.skipExpression()
+			continue
+		}
+		keyPath := joinPath(path, key)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ':':
+			p.pos++
+			if value, ok := p.parseValue(keyPath); ok {
+				obj[key] = value
+			}
+		case ',', '}':
+			// Shorthand property: { appId }
+			if value, ok := p.resolveIdentifier(key); ok {
+				obj[key] = value
+			} else {
+				p.warn(keyPath)
+			}
+		default:
+			// Method definition or getter
+			p.skipExpression()
+			p.warn(keyPath)
+		}
+	}
+}
+
+func (p *configParser) parseKey() (string, bool) {
+	switch c := p.peek(); c {
+	case '\'', '"':
+		return p.parseString(c)
+	}
+	name := identifierRe.FindString(p.src[p.pos:])
+	if name == "" {
+		// Numeric keys
+		end := p.pos
+		for end < len(p.src) && p.src[end] >= '0' && p.src[end] <= '9' {
+			end++
+		}
+		name = p.src[p.pos:end]
+	}
+	if name == "" {
+		return "", false
+	}
+	p.pos += len(name)
+	return name, true
+}
+
+func (p *configParser) parseArray(path string) []interface{} {
+	arr := make([]interface{}, 0)
+	p.pos++ // [
+
+	for i, last := 0, -1; ; i++ {
+		p.skipSpace()
+		switch p.peek() {
+		case 0:
+			return arr
+		case ']':
+			p.pos++
+			return arr
+		case ',':
+			p.pos++
+			continue
+		}
+		if p.stalled(&last) {
+			continue
+		}
+		if value, ok := p.parseValue(fmt.Sprintf("%s[%d]", path, i)); ok {
+			arr = append(arr, value)
+		}
+	}
+}
+
+// parseString parses a quoted string starting at pos
+func (p *configParser) parseString(quote byte) (string, bool) {
+	p.pos++ // opening quote
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				sb.WriteByte(e)
+			}
+		case c == quote:
+			p.pos++
+			return sb.String(), true
+		case c == '\n' && quote != '`':
+			return sb.String(), false
+		default:
+			sb.WriteByte(c)
+		}
+		p.pos++
+	}
+	return sb.String(), false
+}
+
+func (p *configParser) parseNumber() (float64, bool) {
+	end := p.pos
+	for end < len(p.src) && strings.IndexByte("+-.0123456789eExX_abcdefABCDEF", p.src[end]) >= 0 {
+		end++
+	}
+	literal := strings.ReplaceAll(p.src[p.pos:end], "_", "")
+	p.pos = end
+
+	if n, err := strconv.ParseFloat(literal, 64); err == nil {
+		return n, true
+	}
+	if n, err := strconv.ParseInt(literal, 0, 64); err == nil {
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
@@ -0,0 +1,313 @@
+package cap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DevServerTimeout is how long a live reload dev server gets to start
+// listening; the first compile of larger apps takes a while
+const DevServerTimeout = 2 * time.Minute
+
+// DevServer is a web dev server command and the framework it starts, which
+// decides how it is told the host and port to listen on
+type DevServer struct {
+	Argv      []string
+	Framework string // vite, ionic, angular, webpack, next, nuxt, vue, react or parcel; "" when unknown
+	Script    bool   // Argv runs a package.json script, so flags are passed through to it
+}
+
+// String returns the command line
+func (s DevServer) String() string {
+	return strings.Join(s.Argv, " ")
+}
+
+// Listen returns the command line and extra environment that start the dev
+// server on host and port
+func (s DevServer) Listen(host string, port int) ([]string, []string) {
+	flags, env := s.listenFlags(host, port)
+	return s.withFlags(flags), env
+}
+
+// ListenTLS is Listen for an HTTPS dev server using cert. Frameworks whose CLI
+// takes a certificate serve HTTPS themselves. The others listen on a free
+// local port behind the returned TLSProxy, which the caller starts once the
+// dev server is up.
+func (s DevServer) ListenTLS(host string, port int, cert *DevCert) ([]string, []string, *TLSProxy, error) {
+	if tlsFlags, tlsEnv, ok := s.tlsFlags(cert); ok {
+		flags, env := s.listenFlags(host, port)
+		return s.withFlags(append(flags, tlsFlags...)), append(env, tlsEnv...), nil, nil
+	}
+	backend, err := freePort()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	argv, env := s.Listen("127.0.0.1", backend)
+	return argv, env, &TLSProxy{Addr: net.JoinHostPort(host, strconv.Itoa(port)), Backend: backend, Cert: cert}, nil
+}
+
+// ListenHTTPS is ListenTLS with a certificate from the local CA for this
+// machine's addresses and the extra hosts
+func (s DevServer) ListenHTTPS(host string, port int, extra ...string) ([]string, []string, *TLSProxy, error) {
+	cert, err := IssueDevCert(DevCertHosts(extra...))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("HTTPS certificate: %w", err)
+	}
+	return s.ListenTLS(host, port, cert)
+}
+
+// tlsFlags returns the flags and environment that make the dev server serve
+// HTTPS with cert, if its CLI takes a certificate
+func (s DevServer) tlsFlags(cert *DevCert) ([]string, []string, bool) {
+	switch s.Framework {
+	case "angular":
+		return []string{"--ssl", "--ssl-cert", cert.CertFile, "--ssl-key", cert.KeyFile}, nil, true
+	case "webpack":
+		return []string{"--server-type", "https", "--server-options-cert", cert.CertFile, "--server-options-key", cert.KeyFile}, nil, true
+	case "next":
+		return []string{"--experimental-https", "--experimental-https-cert", cert.CertFile, "--experimental-https-key", cert.KeyFile}, nil, true
+	case "nuxt":
+		return []string{"--https", "--https.cert", cert.CertFile, "--https.key", cert.KeyFile}, nil, true
+	case "parcel":
+		return []string{"--https", "--cert", cert.CertFile, "--key", cert.KeyFile}, nil, true
+	case "react":
+		return nil, []string{"HTTPS=true", "SSL_CRT_FILE=" + cert.CertFile, "SSL_KEY_FILE=" + cert.KeyFile}, true
+	}
+	// Vite 5 dropped --https, and ionic and vue take no certificate
+	return nil, nil, false
+}
+
+// listenFlags returns the flags and environment that set the host and port
+func (s DevServer) listenFlags(host string, port int) ([]string, []string) {
+	p := strconv.Itoa(port)
+	var flags, env []string
+	switch s.Framework {
+	case "vite":
+		flags = []string{"--host", host, "--port", p, "--strictPort"}
+	case "ionic":
+		flags = []string{"--host", host, "--port", p, "--no-open"}
+	case "angular":
+		// Devices load the app by IP address, which ng serve rejects by default
+		flags = []string{"--host", host, "--port", p, "--disable-host-check"}
+	case "webpack":
+		flags = []string{"--host", host, "--port", p, "--allowed-hosts", "all"}
+	case "next":
+		flags = []string{"-H", host, "-p", p}
+	case "react":
+		// react-scripts takes no flags, only the environment
+		env = []string{"HOST=" + host, "PORT=" + p, "BROWSER=none"}
+	default:
+		// nuxt, vue, parcel and most other dev servers
+		flags = []string{"--host", host, "--port", p}
+	}
+	return flags, env
+}
+
+// withFlags appends flags to the command line
+func (s DevServer) withFlags(flags []string) []string {
+	argv := append([]string{}, s.Argv...)
+	if len(flags) > 0 && s.Script && argv[0] == "npm" && !containsArg(argv, "--") {
+		// npm keeps flags for itself unless they come after --
+		argv = append(argv, "--")
+	}
+	return append(argv, flags...)
+}
+
+func containsArg(argv []string, arg string) bool {
+	for _, a := range argv {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// devScripts are the package.json scripts that usually start a dev server, in
+// the order they are tried
+var devScripts = []string{
+	"dev",         // Vite, Nuxt, common
+	"serve",       // Vue CLI
+	"start",       // Create React App, Angular, general
+	"develop",     // Gatsby
+	"dev:web",     // Ionic
+	"ionic:serve", // Ionic
+	"watch",       // Some setups
+}
+
+// DetectDevServer returns the dev server of the project in dir (the current
+// directory when empty). A non-empty command, i.e. the Dev Command setting,
+// is used as given; otherwise it comes from the package.json scripts, then
+// the framework dependencies, then the framework config files.
+func DetectDevServer(dir, command string) DevServer {
+	pm := DetectPackageManager(dir)
+	var pkg struct {
+		Scripts      map[string]string `json:"scripts"`
+		Dependencies map[string]string `json:"dependencies"`
+		DevDeps      map[string]string `json:"devDependencies"`
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		_ = json.Unmarshal(data, &pkg)
+	}
+
+	if argv := strings.Fields(command); len(argv) > 0 {
+		if script := scriptName(argv); script != "" {
+			if body, ok := pkg.Scripts[script]; ok {
+				return DevServer{Argv: argv, Framework: frameworkOf(body), Script: true}
+			}
+		}
+		return DevServer{Argv: argv, Framework: frameworkOf(command)}
+	}
+
+	for _, script := range devScripts {
+		if body, ok := pkg.Scripts[script]; ok {
+			return DevServer{Argv: pm.RunScript(script), Framework: frameworkOf(body), Script: true}
+		}
+	}
+
+	// No known script found, run the framework's binary directly
+	deps := make(map[string]bool)
+	for k := range pkg.Dependencies {
+		deps[k] = true
+	}
+	for k := range pkg.DevDeps {
+		deps[k] = true
+	}
+	switch {
+	case deps["vite"]:
+		return DevServer{Argv: pm.Exec("vite"), Framework: "vite"}
+	case deps["@ionic/cli"]:
+		return DevServer{Argv: pm.Exec("ionic", "serve"), Framework: "ionic"}
+	case deps["@angular/cli"]:
+		return DevServer{Argv: pm.Exec("ng", "serve"), Framework: "angular"}
+	case deps["next"]:
+		return DevServer{Argv: pm.Exec("next", "dev"), Framework: "next"}
+	case deps["nuxt"]:
+		return DevServer{Argv: pm.Exec("nuxi", "dev"), Framework: "nuxt"}
+	case deps["@vue/cli-service"]:
+		return DevServer{Argv: pm.Exec("vue-cli-service", "serve"), Framework: "vue"}
+	case deps["react-scripts"]:
+		return DevServer{Argv: pm.Exec("react-scripts", "start"), Framework: "react"}
+	case deps["webpack-dev-server"]:
+		return DevServer{Argv: pm.Exec("webpack", "serve"), Framework: "webpack"}
+	case deps["parcel"]:
+		return DevServer{Argv: pm.Exec("parcel"), Framework: "parcel"}
+	}
+
+	return detectDevServerByConfig(dir, pm)
+}
+
+// detectDevServerByConfig picks the dev server from the framework config
+// files in dir, defaulting to vite as it's most common with Capacitor
+func detectDevServerByConfig(dir string, pm PackageManager) DevServer {
+	configs := []struct {
+		files  []string
+		server DevServer
+	}{
+		{[]string{"vite.config.js", "vite.config.ts", "vite.config.mjs"}, DevServer{Argv: pm.Exec("vite"), Framework: "vite"}},
+		{[]string{"ionic.config.json"}, DevServer{Argv: pm.Exec("ionic", "serve"), Framework: "ionic"}},
+		{[]string{"angular.json"}, DevServer{Argv: pm.Exec("ng", "serve"), Framework: "angular"}},
+		{[]string{"next.config.js", "next.config.mjs", "next.config.ts"}, DevServer{Argv: pm.Exec("next", "dev"), Framework: "next"}},
+		{[]string{"nuxt.config.js", "nuxt.config.ts"}, DevServer{Argv: pm.Exec("nuxi", "dev"), Framework: "nuxt"}},
+		{[]string{"webpack.config.js", "webpack.config.ts"}, DevServer{Argv: pm.Exec("webpack", "serve"), Framework: "webpack"}},
+		{[]string{".parcelrc"}, DevServer{Argv: pm.Exec("parcel"), Framework: "parcel"}},
+	}
+	for _, c := range configs {
+		for _, file := range c.files {
+			if fileExists(filepath.Join(dir, file)) {
+				return c.server
+			}
+		}
+	}
+	return DevServer{Argv: pm.Exec("vite"), Framework: "vite"}
+}
+
+// scriptName returns the package.json script a command line runs, e.g. dev
+// for "npm run dev" or "yarn dev"
+func scriptName(argv []string) string {
+	if len(argv) < 2 {
+		return ""
+	}
+	switch argv[0] {
+	case "npm", "bun":
+		if len(argv) > 2 && (argv[1] == "run" || argv[1] == "run-script") {
+			return argv[2]
+		}
+	case "yarn", "pnpm":
+		if argv[1] == "run" {
+			if len(argv) > 2 {
+				return argv[2]
+			}
+			return ""
+		}
+		if !strings.HasPrefix(argv[1], "-") && argv[1] != "exec" && argv[1] != "dlx" {
+			return argv[1]
+		}
+	}
+	return ""
+}
+
+// frameworkOf guesses the framework a command line or script starts from the
+// first dev server binary it names
+func frameworkOf(command string) string {
+	fields := strings.Fields(command)
+	for i, field := range fields {
+		next := ""
+		if i+1 < len(fields) {
+			next = fields[i+1]
+		}
+		switch filepath.Base(field) {
+		case "vite":
+			return "vite"
+		case "ionic":
+			if next == "serve" {
+				return "ionic"
+			}
+		case "ng":
+			if next == "serve" || next == "s" {
+				return "angular"
+			}
+		case "next":
+			if next == "dev" {
+				return "next"
+			}
+		case "nuxt", "nuxi":
+			return "nuxt"
+		case "webpack-dev-server":
+			return "webpack"
+		case "webpack":
+			if next == "serve" {
+				return "webpack"
+			}
+		case "vue-cli-service":
+			return "vue"
+		case "react-scripts", "craco":
+			return "react"
+		case "parcel":
+			return "parcel"
+		}
+	}
+	return ""
+}
+
+// WaitForServer waits for a dev server to listen on port. It fails when the
+// server exits first or the timeout expires.
+func WaitForServer(server *Execution, port int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if WaitForPort(port, time.Second) {
+			return nil
+		}
+		select {
+		case <-server.Done():
+			return fmt.Errorf("dev server exited before listening on port %d", port)
+		default:
+		}
+	}
+	return fmt.Errorf("dev server did not start on port %d within %s", port, timeout)
+}
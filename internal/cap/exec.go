@@ -0,0 +1,276 @@
+package cap
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCanceled is returned by Execution.Wait when the command was canceled
+var ErrCanceled = errors.New("canceled")
+
+// ErrTimeout is wrapped by the error Execution.Wait returns when the timeout expired
+var ErrTimeout = errors.New("timed out")
+
+// maxExecOutput caps how many output lines an Execution keeps
+const maxExecOutput = 5000
+
+// killGrace is how long a canceled command gets to exit before it is killed
+const killGrace = 3 * time.Second
+
+// ExecOptions configures a command started with Start
+type ExecOptions struct {
+	Dir     string        // Working directory (cwd when empty)
+	Env     []string      // Extra KEY=VALUE pairs on top of the inherited environment
+	Timeout time.Duration // Kill the command after this long (0 = no limit)
+	Output  io.Writer     // Also write every output line here as it is read (optional)
+}
+
+// Execution is a handle to a command started with Start. Output (stdout and
+// stderr combined) is streamed line by line on Lines and kept for Output.
+type Execution struct {
+	Argv      []string
+	Dir       string
+	StartTime time.Time
+
+	cmd     *exec.Cmd
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+	out     io.Writer
+	lines   chan string
+	done    chan struct{}
+
+	mu       sync.Mutex
+	output   []string
+	err      error
+	exitCode int
+	endTime  time.Time
+	canceled bool
+}
+
+// Start runs argv in the background. The command is stopped, along with any
+// processes it spawned, when ctx is done, the timeout expires or Cancel is called.
+func Start(ctx context.Context, argv []string, opts ExecOptions) (*Execution, error) {
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("no command given")
+	}
+
+	e := &Execution{
+		Argv:     argv,
+		Dir:      opts.Dir,
+		timeout:  opts.Timeout,
+		out:      opts.Output,
+		lines:    make(chan string, 256),
+		done:     make(chan struct{}),
+		exitCode: -1,
+	}
+	if opts.Timeout > 0 {
+		e.ctx, e.cancel = context.WithTimeout(ctx, opts.Timeout)
+	} else {
+		e.ctx, e.cancel = context.WithCancel(ctx)
+	}
+
+	e.cmd = exec.Command(argv[0], argv[1:]...)
+	e.cmd.Dir = opts.Dir
+	if len(opts.Env) > 0 {
+		e.cmd.Env = append(os.Environ(), opts.Env...)
+	}
+	setProcessGroup(e.cmd)
+
+	// One pipe for both streams keeps stdout and stderr lines in order
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		e.cancel()
+		return nil, err
+	}
+	e.cmd.Stdout = writer
+	e.cmd.Stderr = writer
+
+	if err := e.cmd.Start(); err != nil {
+		_ = reader.Close()
+		_ = writer.Close()
+		e.cancel()
+		return nil, err
+	}
+	_ = writer.Close()
+	e.StartTime = time.Now()
+
+	readDone := make(chan struct{})
+	go e.readOutput(reader, readDone)
+	go e.wait(reader, readDone)
+	go e.watchContext()
+
+	return e, nil
+}
+
+func (e *Execution) readOutput(reader *os.File, readDone chan struct{}) {
+	defer close(readDone)
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		e.mu.Lock()
+		e.output = append(e.output, line)
+		if len(e.output) > maxExecOutput {
+			e.output = e.output[len(e.output)-maxExecOutput:]
+		}
+		e.mu.Unlock()
+
+		if e.out != nil {
+			_, _ = io.WriteString(e.out, line+"\n")
+		}
+
+		// Never block the command on a slow reader; Output keeps every line
+		select {
+		case e.lines <- line:
+		default:
+		}
+	}
+}
+
+func (e *Execution) wait(reader *os.File, readDone chan struct{}) {
+	waitErr := e.cmd.Wait()
+
+	// Background children can keep the pipe open after the command exits
+	select {
+	case <-readDone:
+	case <-time.After(2 * time.Second):
+		_ = reader.Close()
+		<-readDone
+	}
+	_ = reader.Close()
+
+	e.mu.Lock()
+	e.endTime = time.Now()
+	if e.cmd.ProcessState != nil {
+		e.exitCode = e.cmd.ProcessState.ExitCode()
+	}
+	switch {
+	case e.canceled:
+		e.err = ErrCanceled
+	case errors.Is(e.ctx.Err(), context.DeadlineExceeded):
+		e.err = fmt.Errorf("%s %w after %s", e.Argv[0], ErrTimeout, e.timeout)
+	case errors.Is(e.ctx.Err(), context.Canceled):
+		e.err = ErrCanceled
+	default:
+		e.err = waitErr
+	}
+	e.mu.Unlock()
+
+	e.cancel()
+	close(e.lines)
+	close(e.done)
+}
+
+// watchContext stops the process tree when the context ends, escalating to a
+// hard kill if it doesn't exit in time
+func (e *Execution) watchContext() {
+	select {
+	case <-e.done:
+		return
+	case <-e.ctx.Done():
+	}
+
+	terminateProcess(e.cmd)
+	select {
+	case <-e.done:
+	case <-time.After(killGrace):
+		killProcess(e.cmd)
+	}
+}
+
+// Lines streams output lines as they are produced and is closed once the
+// command exits. Lines are dropped if nobody keeps up; Output has them all.
+func (e *Execution) Lines() <-chan string {
+	return e.lines
+}
+
+// Done is closed once the command has exited
+func (e *Execution) Done() <-chan struct{} {
+	return e.done
+}
+
+// Wait blocks until the command exits and returns its error: nil on success,
+// ErrCanceled after Cancel, a timeout error, or the exit error
+func (e *Execution) Wait() error {
+	<-e.done
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// Cancel stops the command and everything it started
+func (e *Execution) Cancel() {
+	e.mu.Lock()
+	if e.endTime.IsZero() {
+		e.canceled = true
+	}
+	e.mu.Unlock()
+	e.cancel()
+}
+
+// Running reports whether the command is still running
+func (e *Execution) Running() bool {
+	select {
+	case <-e.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// ExitCode returns the exit code, or -1 while running or if the command was killed
+func (e *Execution) ExitCode() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.exitCode
+}
+
+// Pid returns the process ID of the command
+func (e *Execution) Pid() int {
+	if e.cmd.Process == nil {
+		return 0
+	}
+	return e.cmd.Process.Pid
+}
+
+// Duration returns how long the command has been running or ran
+func (e *Execution) Duration() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.endTime.IsZero() {
+		return time.Since(e.StartTime)
+	}
+	return e.endTime.Sub(e.StartTime)
+}
+
+// Output returns the output lines captured so far (at most the last 5000)
+func (e *Execution) Output() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.output...)
+}
+
+// Tail returns the last n output lines joined with newlines
+func (e *Execution) Tail(n int) string {
+	lines := e.Output()
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// String returns the command line
+func (e *Execution) String() string {
+	return strings.Join(e.Argv, " ")
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package cap
+
+import "os/exec"
+
+// Windows has no process groups to signal; the command itself is killed
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func terminateProcess(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
+
+func killProcess(cmd *exec.Cmd) {
+	terminateProcess(cmd)
+}
@@ -0,0 +1,276 @@
+package cap
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/icarus-itcs/lazycap/internal/device"
+)
+
+// listIOSDevices lists iOS simulators and connected physical devices
+func listIOSDevices() ([]device.Device, error) { //nolint:unparam // error kept for API consistency
+	// Check if xcrun is available (macOS only)
+	if _, err := exec.LookPath("xcrun"); err != nil {
+		return nil, nil
+	}
+
+	devices := listPhysicalIOSDevices()
+	return append(devices, listSimulators()...), nil
+}
+
+// simctlDevice is a device in xcrun simctl list devices -j
+type simctlDevice struct {
+	UDID                 string `json:"udid"`
+	Name                 string `json:"name"`
+	State                string `json:"state"`
+	IsAvailable          bool   `json:"isAvailable"`
+	DeviceTypeIdentifier string `json:"deviceTypeIdentifier"`
+}
+
+// listSimulators lists the available iOS simulators, newest runtime first
+func listSimulators() []device.Device {
+	output, err := exec.Command("xcrun", "simctl", "list", "devices", "-j").Output()
+	if err != nil {
+		return nil
+	}
+
+	var result struct {
+		Devices map[string][]simctlDevice `json:"devices"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil
+	}
+
+	runtimes := make([]string, 0, len(result.Devices))
+	for runtime := range result.Devices {
+		// Only include iOS simulators
+		if strings.Contains(runtime, "iOS") {
+			runtimes = append(runtimes, runtime)
+		}
+	}
+	sort.Slice(runtimes, func(i, j int) bool {
+		return device.CompareVersions(runtimeVersion(runtimes[i]), runtimeVersion(runtimes[j])) > 0
+	})
+
+	var devices []device.Device
+	for _, runtime := range runtimes {
+		for _, sim := range result.Devices[runtime] {
+			if !sim.IsAvailable {
+				continue
+			}
+			state := simulatorState(sim.State)
+			devices = append(devices, device.Device{
+				ID:         sim.UDID,
+				Name:       sim.Name,
+				Platform:   "ios",
+				Online:     state == device.StateBooted,
+				State:      state,
+				IsEmulator: true,
+				OSVersion:  runtimeVersion(runtime),
+				Runtime:    runtime,
+				Model:      deviceTypeName(sim.DeviceTypeIdentifier),
+				ModelID:    sim.DeviceTypeIdentifier,
+			})
+		}
+	}
+	return devices
+}
+
+func simulatorState(state string) device.State {
+	switch state {
+	case "Booted":
+		return device.StateBooted
+	case "Booting", "Creating":
+		return device.StateBooting
+	case "Shutting Down":
+		return device.StateShuttingDown
+	default:
+		return device.StateShutdown
+	}
+}
+
+var runtimeVersionPattern = regexp.MustCompile(`(?:iOS|tvOS|watchOS|xrOS|visionOS)[- ](\d+(?:[-.]\d+)*)`)
+
+// runtimeVersion parses the OS version from a runtime identifier such as
+// com.apple.CoreSimulator.SimRuntime.iOS-17-2 (or "iOS 17.2")
+func runtimeVersion(runtime string) string {
+	m := runtimeVersionPattern.FindStringSubmatch(runtime)
+	if m == nil {
+		return ""
+	}
+	return strings.ReplaceAll(m[1], "-", ".")
+}
+
+// deviceTypeName turns com.apple.CoreSimulator.SimDeviceType.iPhone-15-Pro
+// into "iPhone 15 Pro"
+func deviceTypeName(identifier string) string {
+	if identifier == "" {
+		return ""
+	}
+	name := identifier[strings.LastIndex(identifier, ".")+1:]
+	return strings.ReplaceAll(name, "-", " ")
+}
+
+// listPhysicalIOSDevices lists paired iPhones and iPads with devicectl
+// (Xcode 15+), falling back to xctrace on older Xcodes
+func listPhysicalIOSDevices() []device.Device {
+	if devices, ok := listDevicectlDevices(); ok {
+		return devices
+	}
+	return listXctraceDevices()
+}
+
+// devicectlList is the JSON output of xcrun devicectl list devices
+type devicectlList struct {
+	Result struct {
+		Devices []struct {
+			Identifier           string `json:"identifier"`
+			ConnectionProperties struct {
+				TransportType string `json:"transportType"` // wired, localNetwork
+				TunnelState   string `json:"tunnelState"`   // connected, disconnected, unavailable
+				PairingState  string `json:"pairingState"`
+			} `json:"connectionProperties"`
+			DeviceProperties struct {
+				Name            string `json:"name"`
+				OSVersionNumber string `json:"osVersionNumber"`
+				BootState       string `json:"bootState"`
+			} `json:"deviceProperties"`
+			HardwareProperties struct {
+				UDID          string `json:"udid"`
+				ProductType   string `json:"productType"`
+				MarketingName string `json:"marketingName"`
+				Platform      string `json:"platform"`
+				Reality       string `json:"reality"`
+			} `json:"hardwareProperties"`
+		} `json:"devices"`
+	} `json:"result"`
+}
+
+func listDevicectlDevices() ([]device.Device, bool) {
+	// devicectl only writes JSON to a file
+	tmp, err := os.MkdirTemp("", "lazycap-devicectl")
+	if err != nil {
+		return nil, false
+	}
+	defer os.RemoveAll(tmp)
+	out := filepath.Join(tmp, "devices.json")
+
+	cmd := exec.Command("xcrun", "devicectl", "list", "devices", "--quiet", "--json-output", out)
+	if err := cmd.Run(); err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		return nil, false
+	}
+	var list devicectlList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, false
+	}
+
+	var devices []device.Device //nolint:prealloc // non-iOS devices are skipped
+	for _, d := range list.Result.Devices {
+		hw := d.HardwareProperties
+		if hw.Platform != "iOS" || hw.Reality == "virtual" || d.ConnectionProperties.PairingState != "paired" {
+			continue
+		}
+		id := hw.UDID
+		if id == "" {
+			id = d.Identifier
+		}
+
+		state := device.StateDisconnected
+		if d.ConnectionProperties.TunnelState == "connected" || d.DeviceProperties.BootState == "booted" && d.ConnectionProperties.TunnelState != "unavailable" {
+			state = device.StateBooted
+		}
+		connection := ""
+		switch d.ConnectionProperties.TransportType {
+		case "wired":
+			connection = "usb"
+		case "localNetwork":
+			connection = "wifi"
+		}
+
+		dev := device.Device{
+			ID:         id,
+			Name:       d.DeviceProperties.Name,
+			Platform:   "ios",
+			Online:     state == device.StateBooted,
+			State:      state,
+			OSVersion:  d.DeviceProperties.OSVersionNumber,
+			Model:      hw.MarketingName,
+			ModelID:    hw.ProductType,
+			Connection: connection,
+		}
+		if dev.Name == "" {
+			dev.Name = dev.Model
+		}
+		if dev.Online && connection == "usb" {
+			dev.BatteryLevel = iosBatteryLevel(id)
+		}
+		devices = append(devices, dev)
+	}
+	return devices, true
+}
+
+// xctraceDevicePattern matches "Name (17.2) (UDID)" lines of xctrace list devices
+var xctraceDevicePattern = regexp.MustCompile(`^(.+) \((\d+(?:\.\d+)*)\) \(([0-9A-Fa-f-]+)\)$`)
+
+// listXctraceDevices parses xcrun xctrace list devices, which lists
+// connected devices, then offline devices, then simulators
+func listXctraceDevices() []device.Device {
+	output, err := exec.Command("xcrun", "xctrace", "list", "devices").Output()
+	if err != nil {
+		return nil
+	}
+
+	var devices []device.Device
+	section := ""
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "==") {
+			section = strings.Trim(line, "= ")
+			continue
+		}
+		if section != "Devices" && section != "Devices Offline" {
+			continue
+		}
+		m := xctraceDevicePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue // The Mac itself has no OS version in parentheses
+		}
+		state := device.StateDisconnected
+		if section == "Devices" {
+			state = device.StateBooted
+		}
+		devices = append(devices, device.Device{
+			ID:        m[3],
+			Name:      m[1],
+			Platform:  "ios",
+			Online:    state == device.StateBooted,
+			State:     state,
+			OSVersion: m[2],
+		})
+	}
+	return devices
+}
+
+// iosBatteryLevel reads the battery level of a USB-connected device with
+// libimobiledevice's ideviceinfo when it is installed
+func iosBatteryLevel(udid string) int {
+	if _, err := exec.LookPath("ideviceinfo"); err != nil {
+		return 0
+	}
+	output, err := exec.Command("ideviceinfo", "-u", udid, "-q", "com.apple.mobile.battery", "-k", "BatteryCurrentCapacity").Output()
+	if err != nil {
+		return 0
+	}
+	level, _ := strconv.Atoi(strings.TrimSpace(string(output)))
+	return level
+}
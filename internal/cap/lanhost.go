@@ -0,0 +1,225 @@
+package cap
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icarus-itcs/lazycap/internal/device"
+)
+
+// HostInterface is a network interface of this machine that a device could
+// load the live reload dev server from
+type HostInterface struct {
+	Name    string // en0, wlan0, ...
+	IP      string
+	Network *net.IPNet
+	Virtual bool // VPN, container or VM interface
+}
+
+// String returns the address and interface, e.g. "192.168.1.5 (en0)"
+func (i HostInterface) String() string {
+	return fmt.Sprintf("%s (%s)", i.IP, i.Name)
+}
+
+// virtualInterfaces are name prefixes of interfaces devices rarely share a
+// network with
+var virtualInterfaces = []string{
+	"docker", "br-", "veth", "virbr", "vboxnet", "vmnet", "bridge",
+	"utun", "tun", "tap", "wg", "tailscale", "zt", "awdl", "llw", "anpi",
+}
+
+// LANInterfaces returns the up, non-loopback IPv4 interfaces of this
+// machine, Wi-Fi and Ethernet before VPN, container and VM interfaces
+func LANInterfaces() []HostInterface {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var result []HostInterface
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil || ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			result = append(result, HostInterface{
+				Name:    iface.Name,
+				IP:      ipNet.IP.String(),
+				Network: &net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask), Mask: ipNet.Mask},
+				Virtual: isVirtualInterface(iface.Name),
+			})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return !result[i].Virtual && result[j].Virtual
+	})
+	return result
+}
+
+func isVirtualInterface(name string) bool {
+	for _, prefix := range virtualInterfaces {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AndroidNetwork returns the IPv4 address and network of an Android device,
+// preferring Wi-Fi over cellular, from adb shell ip route
+func AndroidNetwork(serial string) (net.IP, *net.IPNet, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "adb", "-s", serial, "shell", "ip", "route").Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("adb shell ip route: %w", err)
+	}
+	ip, network := parseIPRoute(string(output))
+	if ip == nil {
+		return nil, nil, fmt.Errorf("%s has no IPv4 network", serial)
+	}
+	return ip, network, nil
+}
+
+// parseIPRoute reads the device address and network from ip route output:
+//
+//	192.168.1.0/24 dev wlan0 proto kernel scope link src 192.168.1.23
+//
+// Wi-Fi wins over other interfaces, which win over VPN and container ones.
+func parseIPRoute(output string) (net.IP, *net.IPNet) {
+	var ip net.IP
+	var network *net.IPNet
+	best := 3
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var dev, src string
+		for i := 0; i+1 < len(fields); i++ {
+			switch fields[i] {
+			case "dev":
+				dev = fields[i+1]
+			case "src":
+				src = fields[i+1]
+			}
+		}
+		// Cellular (rmnet, ccmni) can't reach this machine
+		if src == "" || strings.HasPrefix(dev, "rmnet") || strings.HasPrefix(dev, "ccmni") || dev == "lo" {
+			continue
+		}
+		_, n, err := net.ParseCIDR(fields[0])
+		srcIP := net.ParseIP(src)
+		if err != nil || srcIP.To4() == nil {
+			continue
+		}
+		rank := 1
+		switch {
+		case strings.HasPrefix(dev, "wlan"):
+			rank = 0
+		case isVirtualInterface(dev):
+			rank = 2
+		}
+		if rank < best {
+			ip, network, best = srcIP, n, rank
+		}
+	}
+	return ip, network
+}
+
+// DetectLANHost returns the address a physical device should load the live
+// reload dev server from, and how it was chosen. It is this machine's address
+// on the device's subnet: Android devices report theirs with adb, wireless
+// ones have it in their address. Otherwise the first Wi-Fi or Ethernet
+// interface is used. Emulators, simulators and the web get "" and use the
+// Capacitor CLI's default.
+func DetectLANHost(dev *device.Device) (string, string) {
+	if dev == nil || dev.IsEmulator || dev.IsWeb {
+		return "", ""
+	}
+	var deviceIP net.IP
+	switch {
+	case dev.IsNetwork() && dev.Address != "":
+		host, _, err := net.SplitHostPort(dev.Address)
+		if err != nil {
+			host = dev.Address
+		}
+		deviceIP = net.ParseIP(host)
+	case dev.Platform == "android":
+		deviceIP, _, _ = AndroidNetwork(dev.ID)
+	}
+	return pickLANHost(dev, deviceIP, LANInterfaces(), LocalAddressFor)
+}
+
+// pickLANHost chooses the address for dev among ifaces. deviceIP is the
+// device's address when known; route returns the local address traffic to a
+// host leaves from, for devices on a network none of ifaces is on.
+func pickLANHost(dev *device.Device, deviceIP net.IP, ifaces []HostInterface, route func(host string) (string, error)) (string, string) {
+	if deviceIP != nil {
+		for _, iface := range ifaces {
+			if iface.Network != nil && iface.Network.Contains(deviceIP) {
+				return iface.IP, fmt.Sprintf("on %s, the subnet of %s (%s)", iface.Name, dev.Name, deviceIP)
+			}
+		}
+		// Routed networks: the address traffic to the device leaves from
+		if local, err := route(deviceIP.String()); err == nil {
+			return local, fmt.Sprintf("the route to %s (%s)", dev.Name, deviceIP)
+		}
+	}
+	for _, iface := range ifaces {
+		if !iface.Virtual {
+			return iface.IP, fmt.Sprintf("%s, the first network interface", iface.Name)
+		}
+	}
+	if len(ifaces) > 0 {
+		return ifaces[0].IP, fmt.Sprintf("%s, the first network interface", ifaces[0].Name)
+	}
+	return "", ""
+}
+
+// LiveReloadHost returns the address a device should load the live reload
+// dev server from, or "" when it can't tell (see DetectLANHost)
+func LiveReloadHost(dev *device.Device) string {
+	host, _ := DetectLANHost(dev)
+	return host
+}
+
+// CheckLiveReloadHost makes sure the dev server on port answers at host
+// before the app is pointed at it. Android devices also check from the
+// device itself when their nc can, which catches other networks and firewalls.
+func CheckLiveReloadHost(dev *device.Device, host string, port int) error {
+	if host == "" || host == "localhost" {
+		return nil
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	conn, err := net.DialTimeout("tcp", address, 3*time.Second)
+	if err != nil {
+		return fmt.Errorf("dev server not reachable at %s: %w", address, err)
+	}
+	_ = conn.Close()
+
+	if dev == nil || dev.Platform != "android" || dev.IsEmulator {
+		return nil
+	}
+	script := fmt.Sprintf("if nc --help 2>&1 | grep -q -- ' -z'; then nc -z -w 3 %s %d && echo ok || echo fail; else echo skip; fi", host, port)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "adb", "-s", dev.ID, "shell", script).Output()
+	if err == nil && strings.TrimSpace(string(output)) == "fail" {
+		return fmt.Errorf("%s can't reach %s; is it on the same Wi-Fi network?", dev.Name, address)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package cap
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/icarus-itcs/lazycap/internal/device"
+)
+
+func TestParseIPRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		ip      string // "" for none
+		network string
+	}{
+		{
+			name:    "wifi",
+			output:  "192.168.1.0/24 dev wlan0 proto kernel scope link src 192.168.1.23 \n",
+			ip:      "192.168.1.23",
+			network: "192.168.1.0/24",
+		},
+		{
+			name: "wifi after cellular",
+			output: `10.45.120.0/27 dev rmnet_data1 proto kernel scope link src 10.45.120.7
+100.64.0.0/10 dev ccmni0 proto kernel scope link src 100.71.3.4
+192.168.1.0/24 dev wlan0 proto kernel scope link src 192.168.1.23
+`,
+			ip:      "192.168.1.23",
+			network: "192.168.1.0/24",
+		},
+		{
+			name: "wifi after VPN",
+			output: `10.8.0.0/24 dev tun0 proto kernel scope link src 10.8.0.2
+192.168.50.0/24 dev wlan1 proto kernel scope link src 192.168.50.9
+`,
+			ip:      "192.168.50.9",
+			network: "192.168.50.0/24",
+		},
+		{
+			name: "ethernet adapter and docker",
+			output: `172.17.0.0/16 dev docker0 proto kernel scope link src 172.17.0.1
+10.0.0.0/24 dev eth0 proto kernel scope link src 10.0.0.12
+`,
+			ip:      "10.0.0.12",
+			network: "10.0.0.0/24",
+		},
+		{
+			name:    "VPN and cellular only",
+			output:  "10.45.120.0/27 dev rmnet0 proto kernel scope link src 10.45.120.7\n10.8.0.0/24 dev tun0 proto kernel scope link src 10.8.0.2\n",
+			ip:      "10.8.0.2",
+			network: "10.8.0.0/24",
+		},
+		{
+			name:    "first wifi",
+			output:  "192.168.1.0/24 dev wlan0 proto kernel scope link src 192.168.1.23\n192.168.49.0/24 dev wlan1 proto kernel scope link src 192.168.49.1\n",
+			ip:      "192.168.1.23",
+			network: "192.168.1.0/24",
+		},
+		{
+			name:   "cellular only",
+			output: "10.45.120.0/27 dev rmnet_data1 proto kernel scope link src 10.45.120.7\n",
+		},
+		{
+			// Default routes carry no source address
+			name:   "default routes only",
+			output: "default via 192.168.1.1 dev wlan0 proto dhcp\ndefault dev rmnet0 scope link\n",
+		},
+		{
+			name:    "no default route",
+			output:  "192.168.1.0/24 dev wlan0 proto kernel scope link src 192.168.1.23\n",
+			ip:      "192.168.1.23",
+			network: "192.168.1.0/24",
+		},
+		{
+			name:   "IPv6 and loopback",
+			output: "fe80::/64 dev wlan0 proto kernel metric 256 src fe80::1\n127.0.0.0/8 dev lo proto kernel scope host src 127.0.0.1\n",
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, network := parseIPRoute(tt.output)
+			if tt.ip == "" {
+				if ip != nil || network != nil {
+					t.Errorf("parseIPRoute() = %v, %v; want none", ip, network)
+				}
+				return
+			}
+			if ip.String() != tt.ip || network == nil || network.String() != tt.network {
+				t.Errorf("parseIPRoute() = %v, %v; want %s, %s", ip, network, tt.ip, tt.network)
+			}
+		})
+	}
+}
+
+func TestPickLANHost(t *testing.T) {
+	iface := func(name, cidr string) HostInterface {
+		ip, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return HostInterface{Name: name, IP: ip.String(), Network: network, Virtual: isVirtualInterface(name)}
+	}
+	wifi := iface("en0", "192.168.1.5/24")
+	ethernet := iface("en7", "10.0.0.20/24")
+	docker := iface("docker0", "172.17.0.1/16")
+	vpn := iface("utun4", "10.8.0.2/24")
+	tailscale := iface("tailscale0", "100.101.102.103/32")
+
+	noRoute := func(string) (string, error) { return "", errors.New("network is unreachable") }
+	routeVia := func(local string) func(string) (string, error) {
+		return func(string) (string, error) { return local, nil }
+	}
+
+	tests := []struct {
+		name     string
+		deviceIP string // "" when unknown
+		ifaces   []HostInterface
+		route    func(string) (string, error)
+		want     string
+	}{
+		{name: "device on wifi", deviceIP: "192.168.1.23", ifaces: []HostInterface{wifi, ethernet, docker}, route: noRoute, want: "192.168.1.5"},
+		{name: "device on second interface", deviceIP: "10.0.0.31", ifaces: []HostInterface{wifi, ethernet}, route: noRoute, want: "10.0.0.20"},
+		{name: "subnet beats order", deviceIP: "10.0.0.31", ifaces: []HostInterface{docker, vpn, wifi, ethernet}, route: noRoute, want: "10.0.0.20"},
+		{name: "device behind VPN", deviceIP: "10.8.0.9", ifaces: []HostInterface{wifi, vpn}, route: noRoute, want: "10.8.0.2"},
+		{name: "routed network", deviceIP: "10.20.0.9", ifaces: []HostInterface{wifi, tailscale}, route: routeVia("100.101.102.103"), want: "100.101.102.103"},
+		{name: "no route", deviceIP: "10.20.0.9", ifaces: []HostInterface{docker, wifi}, route: noRoute, want: "192.168.1.5"},
+		{name: "unknown device address", ifaces: []HostInterface{docker, vpn, ethernet, wifi}, route: noRoute, want: "10.0.0.20"},
+		{name: "virtual interfaces only", ifaces: []HostInterface{vpn, docker}, route: noRoute, want: "10.8.0.2"},
+		{name: "no interfaces", deviceIP: "192.168.1.23", route: noRoute, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := &device.Device{ID: "R58M", Name: "Galaxy S23", Platform: "android"}
+			got, reason := pickLANHost(dev, net.ParseIP(tt.deviceIP), tt.ifaces, tt.route)
+			if got != tt.want {
+				t.Errorf("pickLANHost() = %q (%s), want %q", got, reason, tt.want)
+			}
+			if (got == "") != (reason == "") {
+				t.Errorf("pickLANHost() = %q with reason %q", got, reason)
+			}
+		})
+	}
+}
+
+func TestDetectLANHostDefaultsForVirtualDevices(t *testing.T) {
+	devices := []*device.Device{
+		nil,
+		{ID: "emulator-5554", Name: "Pixel_8", Platform: "android", IsEmulator: true},
+		{ID: "A1B2", Name: "iPhone 15", Platform: "ios", IsEmulator: true},
+		{ID: "web", Name: "Web", Platform: "web", IsWeb: true},
+	}
+	for _, dev := range devices {
+		if host, reason := DetectLANHost(dev); host != "" || reason != "" {
+			t.Errorf("DetectLANHost(%v) = %q, %q; want the Capacitor default", dev, host, reason)
+		}
+	}
+}
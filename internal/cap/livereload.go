@@ -0,0 +1,95 @@
+package cap
+
+import (
+	"fmt"
+
+	"github.com/icarus-itcs/lazycap/internal/device"
+)
+
+// LiveReloadOptions are the settings a live reload run is started with
+type LiveReloadOptions struct {
+	DevCommand string // webDevCommand; detected from package.json when empty
+	ADBReverse bool   // Reach the dev server over adb reverse on devices that can
+	HTTPS      bool   // Serve HTTPS with a certificate from the local CA
+	HostHint   string // How to pick another host, added when the device can't reach it
+}
+
+// LiveReload is a live reload run: the dev server to start for it and how
+// the device reaches that dev server. The TUI and the headless run share it.
+type LiveReload struct {
+	Device     device.Device
+	Run        RunOptions  // cap run options; Host is filled in by Ready when it was empty
+	Server     DevServer   // The detected dev server
+	Argv       []string    // Starts the dev server
+	Env        []string    // Extra environment for the dev server
+	Proxy      *TLSProxy   // Serves HTTPS in front of a dev server that can't itself
+	Reverse    *ADBReverse // Forwards the port when the device loads localhost over adb
+	HostReason string      // How Ready picked Run.Host, when it detected it
+
+	hostHint string
+}
+
+// NewLiveReload works out how to start the project's dev server for a live
+// reload run on dev. It listens on every interface on run.Port (8100 when
+// unset) and the device loads it from run.Host, which Ready detects when it
+// is empty. In the adb reverse mode it only listens locally and the device
+// loads localhost. With HTTPS a certificate is issued for this machine's
+// addresses and run.Host.
+func NewLiveReload(dir string, dev *device.Device, run RunOptions, opts LiveReloadOptions) (*LiveReload, error) {
+	run.LiveReload = true
+	if run.Port <= 0 {
+		run.Port = 8100
+	}
+	lr := &LiveReload{
+		Device:   *dev,
+		Server:   DetectDevServer(dir, opts.DevCommand),
+		hostHint: opts.HostHint,
+	}
+
+	listen := "0.0.0.0"
+	if opts.ADBReverse && CanReverse(dev) {
+		lr.Reverse = &ADBReverse{Serial: dev.ID, Port: run.Port}
+		run.Host = "localhost"
+		listen = "127.0.0.1"
+	}
+	lr.Argv, lr.Env = lr.Server.Listen(listen, run.Port)
+	if opts.HTTPS {
+		var err error
+		if lr.Argv, lr.Env, lr.Proxy, err = lr.Server.ListenHTTPS(listen, run.Port, run.Host); err != nil {
+			return nil, err
+		}
+		run.Https = true
+	}
+	lr.Run = run
+	return lr, nil
+}
+
+// Ready waits for the dev server to listen and starts its TLS proxy, if any.
+// It then adds the adb reverse forward, or else detects the host the device
+// loads the dev server from when none is set and checks the device can
+// reach it there. The app can be run with Run once it returns.
+func (lr *LiveReload) Ready(server *Execution) error {
+	var err error
+	if lr.Proxy != nil {
+		err = lr.Proxy.Serve(server)
+	} else {
+		err = WaitForServer(server, lr.Run.Port, DevServerTimeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if lr.Reverse != nil {
+		return lr.Reverse.Add()
+	}
+	if lr.Run.Host == "" {
+		lr.Run.Host, lr.HostReason = DetectLANHost(&lr.Device)
+	}
+	if err := CheckLiveReloadHost(&lr.Device, lr.Run.Host, lr.Run.Port); err != nil {
+		if lr.hostHint != "" {
+			return fmt.Errorf("%w (%s)", err, lr.hostHint)
+		}
+		return err
+	}
+	return nil
+}
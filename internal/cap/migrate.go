@@ -0,0 +1,290 @@
+package cap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Migration step IDs, in run order
+const (
+	StepCleanTree = "clean-tree"
+	StepBump      = "bump"
+	StepMigrate   = "migrate"
+	StepSync      = "sync"
+	StepReview    = "review"
+)
+
+// Migration step statuses
+const (
+	StepPending = "pending"
+	StepDone    = "done"
+	StepFailed  = "failed"
+	StepSkipped = "skipped"
+)
+
+// MigrationStep is one resumable step of a Capacitor upgrade
+type MigrationStep struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Migration upgrades every @capacitor package to a new version, step by step.
+// Its state is saved after each step so a failed run can be resumed.
+type Migration struct {
+	ProjectDir  string          `json:"projectDir"`
+	FromVersion string          `json:"fromVersion"`
+	ToVersion   string          `json:"toVersion"`
+	Packages    []string        `json:"packages"`
+	Steps       []MigrationStep `json:"steps"`
+	Changes     []string        `json:"changes,omitempty"` // Files changed by the migration (git status)
+	StartedAt   time.Time       `json:"startedAt"`
+}
+
+// NewMigration plans an upgrade of the project's @capacitor packages to toVersion
+func NewMigration(p *Project, fromVersion, toVersion string) (*Migration, error) {
+	if toVersion == "" {
+		return nil, fmt.Errorf("no target version")
+	}
+
+	mig := &Migration{
+		ProjectDir:  p.RootDir,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		StartedAt:   time.Now(),
+	}
+
+	// The runtime and every official plugin move together so majors stay
+	// aligned. Tools such as @capacitor/assets are versioned on their own.
+	if report, err := GetPluginReport(p); err == nil {
+		for _, plugin := range report.Plugins {
+			if plugin.Platform || plugin.Official && plugin.Plugin {
+				mig.Packages = append(mig.Packages, plugin.Name)
+			}
+		}
+	}
+	if len(mig.Packages) == 0 {
+		mig.Packages = []string{"@capacitor/core", "@capacitor/cli"}
+	}
+
+	mig.Steps = []MigrationStep{
+		{ID: StepCleanTree, Name: "Check for a clean git tree"},
+		{ID: StepBump, Name: fmt.Sprintf("Update %d @capacitor packages to %s", len(mig.Packages), toVersion)},
+		{ID: StepMigrate, Name: "Run cap migrate"},
+		{ID: StepSync, Name: "Run cap sync"},
+		{ID: StepReview, Name: "Review changed native files"},
+	}
+	for i := range mig.Steps {
+		mig.Steps[i].Status = StepPending
+	}
+
+	return mig, nil
+}
+
+// Clone returns a copy of the migration that shares no state with m, so a
+// step can run on it in the background
+func (m *Migration) Clone() *Migration {
+	clone := *m
+	clone.Packages = append([]string(nil), m.Packages...)
+	clone.Steps = append([]MigrationStep(nil), m.Steps...)
+	clone.Changes = append([]string(nil), m.Changes...)
+	return &clone
+}
+
+// migrationStatePath keeps the state inside .git so it never dirties the tree
+func migrationStatePath(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "--absolute-git-dir")
+	cmd.Dir = dir
+	if output, err := cmd.Output(); err == nil {
+		return filepath.Join(strings.TrimSpace(string(output)), "lazycap-migration.json")
+	}
+	return filepath.Join(dir, ".lazycap-migration.json")
+}
+
+// LoadMigration returns the unfinished migration for a project, if any
+func LoadMigration(dir string) (*Migration, error) {
+	data, err := os.ReadFile(migrationStatePath(dir))
+	if err != nil {
+		return nil, err
+	}
+	var mig Migration
+	if err := json.Unmarshal(data, &mig); err != nil {
+		return nil, err
+	}
+	return &mig, nil
+}
+
+// Save writes the migration state so it can be resumed
+func (m *Migration) Save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(migrationStatePath(m.ProjectDir), data, 0644)
+}
+
+// Discard removes the saved migration state
+func (m *Migration) Discard() error {
+	err := os.Remove(migrationStatePath(m.ProjectDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Done reports whether every step has completed or been skipped
+func (m *Migration) Done() bool {
+	return m.NextStep() == nil
+}
+
+// NextStep returns the first step that still needs to run (pending or failed)
+func (m *Migration) NextStep() *MigrationStep {
+	for i := range m.Steps {
+		if m.Steps[i].Status == StepPending || m.Steps[i].Status == StepFailed {
+			return &m.Steps[i]
+		}
+	}
+	return nil
+}
+
+// RunNext runs the next pending or failed step and saves the state.
+// It returns the step that ran, or nil when the migration is complete.
+func (m *Migration) RunNext() (*MigrationStep, error) {
+	step := m.NextStep()
+	if step == nil {
+		return nil, nil
+	}
+
+	output, status, err := m.runStep(step.ID)
+	step.Output = output
+	step.Status = status
+	step.Error = ""
+	if err != nil {
+		step.Status = StepFailed
+		step.Error = err.Error()
+	}
+
+	if saveErr := m.Save(); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return step, err
+}
+
+// Run runs the remaining steps, stopping at the first failure
+func (m *Migration) Run() error {
+	for !m.Done() {
+		if _, err := m.RunNext(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SkipStep marks a failed or pending step as skipped
+func (m *Migration) SkipStep(id string) error {
+	for i := range m.Steps {
+		if m.Steps[i].ID == id {
+			m.Steps[i].Status = StepSkipped
+			return m.Save()
+		}
+	}
+	return fmt.Errorf("unknown step %q", id)
+}
+
+func (m *Migration) runStep(id string) (string, string, error) {
+	pm := DetectPackageManager(m.ProjectDir)
+
+	switch id {
+	case StepCleanTree:
+		output, err := m.git("status", "--porcelain")
+		if err != nil {
+			return output, StepFailed, fmt.Errorf("not a git repository - commit your project to git first so the migration can be reviewed and rolled back")
+		}
+		if strings.TrimSpace(output) != "" {
+			return output, StepFailed, fmt.Errorf("working tree has uncommitted changes - commit or stash them first")
+		}
+		return "Working tree is clean", StepDone, nil
+
+	case StepBump:
+		major := majorVersion(m.ToVersion)
+		pkgs := make([]string, 0, len(m.Packages))
+		for _, name := range m.Packages {
+			pkgs = append(pkgs, fmt.Sprintf("%s@^%d.0.0", name, major))
+		}
+		output, err := m.run(pm.Add(pkgs...))
+		if err != nil {
+			return output, StepFailed, err
+		}
+		return output, StepDone, nil
+
+	case StepMigrate:
+		// cap migrate only exists from Capacitor 5 and only matters across majors
+		if majorVersion(m.FromVersion) == majorVersion(m.ToVersion) {
+			return "Same major version - nothing to migrate", StepSkipped, nil
+		}
+		if help, _ := m.run(pm.Exec("cap", "--help")); !strings.Contains(help, "migrate") {
+			return "This Capacitor CLI has no migrate command", StepSkipped, nil
+		}
+		output, err := m.run(pm.Exec("cap", "migrate", "--noprompt"))
+		if err != nil {
+			return output, StepFailed, err
+		}
+		return output, StepDone, nil
+
+	case StepSync:
+		output, err := m.run(pm.Exec("cap", "sync"))
+		if err != nil {
+			return output, StepFailed, err
+		}
+		return output, StepDone, nil
+
+	case StepReview:
+		output, err := m.git("status", "--porcelain")
+		if err != nil {
+			return output, StepFailed, err
+		}
+		m.Changes = nil
+		for _, line := range strings.Split(output, "\n") {
+			if len(line) > 3 {
+				m.Changes = append(m.Changes, strings.TrimSpace(line[3:]))
+			}
+		}
+		diffStat, _ := m.git("diff", "--stat")
+		return diffStat, StepDone, nil
+	}
+
+	return "", StepFailed, fmt.Errorf("unknown step %q", id)
+}
+
+// NativeChanges returns the changed files under the native android/ios projects
+func (m *Migration) NativeChanges() []string {
+	var native []string
+	for _, path := range m.Changes {
+		if strings.HasPrefix(path, "android/") || strings.HasPrefix(path, "ios/") ||
+			strings.Contains(path, "/android/") || strings.Contains(path, "/ios/") {
+			native = append(native, path)
+		}
+	}
+	return native
+}
+
+func (m *Migration) run(argv []string) (string, error) {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = m.ProjectDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("%s failed: %w", strings.Join(argv, " "), err)
+	}
+	return string(output), nil
+}
+
+func (m *Migration) git(args ...string) (string, error) {
+	return m.run(append([]string{"git"}, args...))
+}
@@ -0,0 +1,285 @@
+package cap
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AndroidProject is what lazycap reads from the native Android project
+type AndroidProject struct {
+	GradleFile    string   `json:"gradleFile"`
+	ApplicationID string   `json:"applicationId,omitempty"`
+	VersionName   string   `json:"versionName,omitempty"`
+	VersionCode   int      `json:"versionCode,omitempty"`
+	Flavors       []string `json:"flavors,omitempty"`
+	BuildTypes    []string `json:"buildTypes,omitempty"`
+}
+
+// IOSProject is what lazycap reads from the native iOS project
+type IOSProject struct {
+	ProjectFile      string   `json:"projectFile"`
+	InfoPlist        string   `json:"infoPlist,omitempty"`
+	BundleID         string   `json:"bundleId,omitempty"`
+	MarketingVersion string   `json:"marketingVersion,omitempty"`
+	BuildNumber      string   `json:"buildNumber,omitempty"`
+	Schemes          []string `json:"schemes,omitempty"`
+	Configurations   []string `json:"configurations,omitempty"`
+}
+
+var (
+	gradleApplicationID = regexp.MustCompile(`(?m)^\s*applicationId\s*=?\s*["']([^"']+)["']`)
+	gradleVersionName   = regexp.MustCompile(`(?m)^\s*versionName\s*=?\s*["']([^"']+)["']`)
+	gradleVersionCode   = regexp.MustCompile(`(?m)^\s*versionCode\s*=?\s*(\d+)`)
+	// Block entries: `name {`, `create("name") {`, `getByName("name") {`, `register("name") {`
+	gradleBlockEntry = regexp.MustCompile(`^\s*(?:(?:create|getByName|register|maybeCreate)\s*\(\s*["']([\w-]+)["']\s*\)|([A-Za-z_][\w]*))\s*\{`)
+
+	pbxSetting = regexp.MustCompile(`(?m)^\s*([A-Z_]+)\s*=\s*"?([^";]*)"?;`)
+	pbxName    = regexp.MustCompile(`(?m)^\s*name\s*=\s*"?([^";]+)"?;`)
+	plistEntry = regexp.MustCompile(`<key>([^<]+)</key>\s*<string>([^<]*)</string>`)
+	xcodeVar   = regexp.MustCompile(`^\$[({]([A-Z_]+)[)}]$`)
+)
+
+// LoadAndroidProject parses android/app/build.gradle(.kts) under androidDir
+func LoadAndroidProject(androidDir string) (*AndroidProject, error) {
+	var gradleFile string
+	for _, name := range []string{"build.gradle", "build.gradle.kts"} {
+		path := filepath.Join(androidDir, "app", name)
+		if _, err := os.Stat(path); err == nil {
+			gradleFile = path
+			break
+		}
+	}
+	if gradleFile == "" {
+		return nil, os.ErrNotExist
+	}
+
+	data, err := os.ReadFile(gradleFile)
+	if err != nil {
+		return nil, err
+	}
+	src := stripGradleComments(string(data))
+
+	project := &AndroidProject{GradleFile: gradleFile}
+
+	// Prefer values from defaultConfig, fall back to anywhere in the file
+	scope := gradleBlock(src, "defaultConfig")
+	if scope == "" {
+		scope = src
+	}
+	if m := gradleApplicationID.FindStringSubmatch(scope); m != nil {
+		project.ApplicationID = m[1]
+	}
+	if m := gradleVersionName.FindStringSubmatch(scope); m != nil {
+		project.VersionName = m[1]
+	}
+	if m := gradleVersionCode.FindStringSubmatch(scope); m != nil {
+		project.VersionCode, _ = strconv.Atoi(m[1])
+	}
+
+	project.Flavors = gradleBlockEntries(gradleBlock(src, "productFlavors"))
+
+	// debug and release always exist, even when not declared
+	project.BuildTypes = []string{"debug", "release"}
+	for _, bt := range gradleBlockEntries(gradleBlock(src, "buildTypes")) {
+		if bt != "debug" && bt != "release" {
+			project.BuildTypes = append(project.BuildTypes, bt)
+		}
+	}
+
+	return project, nil
+}
+
+// stripGradleComments removes // and /* */ comments outside of strings
+func stripGradleComments(src string) string {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case quote != 0:
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(src) {
+				i++
+				b.WriteByte(src[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			b.WriteByte(c)
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			b.WriteByte('\n')
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				return b.String()
+			}
+			i += end + 3
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// gradleBlock returns the body of the first `name { ... }` block
+func gradleBlock(src, name string) string {
+	re := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\s*\{`)
+	loc := re.FindStringIndex(src)
+	if loc == nil {
+		return ""
+	}
+	start := loc[1]
+	depth := 1
+	for i := start; i < len(src); i++ {
+		switch src[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return src[start:i]
+			}
+		}
+	}
+	return ""
+}
+
+// gradleBlockEntries lists the names of the nested blocks directly inside body
+func gradleBlockEntries(body string) []string {
+	var names []string
+	depth := 0
+	for _, line := range strings.Split(body, "\n") {
+		if depth == 0 {
+			if m := gradleBlockEntry.FindStringSubmatch(line); m != nil {
+				name := m[1]
+				if name == "" {
+					name = m[2]
+				}
+				if name != "all" && name != "configureEach" {
+					names = append(names, name)
+				}
+			}
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+	}
+	return names
+}
+
+// LoadIOSProject parses the Xcode project and Info.plist under iosDir
+func LoadIOSProject(iosDir string) (*IOSProject, error) {
+	matches, _ := filepath.Glob(filepath.Join(iosDir, "App", "*.xcodeproj"))
+	if len(matches) == 0 {
+		matches, _ = filepath.Glob(filepath.Join(iosDir, "*.xcodeproj"))
+	}
+	if len(matches) == 0 {
+		return nil, os.ErrNotExist
+	}
+	xcodeproj := matches[0]
+	appDir := filepath.Dir(xcodeproj)
+
+	project := &IOSProject{ProjectFile: filepath.Join(xcodeproj, "project.pbxproj")}
+	settings := make(map[string]string)
+
+	if data, err := os.ReadFile(project.ProjectFile); err == nil {
+		section := string(data)
+		if start := strings.Index(section, "/* Begin XCBuildConfiguration section */"); start != -1 {
+			section = section[start:]
+			if end := strings.Index(section, "/* End XCBuildConfiguration section */"); end != -1 {
+				section = section[:end]
+			}
+		}
+		// First value wins; the app target is listed before test targets
+		for _, m := range pbxSetting.FindAllStringSubmatch(section, -1) {
+			if _, ok := settings[m[1]]; !ok {
+				settings[m[1]] = m[2]
+			}
+		}
+		seen := make(map[string]bool)
+		for _, m := range pbxName.FindAllStringSubmatch(section, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				project.Configurations = append(project.Configurations, m[1])
+			}
+		}
+	}
+
+	project.BundleID = resolveXcodeVar(settings["PRODUCT_BUNDLE_IDENTIFIER"], settings)
+	project.MarketingVersion = resolveXcodeVar(settings["MARKETING_VERSION"], settings)
+	project.BuildNumber = resolveXcodeVar(settings["CURRENT_PROJECT_VERSION"], settings)
+
+	// Info.plist literals win; $(VARS) resolve to the build settings above
+	plistPath := filepath.Join(appDir, strings.TrimSuffix(filepath.Base(xcodeproj), ".xcodeproj"), "Info.plist")
+	if data, err := os.ReadFile(plistPath); err == nil {
+		project.InfoPlist = plistPath
+		for _, m := range plistEntry.FindAllStringSubmatch(string(data), -1) {
+			value := resolveXcodeVar(m[2], settings)
+			if value == "" {
+				continue
+			}
+			switch m[1] {
+			case "CFBundleIdentifier":
+				project.BundleID = value
+			case "CFBundleShortVersionString":
+				project.MarketingVersion = value
+			case "CFBundleVersion":
+				project.BuildNumber = value
+			}
+		}
+	}
+
+	project.Schemes = findXcodeSchemes(xcodeproj)
+
+	return project, nil
+}
+
+// resolveXcodeVar expands a value that is a single $(VAR) or ${VAR} build
+// setting reference, following settings that refer to other settings.
+// Unknown settings resolve to "".
+func resolveXcodeVar(value string, settings map[string]string) string {
+	for i := 0; i < 8; i++ {
+		v := xcodeVar.FindStringSubmatch(value)
+		if v == nil {
+			return value
+		}
+		value = settings[v[1]]
+	}
+	return ""
+}
+
+// findXcodeSchemes lists shared and user schemes, falling back to the
+// project name (Xcode autocreates a scheme per target)
+func findXcodeSchemes(xcodeproj string) []string {
+	seen := make(map[string]bool)
+	var schemes []string
+
+	workspace := strings.TrimSuffix(xcodeproj, ".xcodeproj") + ".xcworkspace"
+	patterns := []string{
+		filepath.Join(xcodeproj, "xcshareddata", "xcschemes", "*.xcscheme"),
+		filepath.Join(xcodeproj, "xcuserdata", "*", "xcschemes", "*.xcscheme"),
+		filepath.Join(workspace, "xcshareddata", "xcschemes", "*.xcscheme"),
+	}
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, m := range matches {
+			name := strings.TrimSuffix(filepath.Base(m), ".xcscheme")
+			if !seen[name] {
+				seen[name] = true
+				schemes = append(schemes, name)
+			}
+		}
+	}
+
+	if len(schemes) == 0 {
+		schemes = append(schemes, strings.TrimSuffix(filepath.Base(xcodeproj), ".xcodeproj"))
+	}
+	sort.Strings(schemes)
+	return schemes
+}
@@ -0,0 +1,386 @@
+package cap
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Generated by the Capacitor Android template
+const capacitorBuildGradle = `apply plugin: 'com.android.application'
+
+android {
+    namespace "io.ionic.starter"
+    compileSdk rootProject.ext.compileSdkVersion
+    defaultConfig {
+        applicationId "io.ionic.starter"
+        minSdkVersion rootProject.ext.minSdkVersion
+        targetSdkVersion rootProject.ext.targetSdkVersion
+        versionCode 1
+        versionName "1.0"
+        testInstrumentationRunner "androidx.test.runner.AndroidJUnitRunner"
+        aaptOptions {
+             // Files and dirs to omit from the packaged assets dir, modified to accommodate modern web apps.
+             // Default: https://android.googlesource.com/platform/frameworks/base/+/282e181b58cf72b6ca770dc7ca5f91f135444502/tools/aapt/AaptAssets.cpp#61
+            ignoreAssetsPattern '!.svn:!.cvs:!.git:!.ds_store:!*.scc:.*:!CVS:!thumbs.db:!picasa.ini:!*~'
+        }
+    }
+    buildTypes {
+        release {
+            minifyEnabled false
+            proguardFiles getDefaultProguardFile('proguard-android.txt'), 'proguard-rules.pro'
+        }
+    }
+}
+
+repositories {
+    flatDir{
+        dirs '../capacitor-cordova-android-plugins/src/main/libs', 'libs'
+    }
+}
+
+apply from: 'capacitor.build.gradle'
+`
+
+const kotlinBuildGradle = `plugins {
+    id("com.android.application")
+}
+
+android {
+    namespace = "com.example.app"
+    compileSdk = 34
+
+    defaultConfig {
+        applicationId = "com.example.app"
+        minSdk = 22
+        targetSdk = 34
+        versionCode = 42
+        versionName = "2.3.1"
+    }
+
+    /*
+    defaultConfig {
+        applicationId = "com.example.old"
+    }
+    */
+
+    buildTypes {
+        getByName("release") {
+            isMinifyEnabled = true
+        }
+        create("staging") { initWith(getByName("debug")) }
+        configureEach {
+            buildConfigField("String", "URL", "\"https://example.com\"")
+        }
+    }
+
+    flavorDimensions += "env"
+    productFlavors {
+        create("dev") {
+            dimension = "env"
+            applicationIdSuffix = ".dev"
+        }
+        create("prod") {
+            dimension = "env"
+        }
+    }
+}
+`
+
+func TestLoadAndroidProject(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  AndroidProject // GradleFile relative to the android dir
+	}{
+		{
+			name:  "capacitor template",
+			files: map[string]string{"app/build.gradle": capacitorBuildGradle},
+			want: AndroidProject{
+				GradleFile:    "app/build.gradle",
+				ApplicationID: "io.ionic.starter",
+				VersionName:   "1.0",
+				VersionCode:   1,
+				BuildTypes:    []string{"debug", "release"},
+			},
+		},
+		{
+			name:  "kotlin dsl",
+			files: map[string]string{"app/build.gradle.kts": kotlinBuildGradle},
+			want: AndroidProject{
+				GradleFile:    "app/build.gradle.kts",
+				ApplicationID: "com.example.app",
+				VersionName:   "2.3.1",
+				VersionCode:   42,
+				Flavors:       []string{"dev", "prod"},
+				BuildTypes:    []string{"debug", "release", "staging"},
+			},
+		},
+		{
+			name: "groovy flavors and single quotes",
+			files: map[string]string{"app/build.gradle": `android {
+    defaultConfig {
+        applicationId 'com.example.shop' // the store listing
+        versionCode 1203
+        versionName '12.3'
+    }
+    flavorDimensions "tier"
+    productFlavors {
+        free {
+            dimension "tier"
+            applicationId "com.example.shop.free"
+        }
+        paid { dimension "tier" }
+    }
+    buildTypes {
+        debug { applicationIdSuffix ".debug" }
+        qa {}
+    }
+}
+`},
+			want: AndroidProject{
+				GradleFile:    "app/build.gradle",
+				ApplicationID: "com.example.shop",
+				VersionName:   "12.3",
+				VersionCode:   1203,
+				Flavors:       []string{"free", "paid"},
+				BuildTypes:    []string{"debug", "release", "qa"},
+			},
+		},
+		{
+			// Values that aren't literals aren't guessed
+			name: "variable references",
+			files: map[string]string{"app/build.gradle": `def appVersionCode = 7
+android {
+    defaultConfig {
+        applicationId "com.example.vars"
+        versionCode appVersionCode
+        versionName rootProject.ext.appVersionName
+    }
+}
+`},
+			want: AndroidProject{
+				GradleFile:    "app/build.gradle",
+				ApplicationID: "com.example.vars",
+				BuildTypes:    []string{"debug", "release"},
+			},
+		},
+		{
+			name: "groovy wins over kotlin",
+			files: map[string]string{
+				"app/build.gradle":     capacitorBuildGradle,
+				"app/build.gradle.kts": kotlinBuildGradle,
+			},
+			want: AndroidProject{
+				GradleFile:    "app/build.gradle",
+				ApplicationID: "io.ionic.starter",
+				VersionName:   "1.0",
+				VersionCode:   1,
+				BuildTypes:    []string{"debug", "release"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, tt.files)
+
+			got, err := LoadAndroidProject(dir)
+			if err != nil {
+				t.Fatalf("LoadAndroidProject: %v", err)
+			}
+			tt.want.GradleFile = filepath.Join(dir, filepath.FromSlash(tt.want.GradleFile))
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("LoadAndroidProject() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadAndroidProject(t.TempDir()); err == nil {
+		t.Error("LoadAndroidProject without a build.gradle succeeded")
+	}
+}
+
+// The XCBuildConfiguration section of the Capacitor iOS template. The
+// project-level configurations come first and carry no bundle identifier.
+const capacitorPBXConfigurations = `// !$*UTF8*$!
+{
+	objects = {
+/* Begin XCBuildConfiguration section */
+		504EC3141FED79650016851F /* Debug */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				ALWAYS_SEARCH_USER_PATHS = NO;
+				IPHONEOS_DEPLOYMENT_TARGET = 13.0;
+				SDKROOT = iphoneos;
+			};
+			name = Debug;
+		};
+		504EC3151FED79650016851F /* Release */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				ALWAYS_SEARCH_USER_PATHS = NO;
+				IPHONEOS_DEPLOYMENT_TARGET = 13.0;
+				SDKROOT = iphoneos;
+				VALIDATE_PRODUCT = YES;
+			};
+			name = Release;
+		};
+		504EC3171FED79650016851F /* Debug */ = {
+			isa = XCBuildConfiguration;
+			baseConfigurationReference = FC68EB0AF532CFC21C3344DD /* Pods-App.debug.xcconfig */;
+			buildSettings = {
+				ASSETCATALOG_COMPILER_APPICON_NAME = AppIcon;
+				CODE_SIGN_STYLE = Automatic;
+				CURRENT_PROJECT_VERSION = %s;
+				INFOPLIST_FILE = App/Info.plist;
+				MARKETING_VERSION = %s;
+				OTHER_SWIFT_FLAGS = "$(inherited) \"-D\" \"COCOAPODS\" \"-DDEBUG\"";
+				PRODUCT_BUNDLE_IDENTIFIER = %s;
+				PRODUCT_NAME = "$(TARGET_NAME)";
+				SWIFT_VERSION = 5.0;
+			};
+			name = Debug;
+		};
+		504EC3181FED79650016851F /* Release */ = {
+			isa = XCBuildConfiguration;
+			buildSettings = {
+				CURRENT_PROJECT_VERSION = 99;
+				MARKETING_VERSION = 9.9;
+				PRODUCT_BUNDLE_IDENTIFIER = com.example.release;
+			};
+			name = Release;
+		};
+/* End XCBuildConfiguration section */
+	};
+}
+`
+
+// Info.plist as generated, with the build settings as variables
+const capacitorInfoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleDisplayName</key>
+	<string>My App</string>
+	<key>CFBundleIdentifier</key>
+	<string>$(PRODUCT_BUNDLE_IDENTIFIER)</string>
+	<key>CFBundleShortVersionString</key>
+	<string>$(MARKETING_VERSION)</string>
+	<key>CFBundleVersion</key>
+	<string>$(CURRENT_PROJECT_VERSION)</string>
+</dict>
+</plist>
+`
+
+func TestLoadIOSProject(t *testing.T) {
+	pbxproj := func(build, marketing, bundleID string) string {
+		return fmt.Sprintf(capacitorPBXConfigurations, build, marketing, bundleID)
+	}
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  IOSProject // paths relative to the ios dir
+	}{
+		{
+			name: "capacitor template",
+			files: map[string]string{
+				"App/App.xcodeproj/project.pbxproj": pbxproj("1", "1.0", "io.ionic.starter"),
+				"App/App/Info.plist":                capacitorInfoPlist,
+			},
+			want: IOSProject{
+				ProjectFile:      "App/App.xcodeproj/project.pbxproj",
+				InfoPlist:        "App/App/Info.plist",
+				BundleID:         "io.ionic.starter",
+				MarketingVersion: "1.0",
+				BuildNumber:      "1",
+				Schemes:          []string{"App"},
+				Configurations:   []string{"Debug", "Release"},
+			},
+		},
+		{
+			name: "quoted values",
+			files: map[string]string{
+				"App/App.xcodeproj/project.pbxproj": pbxproj(`"2024.05.1"`, `"3.2.0"`, `"com.example.my-app"`),
+			},
+			want: IOSProject{
+				ProjectFile:      "App/App.xcodeproj/project.pbxproj",
+				BundleID:         "com.example.my-app",
+				MarketingVersion: "3.2.0",
+				BuildNumber:      "2024.05.1",
+				Schemes:          []string{"App"},
+				Configurations:   []string{"Debug", "Release"},
+			},
+		},
+		{
+			name: "settings referring to user-defined settings",
+			files: map[string]string{
+				"App/App.xcodeproj/project.pbxproj": strings.Replace(
+					pbxproj(`"${APP_BUILD}"`, `"$(APP_VERSION)"`, `"$(APP_BUNDLE_ID)"`),
+					"\t\t\t\tCODE_SIGN_STYLE",
+					"\t\t\t\tAPP_BUILD = 17;\n\t\t\t\tAPP_BUNDLE_ID = com.example.vars;\n\t\t\t\tAPP_VERSION = 4.0.2;\n\t\t\t\tCODE_SIGN_STYLE",
+					1),
+				"App/App/Info.plist": capacitorInfoPlist,
+			},
+			want: IOSProject{
+				ProjectFile:      "App/App.xcodeproj/project.pbxproj",
+				InfoPlist:        "App/App/Info.plist",
+				BundleID:         "com.example.vars",
+				MarketingVersion: "4.0.2",
+				BuildNumber:      "17",
+				Schemes:          []string{"App"},
+				Configurations:   []string{"Debug", "Release"},
+			},
+		},
+		{
+			name: "plist literals win",
+			files: map[string]string{
+				"App/App.xcodeproj/project.pbxproj": pbxproj("1", "1.0", "io.ionic.starter"),
+				"App/App/Info.plist": `<plist version="1.0"><dict>
+	<key>CFBundleIdentifier</key>
+	<string>com.example.literal</string>
+	<key>CFBundleShortVersionString</key>
+	<string>5.1</string>
+	<key>CFBundleVersion</key>
+	<string>$(UNDEFINED_SETTING)</string>
+</dict></plist>`,
+				"App/App.xcodeproj/xcshareddata/xcschemes/App.xcscheme":              "",
+				"App/App.xcodeproj/xcuserdata/me.xcuserdatad/xcschemes/Dev.xcscheme": "",
+			},
+			want: IOSProject{
+				ProjectFile:      "App/App.xcodeproj/project.pbxproj",
+				InfoPlist:        "App/App/Info.plist",
+				BundleID:         "com.example.literal",
+				MarketingVersion: "5.1",
+				BuildNumber:      "1",
+				Schemes:          []string{"App", "Dev"},
+				Configurations:   []string{"Debug", "Release"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, tt.files)
+
+			got, err := LoadIOSProject(dir)
+			if err != nil {
+				t.Fatalf("LoadIOSProject: %v", err)
+			}
+			tt.want.ProjectFile = filepath.Join(dir, filepath.FromSlash(tt.want.ProjectFile))
+			if tt.want.InfoPlist != "" {
+				tt.want.InfoPlist = filepath.Join(dir, filepath.FromSlash(tt.want.InfoPlist))
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("LoadIOSProject() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadIOSProject(t.TempDir()); err == nil {
+		t.Error("LoadIOSProject without an Xcode project succeeded")
+	}
+}
@@ -0,0 +1,187 @@
+package cap
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PackageManager identifies the JS package manager a project uses
+type PackageManager string
+
+const (
+	NPM  PackageManager = "npm"
+	Yarn PackageManager = "yarn"
+	PNPM PackageManager = "pnpm"
+	Bun  PackageManager = "bun"
+)
+
+// lockfiles maps each lockfile to its package manager, in detection priority
+var lockfiles = []struct {
+	Name    string
+	Manager PackageManager
+}{
+	{"pnpm-lock.yaml", PNPM},
+	{"yarn.lock", Yarn},
+	{"bun.lock", Bun},
+	{"bun.lockb", Bun},
+	{"package-lock.json", NPM},
+	{"npm-shrinkwrap.json", NPM},
+}
+
+// DetectPackageManager detects the package manager for a project directory.
+// The "packageManager" field in package.json wins, then lockfiles. Parent
+// directories are checked too so workspace members pick up the root lockfile.
+// Falls back to npm.
+func DetectPackageManager(dir string) PackageManager {
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return NPM
+	}
+
+	for current := absDir; ; {
+		if pm, ok := packageManagerField(filepath.Join(current, "package.json")); ok {
+			return pm
+		}
+		for _, lf := range lockfiles {
+			if _, err := os.Stat(filepath.Join(current, lf.Name)); err == nil {
+				return lf.Manager
+			}
+		}
+
+		// Don't walk past the repository root
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+
+	return NPM
+}
+
+// packageManagerField reads the corepack "packageManager" field (e.g. "pnpm@8.15.0")
+func packageManagerField(path string) (PackageManager, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	var pkg struct {
+		PackageManager string `json:"packageManager"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil || pkg.PackageManager == "" {
+		return "", false
+	}
+
+	name := strings.SplitN(pkg.PackageManager, "@", 2)[0]
+	switch PackageManager(name) {
+	case NPM, Yarn, PNPM, Bun:
+		return PackageManager(name), true
+	}
+	return "", false
+}
+
+// Lockfile returns the package manager's lockfile in dir: the one on disk
+// when it has several formats (bun.lock or the older binary bun.lockb,
+// package-lock.json or npm-shrinkwrap.json), else the one it writes today
+func (pm PackageManager) Lockfile(dir string) string {
+	name := ""
+	for _, lf := range lockfiles {
+		if lf.Manager != pm {
+			continue
+		}
+		if name == "" {
+			name = lf.Name
+		}
+		if _, err := os.Stat(filepath.Join(dir, lf.Name)); err == nil {
+			return lf.Name
+		}
+	}
+	if name == "" {
+		return "package-lock.json"
+	}
+	return name
+}
+
+// Exec returns the command line that runs a locally installed binary
+// (npx, pnpm exec, yarn, bunx)
+func (pm PackageManager) Exec(args ...string) []string {
+	switch pm {
+	case Yarn:
+		return append([]string{"yarn"}, args...)
+	case PNPM:
+		return append([]string{"pnpm", "exec"}, args...)
+	case Bun:
+		return append([]string{"bunx"}, args...)
+	default:
+		return append([]string{"npx"}, args...)
+	}
+}
+
+// RunScript returns the command line that runs a package.json script
+func (pm PackageManager) RunScript(script string) []string {
+	switch pm {
+	case Yarn:
+		return []string{"yarn", script}
+	default:
+		return []string{string(pm), "run", script}
+	}
+}
+
+// View returns the command line that prints a field of a package's latest
+// published version from the registry
+func (pm PackageManager) View(pkg, field string) []string {
+	switch pm {
+	case PNPM:
+		return []string{"pnpm", "view", pkg, field}
+	case Yarn:
+		return []string{"yarn", "info", "--silent", pkg, field}
+	default:
+		// bun has no registry query command of its own and uses npm's registry
+		return []string{"npm", "view", pkg, field}
+	}
+}
+
+// Install returns the command line that installs dependencies
+func (pm PackageManager) Install() []string {
+	return []string{string(pm), "install"}
+}
+
+// Add returns the command line that adds or upgrades dependencies
+func (pm PackageManager) Add(pkgs ...string) []string {
+	if pm == NPM {
+		return append([]string{"npm", "install"}, pkgs...)
+	}
+	return append([]string{string(pm), "add"}, pkgs...)
+}
+
+// Update returns the command line that updates dependencies within their ranges
+func (pm PackageManager) Update(pkgs ...string) []string {
+	switch pm {
+	case Yarn:
+		return append([]string{"yarn", "upgrade"}, pkgs...)
+	default:
+		return append([]string{string(pm), "update"}, pkgs...)
+	}
+}
+
+// CacheClean returns the command line that clears the package manager's cache
+func (pm PackageManager) CacheClean() []string {
+	switch pm {
+	case Yarn:
+		return []string{"yarn", "cache", "clean"}
+	case PNPM:
+		return []string{"pnpm", "store", "prune"}
+	case Bun:
+		return []string{"bun", "pm", "cache", "rm"}
+	default:
+		return []string{"npm", "cache", "clean", "--force"}
+	}
+}
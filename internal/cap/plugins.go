@@ -0,0 +1,245 @@
+package cap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PluginInfo describes one installed Capacitor package or plugin
+type PluginInfo struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version,omitempty"`  // Installed version (node_modules)
+	Declared  string   `json:"declared,omitempty"` // Range from package.json
+	Official  bool     `json:"official"`           // @capacitor/* package
+	Platform  bool     `json:"platform"`           // core, cli, android or ios itself
+	Plugin    bool     `json:"plugin"`             // Has a capacitor field or peer-depends on @capacitor/core
+	Android   bool     `json:"android"`            // Ships native Android code
+	IOS       bool     `json:"ios"`                // Ships native iOS code
+	CoreRange string   `json:"coreRange,omitempty"`
+	Issues    []string `json:"issues,omitempty"`
+}
+
+// PluginReport is the plugin inventory for a project
+type PluginReport struct {
+	CoreVersion string       `json:"coreVersion,omitempty"`
+	Plugins     []PluginInfo `json:"plugins"`
+	IssueCount  int          `json:"issueCount"`
+}
+
+// platformPackages are the Capacitor runtime packages rather than plugins
+var platformPackages = map[string]bool{
+	"@capacitor/core":    true,
+	"@capacitor/cli":     true,
+	"@capacitor/android": true,
+	"@capacitor/ios":     true,
+}
+
+var semverMajor = regexp.MustCompile(`^\D*(\d+)`)
+
+// GetPluginReport lists the Capacitor runtime packages and plugins the project
+// depends on, with version and platform compatibility issues. Other packages
+// in the @capacitor scope, such as @capacitor/assets, are tools and left out.
+func GetPluginReport(p *Project) (*PluginReport, error) {
+	data, err := os.ReadFile(filepath.Join(p.RootDir, "package.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package.json: %w", err)
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+
+	declared := make(map[string]string)
+	for name, version := range pkg.DevDependencies {
+		declared[name] = version
+	}
+	for name, version := range pkg.Dependencies {
+		declared[name] = version
+	}
+
+	androidRegistry := readNativeRegistry(filepath.Join(p.RootDir, p.Config.AndroidDir(), "capacitor.settings.gradle"))
+	iosRegistry := readNativeRegistry(
+		filepath.Join(p.RootDir, p.Config.IOSDir(), "App", "Podfile"),
+		filepath.Join(p.RootDir, p.Config.IOSDir(), "App", "CapApp-SPM", "Package.swift"),
+	)
+
+	report := &PluginReport{}
+	for name, rng := range declared {
+		info, ok := inspectPlugin(p.RootDir, name, rng, androidRegistry+iosRegistry)
+		if !ok {
+			continue
+		}
+		if name == "@capacitor/core" {
+			report.CoreVersion = info.Version
+		}
+		report.Plugins = append(report.Plugins, info)
+	}
+
+	sort.Slice(report.Plugins, func(i, j int) bool {
+		a, b := report.Plugins[i], report.Plugins[j]
+		if a.Platform != b.Platform {
+			return a.Platform
+		}
+		return a.Name < b.Name
+	})
+
+	coreMajor := majorVersion(report.CoreVersion)
+
+	for i := range report.Plugins {
+		plugin := &report.Plugins[i]
+		if plugin.Version == "" {
+			plugin.Issues = append(plugin.Issues, "not installed")
+			continue
+		}
+
+		if coreMajor > 0 && plugin.Name != "@capacitor/core" {
+			switch {
+			case plugin.CoreRange != "":
+				if !rangeAllowsMajor(plugin.CoreRange, coreMajor) {
+					plugin.Issues = append(plugin.Issues, fmt.Sprintf("requires @capacitor/core %s (installed %s)", plugin.CoreRange, report.CoreVersion))
+				}
+			case plugin.Official:
+				if major := majorVersion(plugin.Version); major != coreMajor {
+					plugin.Issues = append(plugin.Issues, fmt.Sprintf("major version %d doesn't match core %d", major, coreMajor))
+				}
+			}
+		}
+
+		if plugin.Platform {
+			continue
+		}
+		if plugin.Android && p.HasAndroid && androidRegistry != "" && !registryHas(androidRegistry, plugin.Name) {
+			plugin.Issues = append(plugin.Issues, "missing from capacitor.settings.gradle (run cap sync)")
+		}
+		if plugin.IOS && p.HasIOS && iosRegistry != "" && !registryHas(iosRegistry, plugin.Name) {
+			plugin.Issues = append(plugin.Issues, "missing from Podfile/Package.swift (run cap sync)")
+		}
+	}
+
+	for _, plugin := range report.Plugins {
+		report.IssueCount += len(plugin.Issues)
+	}
+
+	return report, nil
+}
+
+// inspectPlugin reads a dependency's installed package.json and reports
+// whether it is a Capacitor runtime package or plugin. Plugins declare a
+// capacitor field or a peer dependency on @capacitor/core.
+func inspectPlugin(root, name, declared, registry string) (PluginInfo, bool) {
+	info := PluginInfo{
+		Name:     name,
+		Declared: declared,
+		Official: strings.HasPrefix(name, "@capacitor/"),
+		Platform: platformPackages[name],
+	}
+
+	dir := resolveModuleDir(root, name)
+	if dir == "" {
+		// A missing package is only known to be a plugin if cap sync registered it
+		info.Plugin = !info.Platform && registryHas(registry, name)
+		return info, info.Platform || info.Plugin
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return info, false
+	}
+	var pkg struct {
+		Version          string                     `json:"version"`
+		Capacitor        map[string]json.RawMessage `json:"capacitor"`
+		PeerDependencies map[string]string          `json:"peerDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return info, false
+	}
+
+	info.Version = pkg.Version
+	info.CoreRange = pkg.PeerDependencies["@capacitor/core"]
+	_, info.Android = pkg.Capacitor["android"]
+	_, info.IOS = pkg.Capacitor["ios"]
+	info.Plugin = !info.Platform && (pkg.Capacitor != nil || info.CoreRange != "")
+
+	return info, info.Platform || info.Plugin
+}
+
+// resolveModuleDir finds node_modules/<name> like Node does, walking up from
+// root so hoisted workspace dependencies are found
+func resolveModuleDir(root, name string) string {
+	for current := root; ; {
+		dir := filepath.Join(current, "node_modules", filepath.FromSlash(name))
+		if _, err := os.Stat(filepath.Join(dir, "package.json")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return ""
+		}
+		current = parent
+	}
+}
+
+// readNativeRegistry concatenates the native files that list installed plugins
+func readNativeRegistry(paths ...string) string {
+	var sb strings.Builder
+	for _, path := range paths {
+		if data, err := os.ReadFile(path); err == nil {
+			sb.Write(data)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// registryHas reports whether a native registry references the plugin, either by
+// its node_modules path or by the Gradle project name cap sync generates
+func registryHas(registry, name string) bool {
+	if strings.Contains(registry, "node_modules/"+name+"/") ||
+		strings.Contains(registry, "node_modules/"+name+"'") ||
+		strings.Contains(registry, "node_modules/"+name+"\"") {
+		return true
+	}
+	gradleName := ":" + strings.ReplaceAll(strings.TrimPrefix(name, "@"), "/", "-")
+	return strings.Contains(registry, gradleName+"'") || strings.Contains(registry, gradleName+"\"")
+}
+
+// majorVersion returns the major component of a version string (0 if unknown)
+func majorVersion(version string) int {
+	m := semverMajor.FindStringSubmatch(version)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// rangeAllowsMajor does a coarse check of an npm range (e.g. "^5.0.0 || ^6.0.0",
+// ">=5.0.0") against a major version
+func rangeAllowsMajor(rng string, major int) bool {
+	for _, alt := range strings.Split(rng, "||") {
+		alt = strings.TrimSpace(alt)
+		if alt == "" || alt == "*" || alt == "latest" {
+			return true
+		}
+		lower := strings.Fields(alt)[0]
+		m := majorVersion(lower)
+		switch {
+		case strings.HasPrefix(lower, ">"):
+			if major >= m {
+				return true
+			}
+		case major == m:
+			return true
+		}
+	}
+	return false
+}
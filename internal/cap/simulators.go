@@ -0,0 +1,408 @@
+package cap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/icarus-itcs/lazycap/internal/device"
+)
+
+// SimRuntime is an installed simulator runtime
+type SimRuntime struct {
+	Identifier  string // com.apple.CoreSimulator.SimRuntime.iOS-17-2
+	Name        string // iOS 17.2
+	Version     string
+	DeviceTypes []SimDeviceType // Device types the runtime supports (Xcode 11+)
+}
+
+// SimDeviceType is a simulator hardware model
+type SimDeviceType struct {
+	Identifier    string `json:"identifier"` // com.apple.CoreSimulator.SimDeviceType.iPhone-15-Pro
+	Name          string `json:"name"`       // iPhone 15 Pro
+	ProductFamily string `json:"productFamily"`
+}
+
+// StatusBar is a simulator status bar override. Empty fields are left as
+// they are.
+type StatusBar struct {
+	Time         string // e.g. "9:41"
+	BatteryLevel int    // 1-100
+	BatteryState string // charging, charged or discharging
+	DataNetwork  string // wifi, 3g, 4g, lte, 5g, ...
+	WiFiBars     int    // 1-3
+	CellularBars int    // 1-4
+	OperatorName string
+}
+
+// CleanStatusBar is the status bar used for App Store screenshots
+var CleanStatusBar = StatusBar{
+	Time:         "9:41",
+	BatteryLevel: 100,
+	BatteryState: "charged",
+	DataNetwork:  "wifi",
+	WiFiBars:     3,
+	CellularBars: 4,
+}
+
+// SimulatorSettings are changes to a booted simulator. Empty fields are
+// left as they are.
+type SimulatorSettings struct {
+	Appearance     string     // "dark" or "light"
+	Locale         string     // e.g. "de_DE"; restarts the simulator
+	StatusBar      *StatusBar // Override the status bar
+	ClearStatusBar bool
+	Location       string // "latitude,longitude"
+	ClearLocation  bool
+}
+
+// simctlOutput runs xcrun simctl and returns its trimmed output. Errors carry
+// the message simctl printed.
+func simctlOutput(args ...string) (string, error) {
+	output, err := exec.Command("xcrun", append([]string{"simctl"}, args...)...).CombinedOutput()
+	text := strings.TrimSpace(string(output))
+	if err != nil {
+		if text != "" {
+			lines := strings.Split(text, "\n")
+			return "", fmt.Errorf("simctl %s: %s", args[0], strings.TrimSpace(lines[len(lines)-1]))
+		}
+		return "", fmt.Errorf("simctl %s: %w", args[0], err)
+	}
+	return text, nil
+}
+
+func simctl(args ...string) error {
+	_, err := simctlOutput(args...)
+	return err
+}
+
+// ListSimRuntimes lists the available iOS simulator runtimes, newest first
+func ListSimRuntimes() ([]SimRuntime, error) {
+	output, err := simctlOutput("list", "runtimes", "-j")
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Runtimes []struct {
+			Identifier           string          `json:"identifier"`
+			Name                 string          `json:"name"`
+			Version              string          `json:"version"`
+			IsAvailable          bool            `json:"isAvailable"`
+			SupportedDeviceTypes []SimDeviceType `json:"supportedDeviceTypes"`
+		} `json:"runtimes"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse simctl runtimes: %w", err)
+	}
+
+	var runtimes []SimRuntime
+	for _, r := range result.Runtimes {
+		if !r.IsAvailable || !strings.Contains(r.Identifier, "iOS") {
+			continue
+		}
+		runtimes = append(runtimes, SimRuntime{
+			Identifier:  r.Identifier,
+			Name:        r.Name,
+			Version:     r.Version,
+			DeviceTypes: r.SupportedDeviceTypes,
+		})
+	}
+	sort.SliceStable(runtimes, func(i, j int) bool {
+		return device.CompareVersions(runtimes[i].Version, runtimes[j].Version) > 0
+	})
+	return runtimes, nil
+}
+
+// ListSimDeviceTypes lists the iPhone and iPad device types a runtime can
+// run. Older Xcodes don't say, so every iPhone and iPad type is listed.
+func ListSimDeviceTypes(runtime *SimRuntime) ([]SimDeviceType, error) {
+	var types []SimDeviceType
+	if runtime != nil {
+		types = runtime.DeviceTypes
+	}
+	if len(types) == 0 {
+		output, err := simctlOutput("list", "devicetypes", "-j")
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			DeviceTypes []SimDeviceType `json:"devicetypes"`
+		}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			return nil, fmt.Errorf("failed to parse simctl device types: %w", err)
+		}
+		types = result.DeviceTypes
+	}
+
+	var list []SimDeviceType
+	for _, t := range types {
+		if t.ProductFamily == "iPhone" || t.ProductFamily == "iPad" {
+			list = append(list, t)
+		}
+	}
+	return list, nil
+}
+
+// FindSimRuntime finds an installed runtime by identifier, name ("iOS 17.2")
+// or version ("17.2"). An empty query is the newest runtime.
+func FindSimRuntime(query string) (*SimRuntime, error) {
+	runtimes, err := ListSimRuntimes()
+	if err != nil {
+		return nil, err
+	}
+	if len(runtimes) == 0 {
+		return nil, fmt.Errorf("no iOS simulator runtimes installed (Xcode > Settings > Platforms)")
+	}
+	if query == "" {
+		return &runtimes[0], nil
+	}
+	for i, r := range runtimes {
+		if r.Identifier == query || strings.EqualFold(r.Name, query) || r.Version == query {
+			return &runtimes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no iOS runtime %q installed", query)
+}
+
+// FindSimDeviceType finds a device type of a runtime by identifier or name
+// (case-insensitive). An empty query is the runtime's newest iPhone.
+func FindSimDeviceType(runtime *SimRuntime, query string) (*SimDeviceType, error) {
+	types, err := ListSimDeviceTypes(runtime)
+	if err != nil {
+		return nil, err
+	}
+	if query == "" {
+		// Device types are listed oldest first
+		for i := len(types) - 1; i >= 0; i-- {
+			if types[i].ProductFamily == "iPhone" {
+				return &types[i], nil
+			}
+		}
+		return nil, fmt.Errorf("no iPhone device types available")
+	}
+	for i, t := range types {
+		if t.Identifier == query || strings.EqualFold(t.Name, query) {
+			return &types[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no device type %q", query)
+}
+
+// CreateSimulator creates a simulator and returns its UDID
+func CreateSimulator(name, deviceType, runtime string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("simulator name required")
+	}
+	return simctlOutput("create", name, deviceType, runtime)
+}
+
+// CloneSimulator copies a shut down simulator, with its apps and data, under
+// a new name and returns the copy's UDID
+func CloneSimulator(udid, name string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("simulator name required")
+	}
+	return simctlOutput("clone", udid, name)
+}
+
+// EraseSimulator erases all content and settings of a simulator, shutting it
+// down first if needed
+func EraseSimulator(udid string) error {
+	if IsDeviceBooted(udid, "ios") {
+		if err := ShutdownSimulator(udid); err != nil {
+			return err
+		}
+	}
+	return simctl("erase", udid)
+}
+
+// RenameSimulator renames a simulator
+func RenameSimulator(udid, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("simulator name required")
+	}
+	return simctl("rename", udid, name)
+}
+
+// DeleteSimulator deletes a simulator and its data
+func DeleteSimulator(udid string) error {
+	return simctl("delete", udid)
+}
+
+// ShutdownSimulator shuts down a booted simulator
+func ShutdownSimulator(udid string) error {
+	return simctl("shutdown", udid)
+}
+
+// ManageSimulator runs a lifecycle action (boot, shutdown, clone, erase,
+// rename or delete) on a simulator and describes the result. Clone and
+// rename take the new name.
+func ManageSimulator(udid, action, name string) (string, error) {
+	switch action {
+	case "boot":
+		return "Booted", simctl("boot", udid)
+	case "shutdown":
+		return "Shut down", ShutdownSimulator(udid)
+	case "clone":
+		id, err := CloneSimulator(udid, name)
+		return fmt.Sprintf("Cloned as %s (%s)", name, id), err
+	case "erase":
+		return "Erased all content and settings", EraseSimulator(udid)
+	case "rename":
+		return "Renamed to " + name, RenameSimulator(udid, name)
+	case "delete":
+		return "Deleted", DeleteSimulator(udid)
+	}
+	return "", fmt.Errorf("unknown simulator action %q (boot, shutdown, clone, erase, rename or delete)", action)
+}
+
+// SetSimulatorAppearance switches a booted simulator to dark or light mode
+func SetSimulatorAppearance(udid, appearance string) error {
+	if appearance != "dark" && appearance != "light" {
+		return fmt.Errorf("appearance must be dark or light")
+	}
+	return simctl("ui", udid, "appearance", appearance)
+}
+
+// SetSimulatorLocale sets the language and region of a booted simulator and
+// restarts it so apps pick them up
+func SetSimulatorLocale(udid, locale string) error {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "-", "_")
+	if locale == "" {
+		return fmt.Errorf("locale required, e.g. en_US")
+	}
+	language := strings.SplitN(locale, "_", 2)[0]
+	if err := simctl("spawn", udid, "defaults", "write", "Apple Global Domain", "AppleLanguages", "-array", language); err != nil {
+		return err
+	}
+	if err := simctl("spawn", udid, "defaults", "write", "Apple Global Domain", "AppleLocale", "-string", locale); err != nil {
+		return err
+	}
+	if err := ShutdownSimulator(udid); err != nil {
+		return err
+	}
+	if err := simctl("boot", udid); err != nil {
+		return err
+	}
+	// Wait until it has finished booting so later changes apply
+	return simctl("bootstatus", udid)
+}
+
+// StatusBarArgs returns the simctl status_bar override arguments
+func StatusBarArgs(bar StatusBar) []string {
+	var args []string
+	if bar.Time != "" {
+		args = append(args, "--time", bar.Time)
+	}
+	if bar.BatteryLevel > 0 {
+		args = append(args, "--batteryLevel", strconv.Itoa(bar.BatteryLevel))
+	}
+	if bar.BatteryState != "" {
+		args = append(args, "--batteryState", bar.BatteryState)
+	}
+	if bar.DataNetwork != "" {
+		args = append(args, "--dataNetwork", bar.DataNetwork)
+	}
+	if bar.WiFiBars > 0 {
+		args = append(args, "--wifiMode", "active", "--wifiBars", strconv.Itoa(bar.WiFiBars))
+	}
+	if bar.CellularBars > 0 {
+		args = append(args, "--cellularMode", "active", "--cellularBars", strconv.Itoa(bar.CellularBars))
+	}
+	if bar.OperatorName != "" {
+		args = append(args, "--operatorName", bar.OperatorName)
+	}
+	return args
+}
+
+// OverrideStatusBar overrides the status bar of a booted simulator
+func OverrideStatusBar(udid string, bar StatusBar) error {
+	args := StatusBarArgs(bar)
+	if len(args) == 0 {
+		return fmt.Errorf("no status bar overrides given")
+	}
+	return simctl(append([]string{"status_bar", udid, "override"}, args...)...)
+}
+
+// ClearStatusBar removes status bar overrides from a booted simulator
+func ClearStatusBar(udid string) error {
+	return simctl("status_bar", udid, "clear")
+}
+
+// ParseLocation parses "latitude,longitude"
+func ParseLocation(location string) (float64, float64, error) {
+	parts := strings.Split(location, ",")
+	if len(parts) == 2 {
+		lat, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		lon, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err1 == nil && err2 == nil && lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180 {
+			return lat, lon, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("location must be \"latitude,longitude\", e.g. 37.3349,-122.0090")
+}
+
+// SetSimulatorLocation simulates a fixed location ("latitude,longitude") on
+// a booted simulator (Xcode 14+)
+func SetSimulatorLocation(udid, location string) error {
+	lat, lon, err := ParseLocation(location)
+	if err != nil {
+		return err
+	}
+	return simctl("location", udid, "set", fmt.Sprintf("%g,%g", lat, lon))
+}
+
+// ClearSimulatorLocation stops simulating a location
+func ClearSimulatorLocation(udid string) error {
+	return simctl("location", udid, "clear")
+}
+
+// ConfigureSimulator applies settings to a booted simulator and describes
+// what changed. It stops at the first failure.
+func ConfigureSimulator(udid string, s SimulatorSettings) ([]string, error) {
+	var done []string
+	step := func(desc string, err error) error {
+		if err == nil {
+			done = append(done, desc)
+		}
+		return err
+	}
+
+	// First, since the restart drops status bar and location overrides
+	if s.Locale != "" {
+		if err := step("locale "+s.Locale+" (restarted)", SetSimulatorLocale(udid, s.Locale)); err != nil {
+			return done, err
+		}
+	}
+	if s.Appearance != "" {
+		if err := step("appearance "+s.Appearance, SetSimulatorAppearance(udid, s.Appearance)); err != nil {
+			return done, err
+		}
+	}
+	if s.ClearStatusBar {
+		if err := step("status bar cleared", ClearStatusBar(udid)); err != nil {
+			return done, err
+		}
+	} else if s.StatusBar != nil {
+		if err := step("status bar overridden", OverrideStatusBar(udid, *s.StatusBar)); err != nil {
+			return done, err
+		}
+	}
+	if s.ClearLocation {
+		if err := step("location cleared", ClearSimulatorLocation(udid)); err != nil {
+			return done, err
+		}
+	} else if s.Location != "" {
+		if err := step("location "+s.Location, SetSimulatorLocation(udid, s.Location)); err != nil {
+			return done, err
+		}
+	}
+	if len(done) == 0 {
+		return nil, fmt.Errorf("nothing to change")
+	}
+	return done, nil
+}
@@ -0,0 +1,77 @@
+package cap
+
+import (
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// TLSProxy serves HTTPS in front of a dev server whose CLI can't be given a
+// certificate, like Vite. The dev server listens on Backend on 127.0.0.1 and
+// requests and HMR websockets are forwarded to it.
+type TLSProxy struct {
+	Addr    string // host:port served over HTTPS
+	Backend int    // Port the dev server listens on
+	Cert    *DevCert
+}
+
+// Serve waits for server to listen on Backend, then starts the proxy
+func (p *TLSProxy) Serve(server *Execution) error {
+	if err := WaitForServer(server, p.Backend, DevServerTimeout); err != nil {
+		return err
+	}
+	return p.Start(server)
+}
+
+// Start serves HTTPS on Addr until server exits
+func (p *TLSProxy) Start(server *Execution) error {
+	cert, err := tls.LoadX509KeyPair(p.Cert.CertFile, p.Cert.KeyFile)
+	if err != nil {
+		return err
+	}
+	// HTTP/1.1 only: websocket upgrades don't go through HTTP/2
+	ln, err := tls.Listen("tcp", p.Addr, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"http/1.1"},
+	})
+	if err != nil {
+		return err
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: net.JoinHostPort("127.0.0.1", strconv.Itoa(p.Backend))})
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		r.Header.Set("X-Forwarded-Proto", "https")
+	}
+	proxy.ErrorLog = log.New(io.Discard, "", 0)
+	srv := &http.Server{
+		Handler:           proxy,
+		ReadHeaderTimeout: 30 * time.Second,
+		ErrorLog:          log.New(io.Discard, "", 0),
+	}
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+	go func() {
+		<-server.Done()
+		_ = srv.Close()
+	}()
+	return nil
+}
+
+// freePort returns a local port nothing listens on
+func freePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
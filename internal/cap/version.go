@@ -0,0 +1,336 @@
+package cap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version bump parts
+const (
+	BumpMajor = "major"
+	BumpMinor = "minor"
+	BumpPatch = "patch"
+	BumpBuild = "build"
+)
+
+// BumpParts lists the accepted version bump parts
+var BumpParts = []string{BumpMajor, BumpMinor, BumpPatch, BumpBuild}
+
+// VersionBump is a planned version change across package.json, Gradle and Xcode
+type VersionBump struct {
+	Part       string
+	OldVersion string
+	NewVersion string
+	OldBuild   int
+	NewBuild   int
+	Changes    []FileChange
+}
+
+// FileChange is the set of lines a version bump rewrites in one file
+type FileChange struct {
+	Path    string
+	Lines   []LineChange
+	content string
+}
+
+// LineChange is a single rewritten line
+type LineChange struct {
+	Line int // 1-based
+	Old  string
+	New  string
+}
+
+var (
+	packageVersionLine = regexp.MustCompile(`^(\s*"version"\s*:\s*")([^"]*)(".*)$`)
+	gradleNameLine     = regexp.MustCompile(`^(\s*versionName\s*=?\s*["'])([^"']+)(["'].*)$`)
+	gradleCodeLine     = regexp.MustCompile(`^(\s*versionCode\s*=?\s*)(\d+)(.*)$`)
+	pbxMarketingLine   = regexp.MustCompile(`^(\s*MARKETING_VERSION\s*=\s*"?)([^";]*)("?;.*)$`)
+	pbxBuildLine       = regexp.MustCompile(`^(\s*CURRENT_PROJECT_VERSION\s*=\s*"?)([^";]*)("?;.*)$`)
+	plistKeyLine       = regexp.MustCompile(`<key>(CFBundleShortVersionString|CFBundleVersion)</key>`)
+	plistStringLine    = regexp.MustCompile(`^(\s*<string>)([^<]*)(</string>.*)$`)
+)
+
+// PlanVersionBump works out the new version and build number for a project and
+// the file edits needed to apply them. Nothing is written until Apply.
+func PlanVersionBump(p *Project, part string) (*VersionBump, error) {
+	bump := &VersionBump{Part: part}
+
+	pkgPath := filepath.Join(p.RootDir, "package.json")
+	bump.OldVersion = readPackageVersion(pkgPath)
+	if bump.OldVersion == "" && p.Android != nil {
+		bump.OldVersion = p.Android.VersionName
+	}
+	if bump.OldVersion == "" && p.IOS != nil {
+		bump.OldVersion = p.IOS.MarketingVersion
+	}
+	if bump.OldVersion == "" {
+		bump.OldVersion = "0.0.0"
+	}
+
+	// Stores need the build number to grow on every release, so every bump
+	// increments it, starting from the highest of the two platforms
+	if p.Android != nil {
+		bump.OldBuild = p.Android.VersionCode
+	}
+	if p.IOS != nil {
+		if n, err := strconv.Atoi(p.IOS.BuildNumber); err == nil && n > bump.OldBuild {
+			bump.OldBuild = n
+		}
+	}
+	bump.NewBuild = bump.OldBuild + 1
+
+	switch part {
+	case BumpBuild:
+		bump.NewVersion = bump.OldVersion
+	case BumpMajor, BumpMinor, BumpPatch:
+		v, err := BumpSemver(bump.OldVersion, part)
+		if err != nil {
+			return nil, err
+		}
+		bump.NewVersion = v
+	default:
+		return nil, fmt.Errorf("unknown version part %q (use %s)", part, strings.Join(BumpParts, ", "))
+	}
+
+	newBuild := strconv.Itoa(bump.NewBuild)
+
+	if change, err := planFileChange(pkgPath, func(line string, st *lineState) string {
+		// Only the top-level key; "version" also appears nested, e.g. in
+		// config sections or overrides
+		depth := st.depth
+		st.depth = jsonDepth(line, depth)
+		if depth != 1 || st.seen["version"] || !packageVersionLine.MatchString(line) {
+			return line
+		}
+		st.seen["version"] = true
+		return replaceFirst(packageVersionLine, line, bump.NewVersion)
+	}); err == nil {
+		bump.addChange(change)
+	}
+
+	if p.Android != nil {
+		if change, err := planFileChange(p.Android.GradleFile, func(line string, st *lineState) string {
+			if !st.seen["name"] && gradleNameLine.MatchString(line) {
+				st.seen["name"] = true
+				return replaceFirst(gradleNameLine, line, bump.NewVersion)
+			}
+			if !st.seen["code"] && gradleCodeLine.MatchString(line) {
+				st.seen["code"] = true
+				return replaceFirst(gradleCodeLine, line, newBuild)
+			}
+			return line
+		}); err == nil {
+			bump.addChange(change)
+		}
+	}
+
+	if p.IOS != nil {
+		if change, err := planFileChange(p.IOS.ProjectFile, func(line string, _ *lineState) string {
+			// Every build configuration carries its own copy of these
+			line = replaceFirst(pbxMarketingLine, line, bump.NewVersion)
+			return replaceFirst(pbxBuildLine, line, newBuild)
+		}); err == nil {
+			bump.addChange(change)
+		}
+
+		// Only literal Info.plist values need rewriting; $(VARS) follow the pbxproj
+		if p.IOS.InfoPlist != "" {
+			if change, err := planFileChange(p.IOS.InfoPlist, func(line string, st *lineState) string {
+				if m := plistKeyLine.FindStringSubmatch(line); m != nil {
+					st.pending = m[1]
+					return line
+				}
+				key := st.pending
+				st.pending = ""
+				m := plistStringLine.FindStringSubmatch(line)
+				if m == nil || strings.HasPrefix(m[2], "$") {
+					return line
+				}
+				switch key {
+				case "CFBundleShortVersionString":
+					return m[1] + bump.NewVersion + m[3]
+				case "CFBundleVersion":
+					return m[1] + newBuild + m[3]
+				}
+				return line
+			}); err == nil {
+				bump.addChange(change)
+			}
+		}
+	}
+
+	if len(bump.Changes) == 0 {
+		return nil, fmt.Errorf("no version fields found in package.json, build.gradle or project.pbxproj")
+	}
+
+	return bump, nil
+}
+
+func (b *VersionBump) addChange(change *FileChange) {
+	if len(change.Lines) > 0 {
+		b.Changes = append(b.Changes, *change)
+	}
+}
+
+// lineState carries per-file state between lines while planning edits
+type lineState struct {
+	seen    map[string]bool
+	pending string
+	depth   int
+}
+
+// jsonDepth returns the object/array nesting depth after line, given the
+// depth before it. Brackets inside strings don't count.
+func jsonDepth(line string, depth int) int {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return depth
+}
+
+// planFileChange runs edit over every line of path and records the lines it changed
+func planFileChange(path string, edit func(line string, st *lineState) string) (*FileChange, error) {
+	if path == "" {
+		return nil, os.ErrNotExist
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &FileChange{Path: path}
+	st := &lineState{seen: make(map[string]bool)}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		updated := edit(line, st)
+		if updated != line {
+			change.Lines = append(change.Lines, LineChange{Line: i + 1, Old: line, New: updated})
+			lines[i] = updated
+		}
+	}
+	change.content = strings.Join(lines, "\n")
+	return change, nil
+}
+
+// replaceFirst swaps the value group of a prefix/value/suffix line pattern
+func replaceFirst(re *regexp.Regexp, line, value string) string {
+	if m := re.FindStringSubmatch(line); m != nil {
+		return m[1] + value + m[3]
+	}
+	return line
+}
+
+// readPackageVersion returns the top-level "version" field of a package.json
+func readPackageVersion(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return ""
+	}
+	return pkg.Version
+}
+
+// BumpSemver increments the major, minor or patch part of a version.
+// A leading "v" and any prerelease/build suffix are dropped.
+func BumpSemver(version, part string) (string, error) {
+	core := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.IndexAny(core, "-+"); idx != -1 {
+		core = core[:idx]
+	}
+
+	nums := [3]int{}
+	for i, s := range strings.SplitN(core, ".", 3) {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid version %q", version)
+		}
+		nums[i] = n
+	}
+
+	switch part {
+	case BumpMajor:
+		nums = [3]int{nums[0] + 1, 0, 0}
+	case BumpMinor:
+		nums = [3]int{nums[0], nums[1] + 1, 0}
+	case BumpPatch:
+		nums[2]++
+	}
+	return fmt.Sprintf("%d.%d.%d", nums[0], nums[1], nums[2]), nil
+}
+
+// Diff renders the planned edits as a unified-style diff
+func (b *VersionBump) Diff() string {
+	var sb strings.Builder
+	for _, change := range b.Changes {
+		fmt.Fprintf(&sb, "--- %s\n+++ %s\n", change.Path, change.Path)
+		for _, l := range change.Lines {
+			fmt.Fprintf(&sb, "@@ %d @@\n-%s\n+%s\n", l.Line, l.Old, l.New)
+		}
+	}
+	return sb.String()
+}
+
+// Apply writes the planned edits to disk
+func (b *VersionBump) Apply() error {
+	for _, change := range b.Changes {
+		info, err := os.Stat(change.Path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(change.Path, []byte(change.content), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", change.Path, err)
+		}
+	}
+	return nil
+}
+
+// TagVersion commits the bumped files and creates an annotated git tag
+// (vX.Y.Z). Only the bumped files go into the commit; anything else already
+// staged stays staged.
+func TagVersion(dir string, b *VersionBump) (string, error) {
+	tag := "v" + b.NewVersion
+	if b.Part == BumpBuild {
+		tag = fmt.Sprintf("v%s+%d", b.NewVersion, b.NewBuild)
+	}
+
+	paths := make([]string, 0, len(b.Changes))
+	for _, change := range b.Changes {
+		paths = append(paths, change.Path)
+	}
+
+	steps := [][]string{
+		append([]string{"add", "--"}, paths...),
+		append([]string{"commit", "-m", "Release " + tag, "--"}, paths...),
+		{"tag", "-a", tag, "-m", "Release " + tag},
+	}
+	for _, args := range steps {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(output)))
+		}
+	}
+	return tag, nil
+}
@@ -0,0 +1,159 @@
+package cap
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icarus-itcs/lazycap/internal/device"
+)
+
+// WatchDevices sends the device list whenever a device connects, disconnects,
+// boots or shuts down, until ctx is canceled. Android changes are pushed by
+// adb track-devices; simulators are polled with simctl every interval.
+// Physical iOS devices are picked up with the next change or manual refresh.
+func WatchDevices(ctx context.Context, interval time.Duration) <-chan []device.Device {
+	out := make(chan []device.Device)
+	triggers := make(chan struct{}, 1)
+	trigger := func() {
+		select {
+		case triggers <- struct{}{}:
+		default:
+		}
+	}
+
+	go trackAndroidDevices(ctx, interval, trigger)
+	go pollSimulators(ctx, interval, trigger)
+
+	go func() {
+		defer close(out)
+		var last []device.Device
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-triggers:
+			}
+
+			// Let a burst of changes (adb lists an emulator as offline, then
+			// as a device) settle before listing
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(500 * time.Millisecond):
+			}
+			select {
+			case <-triggers:
+			default:
+			}
+
+			devices, err := ListDevices()
+			if err != nil {
+				continue
+			}
+			if last != nil && device.Diff(last, devices).Empty() {
+				continue
+			}
+			last = devices
+			select {
+			case out <- devices:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// trackAndroidDevices runs adb track-devices, restarting it when the adb
+// server goes away, and triggers whenever its device list changes
+func trackAndroidDevices(ctx context.Context, retry time.Duration, trigger func()) {
+	if _, err := exec.LookPath("adb"); err != nil {
+		return
+	}
+
+	var last string
+	first := true
+	for {
+		cmd := exec.CommandContext(ctx, "adb", "track-devices")
+		stdout, err := cmd.StdoutPipe()
+		if err == nil {
+			err = cmd.Start()
+		}
+		if err == nil {
+			reader := bufio.NewReader(stdout)
+			for {
+				list, err := readADBMessage(reader)
+				if err != nil {
+					break
+				}
+				// The first list is what the initial device load already saw
+				if !first && list != last {
+					trigger()
+				}
+				first = false
+				last = list
+			}
+			_ = cmd.Wait()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+// readADBMessage reads one message of the adb host protocol: four hex digits
+// of length followed by the payload
+func readADBMessage(r io.Reader) (string, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", err
+	}
+	n, err := strconv.ParseUint(string(header), 16, 32)
+	if err != nil {
+		return "", err
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+// pollSimulators triggers when a simulator is created, deleted, booted or
+// shut down
+func pollSimulators(ctx context.Context, interval time.Duration, trigger func()) {
+	if _, err := exec.LookPath("xcrun"); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := simulatorFingerprint()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if current := simulatorFingerprint(); current != last {
+			last = current
+			trigger()
+		}
+	}
+}
+
+func simulatorFingerprint() string {
+	var sb strings.Builder
+	for _, sim := range listSimulators() {
+		sb.WriteString(sim.ID + " " + sim.Name + " " + string(sim.State) + "\n")
+	}
+	return sb.String()
+}
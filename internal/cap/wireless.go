@@ -0,0 +1,163 @@
+package cap
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultADBPort is the port adb tcpip and wireless connections use when an
+// address has none
+const DefaultADBPort = "5555"
+
+// ADBAddress adds the default adb port to a host without one
+func ADBAddress(address string) string {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return ""
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return net.JoinHostPort(strings.Trim(address, "[]"), DefaultADBPort)
+	}
+	return address
+}
+
+// adbWireless runs an adb pair/connect/disconnect command. adb often exits 0
+// when these fail, so the output is checked for the success message.
+func adbWireless(ctx context.Context, success []string, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, "adb", args...).CombinedOutput()
+	text := strings.TrimSpace(string(output))
+	lower := strings.ToLower(text)
+	for _, s := range success {
+		if strings.Contains(lower, s) {
+			return text, nil
+		}
+	}
+	if text == "" && err != nil {
+		text = err.Error()
+	}
+	return "", fmt.Errorf("adb %s: %s", args[0], text)
+}
+
+// PairADB pairs with an Android 11+ device using the address and six-digit
+// code from its "Pair device with pairing code" screen
+func PairADB(ctx context.Context, address, code string) error {
+	code = strings.TrimSpace(code)
+	if address == "" || code == "" {
+		return fmt.Errorf("pairing address and code required")
+	}
+	_, err := adbWireless(ctx, []string{"successfully paired"}, "pair", address, code)
+	return err
+}
+
+// ConnectADB connects to a device over Wi-Fi. The address is host:port,
+// with 5555 as the default port.
+func ConnectADB(ctx context.Context, address string) error {
+	address = ADBAddress(address)
+	if address == "" {
+		return fmt.Errorf("device address required")
+	}
+	_, err := adbWireless(ctx, []string{"connected to"}, "connect", address)
+	return err
+}
+
+// DisconnectADB disconnects a wireless device
+func DisconnectADB(ctx context.Context, address string) error {
+	_, err := adbWireless(ctx, []string{"disconnected"}, "disconnect", ADBAddress(address))
+	return err
+}
+
+// ReconnectADB tries to connect to each address in the background, giving each
+// a few seconds, and returns the addresses that connected
+func ReconnectADB(addresses []string) []string {
+	if _, err := exec.LookPath("adb"); err != nil || len(addresses) == 0 {
+		return nil
+	}
+	results := make(chan string, len(addresses))
+	for _, address := range addresses {
+		go func(address string) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if ConnectADB(ctx, address) == nil {
+				results <- address
+				return
+			}
+			results <- ""
+		}(address)
+	}
+	var connected []string
+	for range addresses {
+		if address := <-results; address != "" {
+			connected = append(connected, address)
+		}
+	}
+	return connected
+}
+
+// AndroidModel returns the model name of a connected device
+func AndroidModel(serial string) string {
+	return androidProp(serial, "ro.product.model")
+}
+
+// AndroidSerialNumber returns the hardware serial number of a connected
+// device, which stays the same when its wireless debugging port changes
+func AndroidSerialNumber(serial string) string {
+	return androidProp(serial, "ro.serialno")
+}
+
+func androidProp(serial, name string) string {
+	output, err := exec.Command("adb", "-s", serial, "shell", "getprop", name).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// isNetworkSerial reports whether an adb serial is a wireless connection:
+// host:port for adb connect, or an mDNS service name for devices adb found
+// with wireless debugging
+func isNetworkSerial(serial string) bool {
+	if strings.Contains(serial, "._adb-tls-connect._tcp") || strings.Contains(serial, "._adb._tcp") {
+		return true
+	}
+	_, _, err := net.SplitHostPort(serial)
+	return err == nil
+}
+
+// adbMDNSAddresses maps the mDNS service names adb discovered to their
+// host:port, for wireless devices connected without adb connect
+func adbMDNSAddresses() map[string]string {
+	output, err := exec.Command("adb", "mdns", "services").Output()
+	if err != nil {
+		return nil
+	}
+	addresses := map[string]string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		// adb-R58M12345-AbCdEf	_adb-tls-connect._tcp	192.168.1.23:37123
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		addresses[fields[0]+"."+strings.TrimSuffix(fields[1], ".")] = fields[len(fields)-1]
+	}
+	return addresses
+}
+
+// LocalAddressFor returns this machine's IP address on the network that
+// reaches host, i.e. the address a device at host can connect back to
+func LocalAddressFor(host string) (string, error) {
+	// Dialing UDP sends nothing; it only picks the route and source address
+	conn, err := net.Dial("udp", net.JoinHostPort(host, "9"))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok || addr.IP.IsLoopback() {
+		return "", fmt.Errorf("no network route to %s", host)
+	}
+	return addr.IP.String(), nil
+}
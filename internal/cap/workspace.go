@@ -0,0 +1,336 @@
+package cap
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Workspace tools, in the order they take over running scripts
+const (
+	WorkspaceNx    = "nx"
+	WorkspaceTurbo = "turbo"
+	WorkspacePNPM  = "pnpm"
+	WorkspaceYarn  = "yarn"
+	WorkspaceNPM   = "npm"
+	WorkspaceBun   = "bun"
+)
+
+// Workspace is a monorepo root described by package.json workspaces,
+// pnpm-workspace.yaml, nx.json or turbo.json
+type Workspace struct {
+	Root           string
+	Name           string
+	Tool           string // Runner used for scripts (nx, turbo, pnpm, yarn, npm, bun)
+	PackageManager PackageManager
+	Patterns       []string
+	Packages       []WorkspacePackage
+}
+
+// WorkspacePackage is a single member of a workspace
+type WorkspacePackage struct {
+	Name string
+	Dir  string
+}
+
+// skipWorkspaceDir reports whether a directory never contains workspace members
+func skipWorkspaceDir(name string) bool {
+	switch name {
+	case "node_modules", "dist", "build", "ios", "android", "vendor", "Pods", "tmp":
+		return true
+	}
+	return strings.HasPrefix(name, ".")
+}
+
+// FindWorkspace returns the workspace that dir belongs to, walking up from dir
+// until a workspace manifest is found. Returns nil when dir isn't in a workspace.
+func FindWorkspace(dir string) *Workspace {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+
+	for current := absDir; ; {
+		if ws, err := LoadWorkspace(current); err == nil {
+			return ws
+		}
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return nil
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return nil
+		}
+		current = parent
+	}
+}
+
+// LoadWorkspace reads the workspace manifests at root and expands its members
+func LoadWorkspace(root string) (*Workspace, error) {
+	ws := &Workspace{
+		Root:           root,
+		Name:           filepath.Base(root),
+		PackageManager: DetectPackageManager(root),
+	}
+
+	// package.json "workspaces" (npm, yarn, bun)
+	if data, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil {
+		var pkg struct {
+			Name       string          `json:"name"`
+			Workspaces json.RawMessage `json:"workspaces"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			if pkg.Name != "" {
+				ws.Name = pkg.Name
+			}
+			ws.Patterns = append(ws.Patterns, parseWorkspacesField(pkg.Workspaces)...)
+		}
+	}
+
+	// pnpm-workspace.yaml
+	if patterns, err := readPNPMWorkspace(filepath.Join(root, "pnpm-workspace.yaml")); err == nil {
+		ws.Patterns = append(ws.Patterns, patterns...)
+		ws.PackageManager = PNPM
+	}
+
+	_, nxErr := os.Stat(filepath.Join(root, "nx.json"))
+	_, turboErr := os.Stat(filepath.Join(root, "turbo.json"))
+	hasNx, hasTurbo := nxErr == nil, turboErr == nil
+
+	if len(ws.Patterns) == 0 && !hasNx {
+		return nil, os.ErrNotExist
+	}
+
+	switch {
+	case hasNx:
+		ws.Tool = WorkspaceNx
+	case hasTurbo:
+		ws.Tool = WorkspaceTurbo
+	default:
+		ws.Tool = string(ws.PackageManager)
+	}
+
+	ws.Packages = ws.expandPatterns()
+	if hasNx {
+		ws.Packages = mergePackages(ws.Packages, findNxProjects(root))
+	}
+	sort.Slice(ws.Packages, func(i, j int) bool {
+		return ws.Packages[i].Dir < ws.Packages[j].Dir
+	})
+
+	return ws, nil
+}
+
+// parseWorkspacesField handles both `"workspaces": [...]` and
+// `"workspaces": {"packages": [...]}` (yarn classic)
+func parseWorkspacesField(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+	var obj struct {
+		Packages []string `json:"packages"`
+	}
+	if json.Unmarshal(raw, &obj) == nil {
+		return obj.Packages
+	}
+	return nil
+}
+
+// readPNPMWorkspace extracts the `packages:` list from pnpm-workspace.yaml
+func readPNPMWorkspace(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	inPackages := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(trimmed, "-") {
+			inPackages = strings.HasPrefix(trimmed, "packages:")
+			continue
+		}
+		if inPackages && strings.HasPrefix(trimmed, "-") {
+			pattern := strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			if idx := strings.Index(pattern, " #"); idx != -1 {
+				pattern = strings.TrimSpace(pattern[:idx])
+			}
+			pattern = strings.Trim(pattern, `"'`)
+			if pattern != "" {
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// expandPatterns resolves the workspace globs to member directories.
+// Supports "*" segments, a trailing "**" and "!" exclusions.
+func (w *Workspace) expandPatterns() []WorkspacePackage {
+	included := make(map[string]bool)
+	var excludes []string
+
+	for _, pattern := range w.Patterns {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+		if strings.HasPrefix(pattern, "!") {
+			excludes = append(excludes, strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "./"))
+			continue
+		}
+
+		if strings.HasSuffix(pattern, "**") {
+			base := filepath.Join(w.Root, strings.TrimSuffix(strings.TrimSuffix(pattern, "**"), "/"))
+			walkPackageDirs(base, func(dir string) {
+				included[dir] = true
+			})
+			continue
+		}
+
+		matches, _ := filepath.Glob(filepath.Join(w.Root, filepath.FromSlash(pattern)))
+		for _, match := range matches {
+			if _, err := os.Stat(filepath.Join(match, "package.json")); err == nil {
+				included[match] = true
+			}
+		}
+	}
+
+	var packages []WorkspacePackage
+	for dir := range included {
+		rel, _ := filepath.Rel(w.Root, dir)
+		excluded := false
+		for _, ex := range excludes {
+			if ok, _ := filepath.Match(filepath.FromSlash(ex), rel); ok {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			packages = append(packages, WorkspacePackage{Name: packageName(dir), Dir: dir})
+		}
+	}
+	return packages
+}
+
+// walkPackageDirs calls fn for every directory under root with a package.json
+func walkPackageDirs(root string, fn func(dir string)) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(root, "package.json")); err == nil {
+		fn(root)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !skipWorkspaceDir(entry.Name()) {
+			walkPackageDirs(filepath.Join(root, entry.Name()), fn)
+		}
+	}
+}
+
+// findNxProjects finds Nx projects (directories with a project.json) at any depth
+func findNxProjects(root string) []WorkspacePackage {
+	var packages []WorkspacePackage
+	var walk func(dir string)
+	walk = func(dir string) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		if dir != root {
+			if data, err := os.ReadFile(filepath.Join(dir, "project.json")); err == nil {
+				var proj struct {
+					Name string `json:"name"`
+				}
+				_ = json.Unmarshal(data, &proj)
+				if proj.Name == "" {
+					proj.Name = packageName(dir)
+				}
+				packages = append(packages, WorkspacePackage{Name: proj.Name, Dir: dir})
+			}
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && !skipWorkspaceDir(entry.Name()) {
+				walk(filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	walk(root)
+	return packages
+}
+
+// mergePackages appends extra packages whose directory isn't already listed.
+// Nx project names win over package.json names for the same directory.
+func mergePackages(packages, extra []WorkspacePackage) []WorkspacePackage {
+	index := make(map[string]int, len(packages))
+	for i, p := range packages {
+		index[p.Dir] = i
+	}
+	for _, p := range extra {
+		if i, ok := index[p.Dir]; ok {
+			packages[i].Name = p.Name
+			continue
+		}
+		index[p.Dir] = len(packages)
+		packages = append(packages, p)
+	}
+	return packages
+}
+
+// packageName returns the package.json name for dir, or the directory name
+func packageName(dir string) string {
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(data, &pkg) == nil && pkg.Name != "" {
+			return pkg.Name
+		}
+	}
+	return filepath.Base(dir)
+}
+
+// PackageFor returns the workspace member containing dir, or nil
+func (w *Workspace) PackageFor(dir string) *WorkspacePackage {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	for i := range w.Packages {
+		if w.Packages[i].Dir == absDir {
+			return &w.Packages[i]
+		}
+	}
+	return nil
+}
+
+// RunScript returns the command line that runs a script for one member,
+// from the workspace root, through the workspace runner
+func (w *Workspace) RunScript(pkg *WorkspacePackage, script string) []string {
+	switch w.Tool {
+	case WorkspaceNx:
+		return w.PackageManager.Exec("nx", "run", pkg.Name+":"+script)
+	case WorkspaceTurbo:
+		return w.PackageManager.Exec("turbo", "run", script, "--filter="+pkg.Name)
+	case WorkspacePNPM:
+		return []string{"pnpm", "--filter", pkg.Name, "run", script}
+	case WorkspaceYarn:
+		return []string{"yarn", "workspace", pkg.Name, "run", script}
+	case WorkspaceBun:
+		return []string{"bun", "run", "--filter", pkg.Name, script}
+	default:
+		return []string{"npm", "run", script, "--workspace", pkg.Name}
+	}
+}
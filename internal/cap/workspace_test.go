@@ -0,0 +1,234 @@
+package cap
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTree creates files, given by slash-separated paths relative to root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadWorkspace(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		tool     string
+		pm       PackageManager
+		packages []WorkspacePackage // Dir relative to the root
+	}{
+		{
+			name: "npm",
+			files: map[string]string{
+				"package.json":                 `{"name": "mono", "workspaces": ["apps/*", "./packages/*/", "!packages/legacy"]}`,
+				"package-lock.json":            `{}`,
+				"apps/web/package.json":        `{"name": "@mono/web"}`,
+				"apps/mobile/package.json":     `{"name": "@mono/mobile"}`,
+				"apps/docs/README.md":          "not a package",
+				"packages/ui/package.json":     `{}`,
+				"packages/legacy/package.json": `{"name": "legacy"}`,
+			},
+			tool: WorkspaceNPM,
+			pm:   NPM,
+			packages: []WorkspacePackage{
+				{Name: "@mono/mobile", Dir: "apps/mobile"},
+				{Name: "@mono/web", Dir: "apps/web"},
+				{Name: "ui", Dir: "packages/ui"},
+			},
+		},
+		{
+			name: "yarn classic with nested members",
+			files: map[string]string{
+				"package.json":                      `{"private": true, "workspaces": {"packages": ["packages/**", "!packages/tools/*"], "nohoist": ["**/capacitor"]}}`,
+				"yarn.lock":                         "",
+				"packages/app/package.json":         `{"name": "app"}`,
+				"packages/shared/core/package.json": `{"name": "core"}`,
+				"packages/shared/core/node_modules/x/package.json": `{"name": "x"}`,
+				"packages/shared/core/dist/package.json":           `{"name": "dist"}`,
+				"packages/app/ios/App/package.json":                `{"name": "native"}`,
+				"packages/tools/lint/package.json":                 `{"name": "lint"}`,
+			},
+			tool: WorkspaceYarn,
+			pm:   Yarn,
+			packages: []WorkspacePackage{
+				{Name: "app", Dir: "packages/app"},
+				{Name: "core", Dir: "packages/shared/core"},
+			},
+		},
+		{
+			name: "pnpm",
+			files: map[string]string{
+				"package.json": `{"name": "mono"}`,
+				"pnpm-workspace.yaml": `# members
+packages:
+  - 'apps/*'
+  - "packages/**" # everything
+  - '!packages/internal/*'
+catalog:
+  - not-a-pattern
+`,
+				"pnpm-lock.yaml":                   "",
+				"apps/mobile/package.json":         `{"name": "mobile"}`,
+				"packages/ui/package.json":         `{"name": "ui"}`,
+				"packages/ui/icons/package.json":   `{"name": "icons"}`,
+				"packages/internal/x/package.json": `{"name": "x"}`,
+				"not-a-pattern/package.json":       `{"name": "nope"}`,
+			},
+			tool: WorkspacePNPM,
+			pm:   PNPM,
+			packages: []WorkspacePackage{
+				{Name: "mobile", Dir: "apps/mobile"},
+				{Name: "ui", Dir: "packages/ui"},
+				{Name: "icons", Dir: "packages/ui/icons"},
+			},
+		},
+		{
+			name: "bun",
+			files: map[string]string{
+				"package.json":              `{"workspaces": ["packages/*"]}`,
+				"bun.lockb":                 "",
+				"packages/app/package.json": `{"name": "app"}`,
+			},
+			tool:     WorkspaceBun,
+			pm:       Bun,
+			packages: []WorkspacePackage{{Name: "app", Dir: "packages/app"}},
+		},
+		{
+			name: "turbo",
+			files: map[string]string{
+				"package.json":             `{"workspaces": ["apps/*"], "packageManager": "yarn@4.1.0"}`,
+				"turbo.json":               `{}`,
+				"apps/mobile/package.json": `{"name": "mobile"}`,
+			},
+			tool:     WorkspaceTurbo,
+			pm:       Yarn,
+			packages: []WorkspacePackage{{Name: "mobile", Dir: "apps/mobile"}},
+		},
+		{
+			name: "nx",
+			files: map[string]string{
+				"package.json":             `{"workspaces": ["packages/*"]}`,
+				"package-lock.json":        `{}`,
+				"nx.json":                  `{}`,
+				"packages/ui/package.json": `{"name": "@mono/ui"}`,
+				"packages/ui/project.json": `{"name": "ui-lib"}`,
+				"apps/mobile/project.json": `{"name": "mobile"}`,
+				"apps/web/project.json":    `{}`,
+				"project.json":             `{"name": "root"}`,
+			},
+			tool: WorkspaceNx,
+			pm:   NPM,
+			packages: []WorkspacePackage{
+				{Name: "mobile", Dir: "apps/mobile"},
+				{Name: "web", Dir: "apps/web"},
+				{Name: "ui-lib", Dir: "packages/ui"},
+			},
+		},
+		{
+			name: "nx without workspaces",
+			files: map[string]string{
+				"nx.json":                  `{}`,
+				"pnpm-lock.yaml":           "",
+				"apps/mobile/project.json": `{"name": "mobile"}`,
+			},
+			tool:     WorkspaceNx,
+			pm:       PNPM,
+			packages: []WorkspacePackage{{Name: "mobile", Dir: "apps/mobile"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+
+			ws, err := LoadWorkspace(root)
+			if err != nil {
+				t.Fatalf("LoadWorkspace: %v", err)
+			}
+			if ws.Tool != tt.tool || ws.PackageManager != tt.pm {
+				t.Errorf("tool, package manager = %s, %s; want %s, %s", ws.Tool, ws.PackageManager, tt.tool, tt.pm)
+			}
+			for i := range tt.packages {
+				tt.packages[i].Dir = filepath.Join(root, filepath.FromSlash(tt.packages[i].Dir))
+			}
+			if !reflect.DeepEqual(ws.Packages, tt.packages) {
+				t.Errorf("packages = %v, want %v", ws.Packages, tt.packages)
+			}
+		})
+	}
+}
+
+func TestLoadWorkspaceNotAWorkspace(t *testing.T) {
+	tests := map[string]map[string]string{
+		"empty":            {},
+		"plain package":    {"package.json": `{"name": "app"}`},
+		"empty workspaces": {"package.json": `{"workspaces": []}`},
+		"turbo only":       {"turbo.json": `{}`, "package.json": `{"name": "app"}`},
+		"pnpm without packages": {
+			"pnpm-workspace.yaml": "onlyBuiltDependencies:\n  - esbuild\n",
+		},
+	}
+	for name, files := range tests {
+		root := t.TempDir()
+		writeTree(t, root, files)
+		if ws, err := LoadWorkspace(root); err == nil {
+			t.Errorf("%s: LoadWorkspace = %+v, want an error", name, ws)
+		}
+	}
+}
+
+func TestFindWorkspace(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"mono/package.json":             `{"name": "mono", "workspaces": ["apps/*"]}`,
+		"mono/apps/mobile/package.json": `{"name": "mobile"}`,
+		"mono/apps/mobile/src/main.ts":  "",
+		"mono/vendor/lib/.git/HEAD":     "",
+		"mono/vendor/lib/package.json":  `{"name": "lib"}`,
+		"standalone/package.json":       `{"name": "standalone"}`,
+		"standalone/.git/HEAD":          "",
+	})
+	mono := filepath.Join(root, "mono")
+
+	tests := []struct {
+		dir  string
+		want string // workspace root, empty for none
+	}{
+		{"mono", mono},
+		{"mono/apps/mobile", mono},
+		{"mono/apps/mobile/src", mono},
+		// A nested repository is its own project
+		{"mono/vendor/lib", ""},
+		{"standalone", ""},
+	}
+	for _, tt := range tests {
+		ws := FindWorkspace(filepath.Join(root, filepath.FromSlash(tt.dir)))
+		got := ""
+		if ws != nil {
+			got = ws.Root
+		}
+		if got != tt.want {
+			t.Errorf("FindWorkspace(%s) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+
+	ws := FindWorkspace(filepath.Join(mono, "apps", "mobile", "src"))
+	if pkg := ws.PackageFor(filepath.Join(mono, "apps", "mobile")); pkg == nil || pkg.Name != "mobile" {
+		t.Errorf("PackageFor(apps/mobile) = %v", pkg)
+	}
+	if pkg := ws.PackageFor(filepath.Join(mono, "apps", "mobile", "src")); pkg != nil {
+		t.Errorf("PackageFor(apps/mobile/src) = %v, want nil", pkg)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+
+	"github.com/icarus-itcs/lazycap/internal/cap"
 )
 
 // Action represents a debug/cleanup action
@@ -94,6 +96,14 @@ func GetActions() []Action {
 			Platform:    "darwin",
 			Dangerous:   false,
 		},
+		{
+			ID:          "simulator-trust-ca",
+			Name:        "Trust HTTPS CA on Simulators",
+			Description: "Adds lazycap's local CA to booted simulators for HTTPS live reload",
+			Category:    "iOS/Xcode",
+			Platform:    "darwin",
+			Dangerous:   false,
+		},
 
 		// Android
 		{
@@ -144,6 +154,14 @@ func GetActions() []Action {
 			Platform:    "all",
 			Dangerous:   true,
 		},
+		{
+			ID:          "emulator-trust-ca",
+			Name:        "Install HTTPS CA on Emulators",
+			Description: "Copies lazycap's local CA to booted emulators and opens the certificate installer",
+			Category:    "Android",
+			Platform:    "all",
+			Dangerous:   false,
+		},
 
 		// Node/NPM
 		{
@@ -156,16 +174,16 @@ func GetActions() []Action {
 		},
 		{
 			ID:          "npm-cache",
-			Name:        "Clear NPM Cache",
-			Description: "Runs npm cache clean --force",
+			Name:        "Clear Package Cache",
+			Description: "Clears the npm/yarn/pnpm/bun cache",
 			Category:    "Node/NPM",
 			Platform:    "all",
 			Dangerous:   false,
 		},
 		{
 			ID:          "package-lock",
-			Name:        "Regenerate package-lock",
-			Description: "Removes package-lock.json and regenerates it",
+			Name:        "Regenerate Lockfile",
+			Description: "Removes the lockfile (package-lock, yarn.lock, pnpm-lock) and regenerates it",
 			Category:    "Node/NPM",
 			Platform:    "all",
 			Dangerous:   false,
@@ -253,7 +271,7 @@ func GetActions() []Action {
 		{
 			ID:          "fresh-install",
 			Name:        "Fresh Install",
-			Description: "Full clean + npm install + pod install + cap sync",
+			Description: "Full clean + install + pod install + cap sync",
 			Category:    "Nuclear",
 			Platform:    "all",
 			Dangerous:   true,
@@ -283,6 +301,7 @@ func GetCategories() []string {
 func RunAction(id string) Result {
 	home, _ := os.UserHomeDir()
 	cwd, _ := os.Getwd()
+	pm := cap.DetectPackageManager(cwd)
 
 	switch id {
 	// iOS/Xcode
@@ -325,6 +344,9 @@ func RunAction(id string) Result {
 		runCommand(cwd, "killall", "com.apple.CoreSimulator.CoreSimulatorService")
 		return Result{Success: true, Message: "Simulator processes killed"}
 
+	case "simulator-trust-ca":
+		return trustCA("ios")
+
 	// Android
 	case "android-clean":
 		androidDir := filepath.Join(cwd, "android")
@@ -368,27 +390,30 @@ func RunAction(id string) Result {
 		}
 		return Result{Success: true, Message: fmt.Sprintf("Wiped %d emulators", len(avds))}
 
+	case "emulator-trust-ca":
+		return trustCA("android")
+
 	// Node/NPM
 	case "node-modules":
 		removeDir(filepath.Join(cwd, "node_modules"), "node_modules")
-		return runCommand(cwd, "npm", "install")
+		return runArgs(cwd, pm.Install())
 
 	case "npm-cache":
-		return runCommand(cwd, "npm", "cache", "clean", "--force")
+		return runArgs(cwd, pm.CacheClean())
 
 	case "package-lock":
-		_ = os.Remove(filepath.Join(cwd, "package-lock.json"))
-		return runCommand(cwd, "npm", "install")
+		_ = os.Remove(filepath.Join(cwd, pm.Lockfile(cwd)))
+		return runArgs(cwd, pm.Install())
 
 	// Capacitor
 	case "cap-sync-force":
 		// Remove native web assets
 		removeDir(filepath.Join(cwd, "ios/App/App/public"), "ios web assets")
 		removeDir(filepath.Join(cwd, "android/app/src/main/assets/public"), "android web assets")
-		return runCommand(cwd, "npx", "cap", "sync")
+		return runArgs(cwd, pm.Exec("cap", "sync"))
 
 	case "cap-update":
-		return runCommand(cwd, "npm", "update", "@capacitor/core", "@capacitor/cli", "@capacitor/ios", "@capacitor/android")
+		return runArgs(cwd, pm.Update("@capacitor/core", "@capacitor/cli", "@capacitor/ios", "@capacitor/android"))
 
 	// Web Build
 	case "web-cache":
@@ -432,7 +457,7 @@ func RunAction(id string) Result {
 		removeDir(filepath.Join(cwd, "node_modules/.vite"), "Vite cache")
 		removeDir(filepath.Join(cwd, "node_modules/.cache"), "Babel cache")
 		// Try to rebuild
-		return runCommand(cwd, "npm", "run", "build")
+		return runArgs(cwd, pm.RunScript("build"))
 
 	// System
 	case "watchman-cache":
@@ -456,19 +481,20 @@ func RunAction(id string) Result {
 		removeDir(filepath.Join(cwd, "android/app/build"), "android/app/build")
 		removeDir(filepath.Join(cwd, "dist"), "dist")
 		removeDir(filepath.Join(cwd, "www"), "www")
-		_ = os.Remove(filepath.Join(cwd, "package-lock.json"))
+		_ = os.Remove(filepath.Join(cwd, pm.Lockfile(cwd)))
 		_ = os.Remove(filepath.Join(cwd, "ios/Podfile.lock"))
-		return Result{Success: true, Message: "Full project clean complete", Details: "Run 'npm install' then 'npx cap sync' to rebuild"}
+		details := fmt.Sprintf("Run '%s' then '%s' to rebuild", strings.Join(pm.Install(), " "), strings.Join(pm.Exec("cap", "sync"), " "))
+		return Result{Success: true, Message: "Full project clean complete", Details: details}
 
 	case "fresh-install":
 		// Full clean first
 		RunAction("full-clean")
 		// Reinstall
-		runCommand(cwd, "npm", "install")
+		runArgs(cwd, pm.Install())
 		if runtime.GOOS == "darwin" {
 			runCommand(filepath.Join(cwd, "ios"), "pod", "install")
 		}
-		runCommand(cwd, "npx", "cap", "sync")
+		runArgs(cwd, pm.Exec("cap", "sync"))
 		return Result{Success: true, Message: "Fresh install complete"}
 
 	default:
@@ -476,6 +502,46 @@ func RunAction(id string) Result {
 	}
 }
 
+// trustCA installs lazycap's local CA, which signs the HTTPS dev server's
+// certificate, on the booted simulators or emulators of platform
+func trustCA(platform string) Result {
+	devices, err := cap.ListDevices()
+	if err != nil {
+		return Result{Success: false, Message: "Failed to list devices", Details: err.Error()}
+	}
+	var installed, failed []string
+	for i := range devices {
+		dev := &devices[i]
+		if dev.Platform != platform || !dev.IsEmulator || !dev.Online {
+			continue
+		}
+		if err := cap.TrustCA(dev); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", dev.Name, err))
+			continue
+		}
+		installed = append(installed, dev.Name)
+	}
+
+	kind := "simulators"
+	if platform == "android" {
+		kind = "emulators"
+	}
+	switch {
+	case len(installed) == 0 && len(failed) == 0:
+		return Result{Success: false, Message: "No booted " + kind}
+	case len(installed) == 0:
+		return Result{Success: false, Message: "Failed to install the HTTPS CA", Details: strings.Join(failed, "\n")}
+	}
+	details := strings.Join(failed, "\n")
+	if platform == "android" {
+		// Android only trusts user CAs in apps that opt in
+		details = strings.TrimSpace("In Settings > Security > Encryption & credentials, choose Install a certificate > CA certificate and pick Download/lazycap-ca.crt. " +
+			"The app trusts it when its network security config has <certificates src=\"user\" /> under <debug-overrides>.\n" + details)
+		return Result{Success: true, Message: "Copied the HTTPS CA to " + strings.Join(installed, ", "), Details: details}
+	}
+	return Result{Success: true, Message: "Trusted the HTTPS CA on " + strings.Join(installed, ", "), Details: details}
+}
+
 func removeDir(path, name string) Result {
 	info, err := os.Stat(path)
 	if os.IsNotExist(err) {
@@ -505,6 +571,11 @@ func removeDir(path, name string) Result {
 	return Result{Success: true, Message: fmt.Sprintf("Removed %s (%s)", name, sizeStr)}
 }
 
+// runArgs runs a full command line such as the ones built by cap.PackageManager
+func runArgs(dir string, argv []string) Result {
+	return runCommand(dir, argv[0], argv[1:]...)
+}
+
 func runCommand(dir, name string, args ...string) Result {
 	cmd := exec.Command(name, args...)
 	cmd.Dir = dir
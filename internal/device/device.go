@@ -1,22 +1,72 @@
 package device
 
+// State is where a device is in its lifecycle
+type State string
+
+const (
+	StateBooted       State = "booted"        // Running and reachable
+	StateBooting      State = "booting"       // Simulator/emulator starting up
+	StateShuttingDown State = "shutting-down" // Simulator stopping
+	StateShutdown     State = "shutdown"      // Simulator/emulator that isn't running
+	StateDisconnected State = "disconnected"  // Physical device that is paired but not reachable
+	StateUnauthorized State = "unauthorized"  // Android device waiting for USB debugging approval
+)
+
 // Device represents a mobile device, emulator, or web target
 type Device struct {
-	ID         string
-	Name       string
-	Platform   string // "android", "ios", or "web"
-	Online     bool
-	IsEmulator bool
-	IsWeb      bool   // true for web dev server
-	APILevel   string // Android API level
-	OSVersion  string // iOS version
+	ID           string
+	Name         string
+	Platform     string // "android", "ios", or "web"
+	Online       bool   // Booted and reachable (State is StateBooted)
+	State        State
+	IsEmulator   bool
+	IsWeb        bool   // true for web dev server
+	APILevel     string // Android API level
+	ABI          string // Android emulator ABI, e.g. arm64-v8a
+	OSVersion    string // iOS or Android version, e.g. "17.2"
+	Runtime      string // Simulator runtime identifier, e.g. com.apple.CoreSimulator.SimRuntime.iOS-17-2
+	Model        string // Marketing name, e.g. "iPhone 15 Pro" or "Pixel 8"
+	ModelID      string // Hardware model or simulator device type, e.g. "iPhone16,1"
+	Connection   string // "usb" or "wifi" for physical devices
+	Address      string // host:port of a network (wireless adb) device
+	BatteryLevel int    // Battery percentage (0 = unknown)
 }
 
 // String returns a display string for the device
 func (d Device) String() string {
-	status := "offline"
-	if d.Online {
-		status = "online"
+	return d.Name + " (" + d.Platform + ", " + string(d.CurrentState()) + ")"
+}
+
+// CurrentState returns the device state, derived from Online for devices
+// that were created without one
+func (d Device) CurrentState() State {
+	switch {
+	case d.State != "":
+		return d.State
+	case d.Online:
+		return StateBooted
+	case d.IsEmulator:
+		return StateShutdown
+	default:
+		return StateDisconnected
+	}
+}
+
+// Transitioning reports whether the device is booting or shutting down
+func (d Device) Transitioning() bool {
+	return d.State == StateBooting || d.State == StateShuttingDown
+}
+
+// IsNetwork reports whether the device is connected over the network, e.g.
+// Android wireless debugging. Live reload must use a LAN address for these.
+func (d Device) IsNetwork() bool {
+	return d.Connection == "wifi"
+}
+
+// Version returns the Android API level or the iOS version
+func (d Device) Version() string {
+	if d.APILevel != "" {
+		return d.APILevel
 	}
-	return d.Name + " (" + d.Platform + ", " + status + ")"
+	return d.OSVersion
 }
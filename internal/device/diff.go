@@ -0,0 +1,58 @@
+package device
+
+// Key identifies a device across device lists. Android emulators are listed
+// by AVD name while shut down and by adb serial (emulator-5554) once running,
+// so they are keyed by name; everything else by ID.
+func (d Device) Key() string {
+	if d.Platform == "android" && d.IsEmulator {
+		return "avd:" + d.Name
+	}
+	return d.ID
+}
+
+// Changes is the difference between two device lists
+type Changes struct {
+	Added    []Device // Newly connected or created
+	Removed  []Device // Disconnected or deleted
+	Booted   []Device // Listed before and now booted
+	ShutDown []Device // Listed before and no longer booted
+	Updated  []Device // Other changes, e.g. state or battery level
+}
+
+// Empty reports whether nothing changed
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Booted) == 0 &&
+		len(c.ShutDown) == 0 && len(c.Updated) == 0
+}
+
+// Diff compares an old and a new device list. Devices are matched by Key.
+func Diff(old, new []Device) Changes {
+	var c Changes
+	before := make(map[string]Device, len(old))
+	for _, d := range old {
+		before[d.Key()] = d
+	}
+
+	seen := make(map[string]bool, len(new))
+	for _, d := range new {
+		key := d.Key()
+		seen[key] = true
+		prev, ok := before[key]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, d)
+		case d.CurrentState() == StateBooted && prev.CurrentState() != StateBooted:
+			c.Booted = append(c.Booted, d)
+		case d.CurrentState() != StateBooted && prev.CurrentState() == StateBooted:
+			c.ShutDown = append(c.ShutDown, d)
+		case d != prev:
+			c.Updated = append(c.Updated, d)
+		}
+	}
+	for _, d := range old {
+		if !seen[d.Key()] {
+			c.Removed = append(c.Removed, d)
+		}
+	}
+	return c
+}
@@ -0,0 +1,151 @@
+package device
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	sim := Device{ID: "A1B2", Name: "iPhone 15", Platform: "ios", IsEmulator: true, State: StateShutdown}
+	phone := Device{ID: "R58M", Name: "Galaxy S23", Platform: "android", Online: true, State: StateBooted, BatteryLevel: 80}
+	avd := Device{ID: "Pixel_8_API_34", Name: "Pixel_8_API_34", Platform: "android", IsEmulator: true, State: StateShutdown}
+
+	with := func(d Device, change func(*Device)) Device {
+		change(&d)
+		return d
+	}
+	booted := func(d *Device) { d.Online, d.State = true, StateBooted }
+
+	tests := []struct {
+		name     string
+		old, new []Device
+		want     Changes
+	}{
+		{
+			name: "unchanged",
+			old:  []Device{sim, phone},
+			new:  []Device{phone, sim},
+		},
+		{
+			name: "appeared",
+			old:  []Device{sim},
+			new:  []Device{sim, phone},
+			want: Changes{Added: []Device{phone}},
+		},
+		{
+			name: "disappeared",
+			old:  []Device{sim, phone},
+			new:  []Device{sim},
+			want: Changes{Removed: []Device{phone}},
+		},
+		{
+			name: "first list",
+			new:  []Device{sim, phone},
+			want: Changes{Added: []Device{sim, phone}},
+		},
+		{
+			name: "all gone",
+			old:  []Device{sim, phone},
+			want: Changes{Removed: []Device{sim, phone}},
+		},
+		{
+			name: "booted",
+			old:  []Device{sim},
+			new:  []Device{with(sim, booted)},
+			want: Changes{Booted: []Device{with(sim, booted)}},
+		},
+		{
+			name: "shut down",
+			old:  []Device{with(sim, booted)},
+			new:  []Device{sim},
+			want: Changes{ShutDown: []Device{sim}},
+		},
+		{
+			name: "booting is not booted yet",
+			old:  []Device{sim},
+			new:  []Device{with(sim, func(d *Device) { d.State = StateBooting })},
+			want: Changes{Updated: []Device{with(sim, func(d *Device) { d.State = StateBooting })}},
+		},
+		{
+			name: "shutting down is no longer booted",
+			old:  []Device{with(sim, booted)},
+			new:  []Device{with(sim, func(d *Device) { d.Online, d.State = false, StateShuttingDown })},
+			want: Changes{ShutDown: []Device{with(sim, func(d *Device) { d.Online, d.State = false, StateShuttingDown })}},
+		},
+		{
+			name: "physical device unplugged",
+			old:  []Device{phone},
+			new:  []Device{with(phone, func(d *Device) { d.Online, d.State = false, StateDisconnected })},
+			want: Changes{ShutDown: []Device{with(phone, func(d *Device) { d.Online, d.State = false, StateDisconnected })}},
+		},
+		{
+			name: "unauthorized",
+			old:  []Device{with(phone, func(d *Device) { d.Online, d.State = false, StateDisconnected })},
+			new:  []Device{with(phone, func(d *Device) { d.Online, d.State = false, StateUnauthorized })},
+			want: Changes{Updated: []Device{with(phone, func(d *Device) { d.Online, d.State = false, StateUnauthorized })}},
+		},
+		{
+			name: "battery level",
+			old:  []Device{phone},
+			new:  []Device{with(phone, func(d *Device) { d.BatteryLevel = 79 })},
+			want: Changes{Updated: []Device{with(phone, func(d *Device) { d.BatteryLevel = 79 })}},
+		},
+		{
+			name: "state derived from online",
+			old:  []Device{{ID: "web", Name: "Web", Platform: "web", IsWeb: true}},
+			new:  []Device{{ID: "web", Name: "Web", Platform: "web", IsWeb: true, Online: true}},
+			want: Changes{Booted: []Device{{ID: "web", Name: "Web", Platform: "web", IsWeb: true, Online: true}}},
+		},
+		{
+			// A running emulator is listed by adb serial, not AVD name
+			name: "emulator booted under its serial",
+			old:  []Device{avd},
+			new:  []Device{with(avd, func(d *Device) { d.ID = "emulator-5554"; booted(d) })},
+			want: Changes{Booted: []Device{with(avd, func(d *Device) { d.ID = "emulator-5554"; booted(d) })}},
+		},
+		{
+			name: "emulator shut down",
+			old:  []Device{with(avd, func(d *Device) { d.ID = "emulator-5554"; booted(d) })},
+			new:  []Device{avd},
+			want: Changes{ShutDown: []Device{avd}},
+		},
+		{
+			name: "mixed",
+			old:  []Device{sim, phone},
+			new:  []Device{with(sim, booted), avd},
+			want: Changes{
+				Added:   []Device{avd},
+				Removed: []Device{phone},
+				Booted:  []Device{with(sim, booted)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(tt.old, tt.new)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.want)
+			}
+			if got.Empty() != reflect.DeepEqual(tt.want, Changes{}) {
+				t.Errorf("Empty() = %v", got.Empty())
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		dev  Device
+		want string
+	}{
+		{Device{ID: "emulator-5554", Name: "Pixel_8", Platform: "android", IsEmulator: true}, "avd:Pixel_8"},
+		{Device{ID: "Pixel_8", Name: "Pixel_8", Platform: "android", IsEmulator: true}, "avd:Pixel_8"},
+		{Device{ID: "R58M", Name: "Galaxy S23", Platform: "android"}, "R58M"},
+		{Device{ID: "A1B2", Name: "iPhone 15", Platform: "ios", IsEmulator: true}, "A1B2"},
+	}
+	for _, tt := range tests {
+		if got := tt.dev.Key(); got != tt.want {
+			t.Errorf("%s.Key() = %q, want %q", tt.dev.ID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package device
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Rule keywords. A query made only of keywords joined by ":" is a selection
+// rule, e.g. "ios:simulator:booted" (first booted iOS simulator) or
+// "android:newest" (the Android device with the highest API level).
+var ruleKeywords = map[string]bool{
+	"any":       true, // any platform
+	"ios":       true,
+	"android":   true,
+	"web":       true,
+	"booted":    true, // online only
+	"online":    true,
+	"offline":   true,
+	"simulator": true, // simulators and emulators
+	"emulator":  true,
+	"physical":  true, // real devices
+	"first":     true, // first in list order (the default)
+	"newest":    true, // highest OS version / API level
+	"oldest":    true,
+}
+
+// IsRule reports whether query is a selection rule rather than a device ID,
+// alias or name
+func IsRule(query string) bool {
+	if !strings.Contains(query, ":") {
+		return false
+	}
+	for _, part := range strings.Split(strings.ToLower(query), ":") {
+		if !ruleKeywords[strings.TrimSpace(part)] {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve finds the device a query refers to. The query is tried, in order, as
+// an exact device ID, a user-defined alias (which may name an ID, a name or a
+// rule), a selection rule, an exact name (case-insensitive) and finally a
+// fuzzy name match that ignores case, spaces and punctuation ("iphone15"
+// finds "iPhone 15"). An ambiguous fuzzy match is an error listing the candidates.
+func Resolve(devices []Device, query string, aliases map[string]string) (*Device, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("no device given")
+	}
+
+	for i := range devices {
+		if devices[i].ID == query {
+			return &devices[i], nil
+		}
+	}
+
+	if target, ok := lookupAlias(aliases, query); ok {
+		// Aliases don't chain, so a typo can't loop
+		d, err := Resolve(devices, target, nil)
+		if err != nil {
+			return nil, fmt.Errorf("alias %q (%s): %w", query, target, err)
+		}
+		return d, nil
+	}
+
+	if IsRule(query) {
+		return resolveRule(devices, query)
+	}
+
+	for i := range devices {
+		if strings.EqualFold(devices[i].Name, query) {
+			return &devices[i], nil
+		}
+	}
+
+	return resolveFuzzy(devices, query)
+}
+
+func lookupAlias(aliases map[string]string, query string) (string, bool) {
+	for alias, target := range aliases {
+		if strings.EqualFold(alias, query) && target != "" {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+func resolveRule(devices []Device, rule string) (*Device, error) {
+	var candidates []*Device
+	parts := strings.Split(strings.ToLower(rule), ":")
+	for i := range devices {
+		if matchesRule(devices[i], parts) {
+			candidates = append(candidates, &devices[i])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no device matches rule %q", rule)
+	}
+
+	for _, part := range parts {
+		switch strings.TrimSpace(part) {
+		case "newest":
+			sort.SliceStable(candidates, func(a, b int) bool {
+				return CompareVersions(candidates[a].Version(), candidates[b].Version()) > 0
+			})
+		case "oldest":
+			sort.SliceStable(candidates, func(a, b int) bool {
+				return CompareVersions(candidates[a].Version(), candidates[b].Version()) < 0
+			})
+		}
+	}
+	return candidates[0], nil
+}
+
+func matchesRule(d Device, parts []string) bool {
+	for _, part := range parts {
+		switch strings.TrimSpace(part) {
+		case "ios", "android", "web":
+			if d.Platform != strings.TrimSpace(part) {
+				return false
+			}
+		case "booted", "online":
+			if !d.Online {
+				return false
+			}
+		case "offline":
+			if d.Online {
+				return false
+			}
+		case "simulator", "emulator":
+			if !d.IsEmulator || d.IsWeb {
+				return false
+			}
+		case "physical":
+			if d.IsEmulator || d.IsWeb {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// resolveFuzzy matches normalized names: an exact normalized name wins over a
+// name containing the query, which wins over the query's letters appearing in
+// order. Within the best tier a single online device beats offline ones.
+func resolveFuzzy(devices []Device, query string) (*Device, error) {
+	q := normalize(query)
+	if q == "" {
+		return nil, fmt.Errorf("no device matches %q", query)
+	}
+
+	tiers := make([][]*Device, 3)
+	for i := range devices {
+		name := normalize(devices[i].Name)
+		switch {
+		case name == q:
+			tiers[0] = append(tiers[0], &devices[i])
+		case strings.Contains(name, q):
+			tiers[1] = append(tiers[1], &devices[i])
+		case isSubsequence(q, name):
+			tiers[2] = append(tiers[2], &devices[i])
+		}
+	}
+
+	for _, matches := range tiers {
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0], nil
+		}
+
+		var online []*Device
+		for _, d := range matches {
+			if d.Online {
+				online = append(online, d)
+			}
+		}
+		if len(online) == 1 {
+			return online[0], nil
+		}
+
+		names := make([]string, len(matches))
+		for i, d := range matches {
+			names[i] = fmt.Sprintf("%s (%s)", d.Name, d.ID)
+		}
+		return nil, fmt.Errorf("%q matches several devices: %s", query, strings.Join(names, ", "))
+	}
+	return nil, fmt.Errorf("no device matches %q", query)
+}
+
+// normalize lowercases s and drops everything but letters and digits
+func normalize(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func isSubsequence(needle, haystack string) bool {
+	runes := []rune(needle)
+	i := 0
+	for _, r := range haystack {
+		if i < len(runes) && runes[i] == r {
+			i++
+		}
+	}
+	return i == len(runes)
+}
+
+// CompareVersions compares dotted numeric versions like 17.2 and 17.10,
+// returning -1, 0 or 1; missing or non-numeric parts count as zero
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(strings.TrimSpace(as[i]))
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(strings.TrimSpace(bs[i]))
+		}
+		if x != y {
+			if x > y {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// AliasesFor returns the aliases that name d by ID or exact name, sorted.
+// Aliases holding rules are left out since they pick a device dynamically.
+func AliasesFor(d Device, aliases map[string]string) []string {
+	var names []string
+	for alias, target := range aliases {
+		if target == d.ID || strings.EqualFold(target, d.Name) {
+			names = append(names, alias)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,99 @@
+package device
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	devices := []Device{
+		{ID: "A1B2", Name: "iPhone 15", Platform: "ios", IsEmulator: true, OSVersion: "17.2"},
+		{ID: "C3D4", Name: "iPhone 15 Pro", Platform: "ios", IsEmulator: true, Online: true, OSVersion: "17.5"},
+		{ID: "E5F6", Name: "iPad Air", Platform: "ios", IsEmulator: true, OSVersion: "16.4"},
+		{ID: "Pixel_8_API_34", Name: "Pixel 8", Platform: "android", IsEmulator: true, APILevel: "34"},
+		{ID: "Pixel_7_API_33", Name: "Pixel 7", Platform: "android", IsEmulator: true, APILevel: "33"},
+		{ID: "R58M", Name: "Galaxy S23", Platform: "android", Online: true, APILevel: "34"},
+		{ID: "iPad Air", Name: "Studio iPad", Platform: "ios"},
+	}
+	aliases := map[string]string{
+		"work":      "R58M",
+		"Phone":     "iphone 15 pro",
+		"newest":    "android:newest",
+		"pixel 8":   "Pixel_7_API_33", // an alias beats an exact name
+		"R58M":      "A1B2",           // an ID beats an alias
+		"broken":    "nokia",
+		"loop":      "work", // aliases don't chain
+		"empty":     "",
+		"iPhone 15": "",
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    string // device ID
+		wantErr string // substring of the error
+	}{
+		{name: "exact ID", query: "C3D4", want: "C3D4"},
+		{name: "ID is case-sensitive", query: "c3d4", wantErr: "no device matches"},
+		{name: "ID beats name", query: "iPad Air", want: "iPad Air"},
+		{name: "ID beats alias", query: "R58M", want: "R58M"},
+		{name: "alias to ID", query: "work", want: "R58M"},
+		{name: "alias is case-insensitive", query: "WORK", want: "R58M"},
+		{name: "alias to name", query: "phone", want: "C3D4"},
+		{name: "alias to rule", query: "newest", want: "Pixel_8_API_34"},
+		{name: "alias beats name", query: "Pixel 8", want: "Pixel_7_API_33"},
+		{name: "empty alias is ignored", query: "iPhone 15", want: "A1B2"},
+		{name: "alias with no match", query: "broken", wantErr: `alias "broken" (nokia): no device matches "nokia"`},
+		{name: "aliases don't chain", query: "loop", wantErr: `alias "loop" (work)`},
+		{name: "exact name ignores case", query: "IPHONE 15", want: "A1B2"},
+		{name: "exact name beats fuzzy", query: "iphone 15", want: "A1B2"},
+		{name: "fuzzy normalized name", query: "iphone15pro", want: "C3D4"},
+		{name: "fuzzy substring", query: "galaxy", want: "R58M"},
+		{name: "fuzzy subsequence", query: "gxys23", want: "R58M"},
+		{name: "fuzzy prefers online", query: "iphone", want: "C3D4"},
+		{name: "ambiguous", query: "pixel", wantErr: `"pixel" matches several devices: Pixel 8 (Pixel_8_API_34), Pixel 7 (Pixel_7_API_33)`},
+		{name: "ambiguous subsequence", query: "pl", wantErr: "matches several devices"},
+		{name: "rule", query: "ios:booted", want: "C3D4"},
+		{name: "rule newest", query: "android:emulator:newest", want: "Pixel_8_API_34"},
+		{name: "rule oldest", query: "ios:simulator:oldest", want: "E5F6"},
+		{name: "rule physical", query: "android:physical", want: "R58M"},
+		{name: "rule with no match", query: "web:any", wantErr: `no device matches rule "web:any"`},
+		{name: "no match", query: "nokia", wantErr: `no device matches "nokia"`},
+		{name: "only punctuation", query: "--", wantErr: `no device matches "--"`},
+		{name: "empty", query: "  ", wantErr: "no device given"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Resolve(devices, tt.query, aliases)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve(%q) error = %v, want %q", tt.query, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q): %v", tt.query, err)
+			}
+			if d.ID != tt.want {
+				t.Errorf("Resolve(%q) = %s, want %s", tt.query, d.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRule(t *testing.T) {
+	tests := map[string]bool{
+		"ios:simulator:booted": true,
+		"Android:Newest":       true,
+		"any:first":            true,
+		"ios":                  false, // a bare keyword may be a device name
+		"ios:iphone":           false,
+		"emulator-5554":        false,
+		"":                     false,
+	}
+	for query, want := range tests {
+		if got := IsRule(query); got != want {
+			t.Errorf("IsRule(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
@@ -16,11 +16,11 @@ type Context interface {
 	RefreshDevices() error
 
 	// Build & Run Actions
-	RunOnDevice(deviceID string, liveReload bool) error
-	RunWeb() error
-	Sync(platform string) error
-	Build() error
-	OpenIDE(platform string) error
+	RunOnDevice(deviceID string, liveReload bool) (*ProcessHandle, error)
+	RunWeb() (*ProcessHandle, error)
+	Sync(platform string) (*ProcessHandle, error)
+	Build() (*ProcessHandle, error)
+	OpenIDE(platform string) (*ProcessHandle, error)
 	KillProcess(processID string) error
 
 	// Process Management
@@ -51,6 +51,12 @@ type Context interface {
 	LogError(pluginID string, err error)
 }
 
+// ProcessHandle is a process the UI started on behalf of a plugin
+type ProcessHandle struct {
+	ProcessID string         // ID for GetProcessLogs and KillProcess
+	Exec      *cap.Execution // Streamed output, exit status and cancellation
+}
+
 // ProcessInfo contains information about a running process
 type ProcessInfo struct {
 	ID        string
@@ -85,6 +91,10 @@ const (
 	EventSyncStarted   EventType = "sync:started"
 	EventSyncFinished  EventType = "sync:finished"
 
+	// Hook events
+	EventHookStarted  EventType = "hook:started"
+	EventHookFinished EventType = "hook:finished"
+
 	// Settings events
 	EventSettingChanged EventType = "setting:changed"
 )
@@ -95,6 +105,18 @@ type EventHandler func(data interface{})
 // UnsubscribeFunc removes an event subscription
 type UnsubscribeFunc func()
 
+// DevicesChangedEvent is emitted when devices connect, disconnect, boot or
+// shut down. Devices is the new list; Changes says how it differs.
+type DevicesChangedEvent struct {
+	Devices []device.Device
+	device.Changes
+}
+
+// DeviceBootedEvent is emitted for each device that finished booting
+type DeviceBootedEvent struct {
+	Device device.Device
+}
+
 // DeviceSelectedEvent is emitted when a device is selected
 type DeviceSelectedEvent struct {
 	Device *device.Device
@@ -120,6 +142,17 @@ type ProcessFinishedEvent struct {
 	Error     error
 }
 
+// HookEvent is emitted when a pre/post hook starts or finishes
+type HookEvent struct {
+	Stage     string // preRun, postBuild, ...
+	Name      string
+	Command   string
+	ProcessID string
+	Success   bool  // Finished events only
+	Error     error // Finished events only
+	Aborted   bool  // The failure stopped the rest of the pipeline
+}
+
 // SettingChangedEvent is emitted when a setting changes
 type SettingChangedEvent struct {
 	Key      string
@@ -32,11 +32,11 @@ type AppContext struct {
 	onGetDevices        func() []device.Device
 	onGetSelectedDevice func() *device.Device
 	onRefreshDevices    func() error
-	onRunOnDevice       func(deviceID string, liveReload bool) error
-	onRunWeb            func() error
-	onSync              func(platform string) error
-	onBuild             func() error
-	onOpenIDE           func(platform string) error
+	onRunOnDevice       func(deviceID string, liveReload bool) (*ProcessHandle, error)
+	onRunWeb            func() (*ProcessHandle, error)
+	onSync              func(platform string) (*ProcessHandle, error)
+	onBuild             func() (*ProcessHandle, error)
+	onOpenIDE           func(platform string) (*ProcessHandle, error)
 	onKillProcess       func(processID string) error
 	onGetProcesses      func() []ProcessInfo
 	onGetProcessLogs    func(processID string) []string
@@ -82,11 +82,11 @@ func (c *AppContext) SetCallbacks(
 	getDevices func() []device.Device,
 	getSelectedDevice func() *device.Device,
 	refreshDevices func() error,
-	runOnDevice func(deviceID string, liveReload bool) error,
-	runWeb func() error,
-	sync func(platform string) error,
-	build func() error,
-	openIDE func(platform string) error,
+	runOnDevice func(deviceID string, liveReload bool) (*ProcessHandle, error),
+	runWeb func() (*ProcessHandle, error),
+	sync func(platform string) (*ProcessHandle, error),
+	build func() (*ProcessHandle, error),
+	openIDE func(platform string) (*ProcessHandle, error),
 	killProcess func(processID string) error,
 	getProcesses func() []ProcessInfo,
 	getProcessLogs func(processID string) []string,
@@ -149,7 +149,7 @@ func (c *AppContext) RefreshDevices() error {
 	return fmt.Errorf("refresh not available")
 }
 
-func (c *AppContext) RunOnDevice(deviceID string, liveReload bool) error {
+func (c *AppContext) RunOnDevice(deviceID string, liveReload bool) (*ProcessHandle, error) {
 	c.mu.RLock()
 	fn := c.onRunOnDevice
 	c.mu.RUnlock()
@@ -157,10 +157,10 @@ func (c *AppContext) RunOnDevice(deviceID string, liveReload bool) error {
 	if fn != nil {
 		return fn(deviceID, liveReload)
 	}
-	return fmt.Errorf("run not available")
+	return nil, fmt.Errorf("run not available")
 }
 
-func (c *AppContext) RunWeb() error {
+func (c *AppContext) RunWeb() (*ProcessHandle, error) {
 	c.mu.RLock()
 	fn := c.onRunWeb
 	c.mu.RUnlock()
@@ -168,10 +168,10 @@ func (c *AppContext) RunWeb() error {
 	if fn != nil {
 		return fn()
 	}
-	return fmt.Errorf("run web not available")
+	return nil, fmt.Errorf("run web not available")
 }
 
-func (c *AppContext) Sync(platform string) error {
+func (c *AppContext) Sync(platform string) (*ProcessHandle, error) {
 	c.mu.RLock()
 	fn := c.onSync
 	c.mu.RUnlock()
@@ -179,10 +179,10 @@ func (c *AppContext) Sync(platform string) error {
 	if fn != nil {
 		return fn(platform)
 	}
-	return fmt.Errorf("sync not available")
+	return nil, fmt.Errorf("sync not available")
 }
 
-func (c *AppContext) Build() error {
+func (c *AppContext) Build() (*ProcessHandle, error) {
 	c.mu.RLock()
 	fn := c.onBuild
 	c.mu.RUnlock()
@@ -190,10 +190,10 @@ func (c *AppContext) Build() error {
 	if fn != nil {
 		return fn()
 	}
-	return fmt.Errorf("build not available")
+	return nil, fmt.Errorf("build not available")
 }
 
-func (c *AppContext) OpenIDE(platform string) error {
+func (c *AppContext) OpenIDE(platform string) (*ProcessHandle, error) {
 	c.mu.RLock()
 	fn := c.onOpenIDE
 	c.mu.RUnlock()
@@ -201,7 +201,7 @@ func (c *AppContext) OpenIDE(platform string) error {
 	if fn != nil {
 		return fn(platform)
 	}
-	return fmt.Errorf("open IDE not available")
+	return nil, fmt.Errorf("open IDE not available")
 }
 
 func (c *AppContext) KillProcess(processID string) error {
@@ -400,10 +400,36 @@ func (c *AppContext) NotifyProcessFinished(processID string, success bool, err e
 	}
 }
 
-// NotifyDevicesChanged emits a devices changed event
-func (c *AppContext) NotifyDevicesChanged() {
+// NotifyHookStarted emits a hook started event
+func (c *AppContext) NotifyHookStarted(e HookEvent) {
 	if c.manager != nil {
-		c.manager.GetEventBus().Emit(EventDevicesChanged, nil)
+		c.manager.GetEventBus().Emit(EventHookStarted, e)
+	}
+}
+
+// NotifyHookFinished emits a hook finished event
+func (c *AppContext) NotifyHookFinished(e HookEvent) {
+	if c.manager != nil {
+		c.manager.GetEventBus().Emit(EventHookFinished, e)
+	}
+}
+
+// NotifyDevicesChanged emits a devices changed event with what changed
+func (c *AppContext) NotifyDevicesChanged(devices []device.Device, changes device.Changes) {
+	if c.manager != nil {
+		c.manager.GetEventBus().Emit(EventDevicesChanged, DevicesChangedEvent{
+			Devices: devices,
+			Changes: changes,
+		})
+	}
+}
+
+// NotifyDeviceBooted emits a device booted event
+func (c *AppContext) NotifyDeviceBooted(dev device.Device) {
+	if c.manager != nil {
+		c.manager.GetEventBus().Emit(EventDeviceBooted, DeviceBootedEvent{
+			Device: dev,
+		})
 	}
 }
 
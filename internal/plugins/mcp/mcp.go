@@ -9,6 +9,8 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/icarus-itcs/lazycap/internal/cap"
+	"github.com/icarus-itcs/lazycap/internal/device"
 	"github.com/icarus-itcs/lazycap/internal/plugin"
 )
 
@@ -348,13 +350,13 @@ func (p *MCPPlugin) handleToolsList() map[string]interface{} {
 		},
 		{
 			Name:        "run_on_device",
-			Description: "[Capacitor] Deploy and run the app on a device/emulator using 'npx cap run'. Builds web assets, syncs to native platform, compiles native code, and launches on the target device.",
+			Description: "[Capacitor] Deploy and run the app on a device/emulator using 'cap run' via the project's package manager (npx, pnpm exec, yarn, bunx). Builds web assets, syncs to native platform, compiles native code, and launches on the target device.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"deviceId": map[string]interface{}{
 						"type":        "string",
-						"description": "Device ID from list_devices (e.g., 'iPhone-15-Pro' or 'emulator-5554')",
+						"description": "Device ID, alias or name from list_devices (e.g., 'emulator-5554', 'pixel' or 'iPhone 15'), or a rule like 'ios:simulator:booted' or 'android:newest'",
 					},
 					"liveReload": map[string]interface{}{
 						"type":        "boolean",
@@ -374,7 +376,7 @@ func (p *MCPPlugin) handleToolsList() map[string]interface{} {
 		},
 		{
 			Name:        "sync",
-			Description: "[Capacitor] Run 'npx cap sync' to copy web assets (HTML/CSS/JS) to native iOS/Android projects and update native plugins. Required after npm install or web code changes before native build.",
+			Description: "[Capacitor] Run 'cap sync' to copy web assets (HTML/CSS/JS) to native iOS/Android projects and update native plugins. Required after npm install or web code changes before native build. Waits for the sync to finish (up to the syncTimeout setting) and returns its output.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -387,7 +389,7 @@ func (p *MCPPlugin) handleToolsList() map[string]interface{} {
 		},
 		{
 			Name:        "build",
-			Description: "[Web Build] Run the web build command (npm run build) to compile and bundle the web application. Creates production-ready assets in the build output directory.",
+			Description: "[Web Build] Run the web build command (npm/yarn/pnpm/bun run build) to compile and bundle the web application. Creates production-ready assets in the build output directory. Waits for the build to finish (up to the buildTimeout setting) and returns its output.",
 			InputSchema: map[string]interface{}{
 				"type":       "object",
 				"properties": map[string]interface{}{},
@@ -395,7 +397,7 @@ func (p *MCPPlugin) handleToolsList() map[string]interface{} {
 		},
 		{
 			Name:        "open_ide",
-			Description: "[Capacitor] Open native project in IDE using 'npx cap open'. Opens Xcode for iOS development or Android Studio for Android development.",
+			Description: "[Capacitor] Open native project in IDE using 'cap open'. Opens Xcode for iOS development or Android Studio for Android development.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -473,6 +475,14 @@ func (p *MCPPlugin) handleToolsList() map[string]interface{} {
 				"required": []string{"processId"},
 			},
 		},
+		{
+			Name:        "get_plugin_report",
+			Description: "[Capacitor] List the installed Capacitor runtime packages and plugins (official and community) with versions and supported platforms. Flags plugins whose major version doesn't match @capacitor/core or that are missing from the native Android/iOS projects (needs cap sync).",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
 		{
 			Name:        "get_debug_actions",
 			Description: "[Debug Tools] List available debug and cleanup actions for troubleshooting Capacitor/iOS/Android issues. Includes cache clearing, dependency reinstall, and platform reset options.",
@@ -495,6 +505,98 @@ func (p *MCPPlugin) handleToolsList() map[string]interface{} {
 				"required": []string{"actionId"},
 			},
 		},
+		{
+			Name:        "list_simulator_options",
+			Description: "[iOS Simulator] List installed iOS simulator runtimes, newest first, with the device types (iPhone/iPad models) each one supports. Use the names with create_simulator.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "create_simulator",
+			Description: "[iOS Simulator] Create a new iOS simulator with 'xcrun simctl create'. Returns the new simulator's UDID.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Simulator name. Defaults to the device type name.",
+					},
+					"runtime": map[string]interface{}{
+						"type":        "string",
+						"description": "Runtime name, version or identifier from list_simulator_options (e.g., 'iOS 17.2' or '17.2'). Defaults to the newest.",
+					},
+					"deviceType": map[string]interface{}{
+						"type":        "string",
+						"description": "Device type name or identifier from list_simulator_options (e.g., 'iPhone 15 Pro'). Defaults to the runtime's newest iPhone.",
+					},
+				},
+			},
+		},
+		{
+			Name:        "manage_simulator",
+			Description: "[iOS Simulator] Boot, shut down, clone, erase (all content and settings), rename or delete an iOS simulator. Cloning needs the simulator shut down.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"deviceId": map[string]interface{}{
+						"type":        "string",
+						"description": "Simulator UDID, alias or name from list_devices",
+					},
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"boot", "shutdown", "clone", "erase", "rename", "delete"},
+						"description": "What to do with the simulator",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "New name for clone and rename",
+					},
+				},
+				"required": []string{"deviceId", "action"},
+			},
+		},
+		{
+			Name:        "configure_simulator",
+			Description: "[iOS Simulator] Change a booted iOS simulator: dark/light appearance, locale (restarts the simulator), status bar overrides for screenshots, and simulated location. Only the given settings change.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"deviceId": map[string]interface{}{
+						"type":        "string",
+						"description": "Simulator UDID, alias or name from list_devices",
+					},
+					"appearance": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"dark", "light"},
+						"description": "Dark or light mode",
+					},
+					"locale": map[string]interface{}{
+						"type":        "string",
+						"description": "Language and region, e.g. 'de_DE' or 'ja_JP'",
+					},
+					"statusBar": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"clean", "clear"},
+						"description": "'clean' shows 9:41 with full battery and signal, 'clear' removes overrides",
+					},
+					"time": map[string]interface{}{
+						"type":        "string",
+						"description": "Status bar time for statusBar 'clean' (default 9:41)",
+					},
+					"batteryLevel": map[string]interface{}{
+						"type":        "integer",
+						"description": "Status bar battery percentage for statusBar 'clean' (default 100)",
+					},
+					"location": map[string]interface{}{
+						"type":        "string",
+						"description": "Simulated location as 'latitude,longitude' (e.g., '37.3349,-122.0090'), or 'clear'",
+					},
+				},
+				"required": []string{"deviceId"},
+			},
+		},
 		{
 			Name:        "get_settings",
 			Description: "[Configuration] Get lazycap settings including default platform, build commands, live reload preferences, and enabled MCP tools.",
@@ -565,10 +667,20 @@ func (p *MCPPlugin) handleToolsCall(params json.RawMessage) (interface{}, *MCPEr
 		return p.toolGetAllLogs(call.Arguments)
 	case "kill_process":
 		return p.toolKillProcess(call.Arguments)
+	case "get_plugin_report":
+		return p.toolGetPluginReport()
 	case "get_debug_actions":
 		return p.toolGetDebugActions()
 	case "run_debug_action":
 		return p.toolRunDebugAction(call.Arguments)
+	case "list_simulator_options":
+		return p.toolListSimulatorOptions()
+	case "create_simulator":
+		return p.toolCreateSimulator(call.Arguments)
+	case "manage_simulator":
+		return p.toolManageSimulator(call.Arguments)
+	case "configure_simulator":
+		return p.toolConfigureSimulator(call.Arguments)
 	case "get_settings":
 		return p.toolGetSettings()
 	case "set_setting":
@@ -584,6 +696,10 @@ func (p *MCPPlugin) handleToolsCall(params json.RawMessage) (interface{}, *MCPEr
 
 func (p *MCPPlugin) toolListDevices() (interface{}, *MCPError) {
 	devices := p.ctx.GetDevices()
+	var aliases map[string]string
+	if s := p.ctx.GetSettings(); s != nil {
+		aliases = s.DeviceAliases
+	}
 	result := make([]map[string]interface{}, len(devices))
 	for i, d := range devices {
 		result[i] = map[string]interface{}{
@@ -591,8 +707,22 @@ func (p *MCPPlugin) toolListDevices() (interface{}, *MCPError) {
 			"name":       d.Name,
 			"platform":   d.Platform,
 			"online":     d.Online,
+			"state":      d.CurrentState(),
 			"isEmulator": d.IsEmulator,
 			"isWeb":      d.IsWeb,
+			"osVersion":  d.OSVersion,
+			"apiLevel":   d.APILevel,
+			"model":      d.Model,
+			"connection": d.Connection,
+		}
+		if d.BatteryLevel > 0 {
+			result[i]["batteryLevel"] = d.BatteryLevel
+		}
+		if d.Address != "" {
+			result[i]["address"] = d.Address
+		}
+		if aliases := device.AliasesFor(d, aliases); len(aliases) > 0 {
+			result[i]["aliases"] = aliases
 		}
 	}
 	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(result)}}}, nil
@@ -606,37 +736,41 @@ func (p *MCPPlugin) toolRunOnDevice(args map[string]interface{}) (interface{}, *
 		return nil, &MCPError{Code: -32602, Message: "deviceId required"}
 	}
 
-	if err := p.ctx.RunOnDevice(deviceID, liveReload); err != nil {
+	handle, err := p.ctx.RunOnDevice(deviceID, liveReload)
+	if err != nil {
 		return nil, &MCPError{Code: -32000, Message: err.Error()}
 	}
 
-	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": "Started run on " + deviceID}}}, nil
+	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": fmt.Sprintf("Started run on %s (process %s)", deviceID, handle.ProcessID)}}}, nil
 }
 
 func (p *MCPPlugin) toolRunWeb() (interface{}, *MCPError) {
-	if err := p.ctx.RunWeb(); err != nil {
+	handle, err := p.ctx.RunWeb()
+	if err != nil {
 		return nil, &MCPError{Code: -32000, Message: err.Error()}
 	}
-	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": "Web dev server started"}}}, nil
+	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": fmt.Sprintf("Web dev server started (process %s)", handle.ProcessID)}}}, nil
 }
 
 func (p *MCPPlugin) toolSync(args map[string]interface{}) (interface{}, *MCPError) {
 	platform, _ := args["platform"].(string)
-	if err := p.ctx.Sync(platform); err != nil {
+	handle, err := p.ctx.Sync(platform)
+	if err != nil {
 		return nil, &MCPError{Code: -32000, Message: err.Error()}
 	}
-	msg := "Sync started"
+	msg := "Sync completed"
 	if platform != "" {
-		msg = "Sync started for " + platform
+		msg = "Sync completed for " + platform
 	}
-	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": msg}}}, nil
+	return waitForProcess(handle, msg)
 }
 
 func (p *MCPPlugin) toolBuild() (interface{}, *MCPError) {
-	if err := p.ctx.Build(); err != nil {
+	handle, err := p.ctx.Build()
+	if err != nil {
 		return nil, &MCPError{Code: -32000, Message: err.Error()}
 	}
-	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": "Build started"}}}, nil
+	return waitForProcess(handle, "Build completed")
 }
 
 func (p *MCPPlugin) toolOpenIDE(args map[string]interface{}) (interface{}, *MCPError) {
@@ -644,12 +778,24 @@ func (p *MCPPlugin) toolOpenIDE(args map[string]interface{}) (interface{}, *MCPE
 	if platform == "" {
 		return nil, &MCPError{Code: -32602, Message: "platform required"}
 	}
-	if err := p.ctx.OpenIDE(platform); err != nil {
+	if _, err := p.ctx.OpenIDE(platform); err != nil {
 		return nil, &MCPError{Code: -32000, Message: err.Error()}
 	}
 	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": "Opening " + platform + " IDE"}}}, nil
 }
 
+// waitForProcess waits for a process the UI started and reports its exit status
+// with the tail of its output
+func waitForProcess(handle *plugin.ProcessHandle, success string) (interface{}, *MCPError) {
+	if err := handle.Exec.Wait(); err != nil {
+		return nil, &MCPError{Code: -32000, Message: fmt.Sprintf("Process %s failed: %v\n\n%s", handle.ProcessID, err, handle.Exec.Tail(50))}
+	}
+	if tail := handle.Exec.Tail(50); tail != "" {
+		success += "\n\n" + tail
+	}
+	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": success}}}, nil
+}
+
 func (p *MCPPlugin) toolGetProcesses() (interface{}, *MCPError) {
 	processes := p.ctx.GetProcesses()
 	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(processes)}}}, nil
@@ -764,6 +910,18 @@ func (p *MCPPlugin) toolKillProcess(args map[string]interface{}) (interface{}, *
 	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": "Process killed"}}}, nil
 }
 
+func (p *MCPPlugin) toolGetPluginReport() (interface{}, *MCPError) {
+	project := p.ctx.GetProject()
+	if project == nil {
+		return nil, &MCPError{Code: -32000, Message: "No project loaded"}
+	}
+	report, err := cap.GetPluginReport(project)
+	if err != nil {
+		return nil, &MCPError{Code: -32000, Message: err.Error()}
+	}
+	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(report)}}}, nil
+}
+
 func (p *MCPPlugin) toolGetDebugActions() (interface{}, *MCPError) {
 	actions := p.ctx.GetDebugActions()
 	result := make([]map[string]interface{}, len(actions))
@@ -788,6 +946,130 @@ func (p *MCPPlugin) toolRunDebugAction(args map[string]interface{}) (interface{}
 	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(result)}}}, nil
 }
 
+// resolveSimulator finds the iOS simulator a deviceId argument refers to
+func (p *MCPPlugin) resolveSimulator(args map[string]interface{}) (*device.Device, *MCPError) {
+	deviceID, _ := args["deviceId"].(string)
+	if deviceID == "" {
+		return nil, &MCPError{Code: -32602, Message: "deviceId required"}
+	}
+	var aliases map[string]string
+	if s := p.ctx.GetSettings(); s != nil {
+		aliases = s.DeviceAliases
+	}
+	dev, err := device.Resolve(p.ctx.GetDevices(), deviceID, aliases)
+	if err != nil {
+		return nil, &MCPError{Code: -32000, Message: err.Error()}
+	}
+	if dev.Platform != "ios" || !dev.IsEmulator {
+		return nil, &MCPError{Code: -32000, Message: fmt.Sprintf("%s is not an iOS simulator", dev.Name)}
+	}
+	return dev, nil
+}
+
+func (p *MCPPlugin) toolListSimulatorOptions() (interface{}, *MCPError) {
+	runtimes, err := cap.ListSimRuntimes()
+	if err != nil {
+		return nil, &MCPError{Code: -32000, Message: err.Error()}
+	}
+	result := make([]map[string]interface{}, len(runtimes))
+	for i := range runtimes {
+		types, err := cap.ListSimDeviceTypes(&runtimes[i])
+		if err != nil {
+			return nil, &MCPError{Code: -32000, Message: err.Error()}
+		}
+		result[i] = map[string]interface{}{
+			"name":        runtimes[i].Name,
+			"version":     runtimes[i].Version,
+			"identifier":  runtimes[i].Identifier,
+			"deviceTypes": types,
+		}
+	}
+	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(result)}}}, nil
+}
+
+func (p *MCPPlugin) toolCreateSimulator(args map[string]interface{}) (interface{}, *MCPError) {
+	name, _ := args["name"].(string)
+	runtimeName, _ := args["runtime"].(string)
+	typeName, _ := args["deviceType"].(string)
+	runtime, err := cap.FindSimRuntime(runtimeName)
+	if err != nil {
+		return nil, &MCPError{Code: -32000, Message: err.Error()}
+	}
+	deviceType, err := cap.FindSimDeviceType(runtime, typeName)
+	if err != nil {
+		return nil, &MCPError{Code: -32000, Message: err.Error()}
+	}
+	if name == "" {
+		name = deviceType.Name
+	}
+	udid, err := cap.CreateSimulator(name, deviceType.Identifier, runtime.Identifier)
+	if err != nil {
+		return nil, &MCPError{Code: -32000, Message: err.Error()}
+	}
+	_ = p.ctx.RefreshDevices()
+	text := fmt.Sprintf("Created %s (%s, %s): %s", name, deviceType.Name, runtime.Name, udid)
+	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": text}}}, nil
+}
+
+func (p *MCPPlugin) toolManageSimulator(args map[string]interface{}) (interface{}, *MCPError) {
+	action, _ := args["action"].(string)
+	name, _ := args["name"].(string)
+	dev, mcpErr := p.resolveSimulator(args)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	msg, err := cap.ManageSimulator(dev.ID, action, name)
+	if err != nil {
+		return nil, &MCPError{Code: -32000, Message: err.Error()}
+	}
+	_ = p.ctx.RefreshDevices()
+	text := fmt.Sprintf("%s: %s", dev.Name, msg)
+	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": text}}}, nil
+}
+
+func (p *MCPPlugin) toolConfigureSimulator(args map[string]interface{}) (interface{}, *MCPError) {
+	dev, mcpErr := p.resolveSimulator(args)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+
+	var s cap.SimulatorSettings
+	s.Appearance, _ = args["appearance"].(string)
+	s.Locale, _ = args["locale"].(string)
+	switch bar, _ := args["statusBar"].(string); bar {
+	case "":
+	case "clear":
+		s.ClearStatusBar = true
+	case "clean":
+		clean := cap.CleanStatusBar
+		if t, _ := args["time"].(string); t != "" {
+			clean.Time = t
+		}
+		if level, ok := args["batteryLevel"].(float64); ok {
+			clean.BatteryLevel = int(level)
+		}
+		s.StatusBar = &clean
+	default:
+		return nil, &MCPError{Code: -32602, Message: "statusBar must be 'clean' or 'clear'"}
+	}
+	if location, _ := args["location"].(string); location == "clear" {
+		s.ClearLocation = true
+	} else {
+		s.Location = location
+	}
+
+	done, err := cap.ConfigureSimulator(dev.ID, s)
+	if err != nil {
+		msg := err.Error()
+		if len(done) > 0 {
+			msg = fmt.Sprintf("%s (after: %s)", msg, strings.Join(done, ", "))
+		}
+		return nil, &MCPError{Code: -32000, Message: msg}
+	}
+	text := fmt.Sprintf("%s: %s", dev.Name, strings.Join(done, ", "))
+	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": text}}}, nil
+}
+
 func (p *MCPPlugin) toolGetSettings() (interface{}, *MCPError) {
 	settings := p.ctx.GetSettings()
 	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(settings)}}}, nil
@@ -811,12 +1093,24 @@ func (p *MCPPlugin) toolGetProject() (interface{}, *MCPError) {
 		return nil, &MCPError{Code: -32000, Message: "No project loaded"}
 	}
 	result := map[string]interface{}{
-		"name":       project.Name,
-		"appId":      project.AppID,
-		"webDir":     project.WebDir,
-		"hasAndroid": project.HasAndroid,
-		"hasIOS":     project.HasIOS,
-		"rootDir":    project.RootDir,
+		"name":           project.Name,
+		"appId":          project.AppID,
+		"webDir":         project.WebDir,
+		"hasAndroid":     project.HasAndroid,
+		"hasIOS":         project.HasIOS,
+		"rootDir":        project.RootDir,
+		"configPath":     project.ConfigPath,
+		"config":         project.Config,
+		"packageManager": project.PackageManager,
+	}
+	if project.Android != nil {
+		result["android"] = project.Android
+	}
+	if project.IOS != nil {
+		result["ios"] = project.IOS
+	}
+	if len(project.ConfigWarnings) > 0 {
+		result["configWarnings"] = project.ConfigWarnings
 	}
 	return map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": toJSON(result)}}}, nil
 }
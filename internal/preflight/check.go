@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/icarus-itcs/lazycap/internal/cap"
 	"github.com/icarus-itcs/lazycap/internal/update"
 )
 
@@ -30,10 +31,11 @@ const (
 
 // Discovery represents a discovered project or configuration
 type Discovery struct {
-	Type    string // "capacitor", "firebase", "ionic", etc.
-	Name    string
-	Path    string
-	Details string
+	Type      string // "capacitor", "firebase", "ionic", etc.
+	Name      string
+	Path      string
+	Details   string
+	Workspace string // Monorepo package name, if the project is a workspace member
 }
 
 // Results contains all preflight check results
@@ -102,7 +104,7 @@ func RunAt(baseDir string) *Results {
 	}
 
 	// Check Capacitor CLI
-	capResult := checkCapacitorCLI()
+	capResult := checkCapacitorCLI(baseDir)
 	results.Checks = append(results.Checks, capResult)
 	if capResult.Status == StatusError {
 		results.HasErrors = true
@@ -111,9 +113,54 @@ func RunAt(baseDir string) *Results {
 	// Discover projects and configurations
 	results.Discoveries = discoverProjects(baseDir)
 
+	// Report config values that can't be resolved without running Node
+	for _, d := range results.Discoveries {
+		if d.Type != "capacitor" {
+			continue
+		}
+		if configResult, ok := checkCapacitorConfig(d); ok {
+			results.Checks = append(results.Checks, configResult)
+			results.HasWarnings = true
+		}
+	}
+
 	return results
 }
 
+// checkCapacitorConfig loads a discovered project's config and returns a
+// warning result for runtime-computed values or a leftover dev server URL
+func checkCapacitorConfig(d Discovery) (CheckResult, bool) {
+	project, err := cap.LoadProjectAt(d.Path)
+	if err != nil {
+		return CheckResult{
+			Name:    "Config: " + d.Name,
+			Status:  StatusWarning,
+			Message: err.Error(),
+			Path:    d.Path,
+		}, true
+	}
+
+	var warnings []string
+	warnings = append(warnings, project.ConfigWarnings...)
+	if project.Config != nil && project.Config.Server.URL != "" {
+		warnings = append(warnings, "server.url is set to "+project.Config.Server.URL+" (remove before release)")
+	}
+	if len(warnings) == 0 {
+		return CheckResult{}, false
+	}
+
+	message := warnings[0]
+	if len(warnings) > 1 {
+		message += fmt.Sprintf(" (+%d more)", len(warnings)-1)
+	}
+	return CheckResult{
+		Name:    "Config: " + d.Name,
+		Status:  StatusWarning,
+		Message: message,
+		Path:    project.ConfigPath,
+	}, true
+}
+
 func checkTool(tool RequiredTool) CheckResult {
 	result := CheckResult{
 		Name: tool.Name,
@@ -159,23 +206,26 @@ func checkTool(tool RequiredTool) CheckResult {
 	return result
 }
 
-func checkCapacitorCLI() CheckResult {
+func checkCapacitorCLI(baseDir string) CheckResult {
 	result := CheckResult{
 		Name: "Capacitor CLI",
 	}
 
-	// Check if npx cap works
-	cmd := exec.Command("npx", "cap", "--version")
+	// Check if cap works through the project's package manager
+	pm := cap.DetectPackageManager(baseDir)
+	argv := pm.Exec("cap", "--version")
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = baseDir
 	output, err := cmd.Output()
 	if err != nil {
 		result.Status = StatusError
-		result.Message = "Not installed - run: npm install @capacitor/cli"
+		result.Message = "Not installed - run: " + strings.Join(pm.Add("@capacitor/cli"), " ")
 		return result
 	}
 
 	result.Status = StatusOK
 	result.Message = "v" + strings.TrimSpace(string(output))
-	result.Path = "npx cap"
+	result.Path = strings.Join(pm.Exec("cap"), " ")
 	return result
 }
 
@@ -291,9 +341,74 @@ func discoverProjects(baseDir string) []Discovery {
 	// Walk directory tree (max 4 levels deep)
 	discoveries = append(discoveries, walkForDiscoveries(baseDir, 0, 4)...)
 
+	// Workspace members can live at any depth, so list them from the manifest
+	if ws := cap.FindWorkspace(baseDir); ws != nil {
+		discoveries = append(discoveries, workspaceDiscoveries(ws)...)
+	}
+
+	return dedupeDiscoveries(discoveries)
+}
+
+// workspaceDiscoveries returns the Capacitor projects among a workspace's members
+func workspaceDiscoveries(ws *cap.Workspace) []Discovery {
+	var discoveries []Discovery
+	for _, pkg := range ws.Packages {
+		if d, ok := capacitorDiscovery(pkg.Dir); ok {
+			d.Workspace = pkg.Name
+			d.Details += " (" + ws.Tool + " workspace: " + pkg.Name + ")"
+			discoveries = append(discoveries, d)
+		}
+	}
 	return discoveries
 }
 
+// dedupeDiscoveries drops repeated type/path pairs, preferring the entry that
+// carries workspace information
+func dedupeDiscoveries(discoveries []Discovery) []Discovery {
+	index := make(map[string]int)
+	result := make([]Discovery, 0, len(discoveries))
+	for _, d := range discoveries {
+		key := d.Type + "\x00" + d.Path
+		if i, ok := index[key]; ok {
+			if result[i].Workspace == "" && d.Workspace != "" {
+				result[i] = d
+			}
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, d)
+	}
+	return result
+}
+
+// capacitorDiscovery reports a Capacitor project in dir, if there is one
+func capacitorDiscovery(dir string) (Discovery, bool) {
+	for _, cfg := range cap.ConfigFiles {
+		if _, err := os.Stat(filepath.Join(dir, cfg)); err != nil {
+			continue
+		}
+		details := cfg
+		// Check for platforms
+		var platforms []string
+		if _, err := os.Stat(filepath.Join(dir, "ios")); err == nil {
+			platforms = append(platforms, "ios")
+		}
+		if _, err := os.Stat(filepath.Join(dir, "android")); err == nil {
+			platforms = append(platforms, "android")
+		}
+		if len(platforms) > 0 {
+			details += " [" + strings.Join(platforms, ", ") + "]"
+		}
+		return Discovery{
+			Type:    "capacitor",
+			Name:    getProjectName(dir),
+			Path:    dir,
+			Details: details,
+		}, true
+	}
+	return Discovery{}, false
+}
+
 func walkForDiscoveries(dir string, depth, maxDepth int) []Discovery {
 	discoveries := make([]Discovery, 0)
 
@@ -302,31 +417,13 @@ func walkForDiscoveries(dir string, depth, maxDepth int) []Discovery {
 	}
 
 	// Check for Capacitor project
-	capConfigs := []string{"capacitor.config.ts", "capacitor.config.js", "capacitor.config.json"}
-	for _, cfg := range capConfigs {
-		configPath := filepath.Join(dir, cfg)
-		if _, err := os.Stat(configPath); err == nil {
-			name := getProjectName(dir)
-			details := cfg
-			// Check for platforms
-			var platforms []string
-			if _, err := os.Stat(filepath.Join(dir, "ios")); err == nil {
-				platforms = append(platforms, "ios")
-			}
-			if _, err := os.Stat(filepath.Join(dir, "android")); err == nil {
-				platforms = append(platforms, "android")
-			}
-			if len(platforms) > 0 {
-				details += " [" + strings.Join(platforms, ", ") + "]"
-			}
-			discoveries = append(discoveries, Discovery{
-				Type:    "capacitor",
-				Name:    name,
-				Path:    dir,
-				Details: details,
-			})
-			break
-		}
+	if d, ok := capacitorDiscovery(dir); ok {
+		discoveries = append(discoveries, d)
+	}
+
+	// Nested monorepo roots list their members explicitly
+	if ws, err := cap.LoadWorkspace(dir); err == nil {
+		discoveries = append(discoveries, workspaceDiscoveries(ws)...)
 	}
 
 	// Check for Firebase
@@ -0,0 +1,203 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/icarus-itcs/lazycap/internal/shellenv"
+)
+
+// Environment is a named profile (e.g. dev/staging/prod) that overlays the
+// global settings for runs, builds and syncs. Empty fields fall back to the
+// global value.
+type Environment struct {
+	Name            string            `json:"name"`
+	EnvironmentVars map[string]string `json:"environmentVars,omitempty"` // Merged over the global env vars
+	BuildCommand    string            `json:"buildCommand,omitempty"`    // Overrides buildCommand
+	LiveReloadHost  string            `json:"liveReloadHost,omitempty"`  // Overrides externalHost
+	LiveReloadPort  int               `json:"liveReloadPort,omitempty"`  // Overrides liveReloadPort
+	AndroidFlavor   string            `json:"androidFlavor,omitempty"`   // Overrides androidFlavor
+	IOSScheme       string            `json:"iosScheme,omitempty"`       // Overrides iosScheme
+}
+
+// EnvironmentNames returns the configured profile names in definition order
+func (s *Settings) EnvironmentNames() []string {
+	names := make([]string, 0, len(s.Environments))
+	for _, env := range s.Environments {
+		names = append(names, env.Name)
+	}
+	return names
+}
+
+// GetEnvironment returns the profile with the given name, or nil
+func (s *Settings) GetEnvironment(name string) *Environment {
+	for i := range s.Environments {
+		if s.Environments[i].Name == name {
+			return &s.Environments[i]
+		}
+	}
+	return nil
+}
+
+// ActiveEnv returns the active profile, or nil when none is selected
+func (s *Settings) ActiveEnv() *Environment {
+	if s.ActiveEnvironment == "" {
+		return nil
+	}
+	return s.GetEnvironment(s.ActiveEnvironment)
+}
+
+// CycleEnvironment selects the next profile (wrapping through "none") and
+// returns the new active name
+func (s *Settings) CycleEnvironment() string {
+	choices := append([]string{""}, s.EnvironmentNames()...)
+	return s.CycleChoice("activeEnvironment", choices)
+}
+
+// ToolEnvVars returns the environment for the configured tool paths: custom
+// node/npm/npx/pod and Android SDK directories go first on PATH, and the SDK,
+// Xcode, CocoaPods and Android Studio paths are exported the way their tools expect
+func (s *Settings) ToolEnvVars() map[string]string {
+	vars := make(map[string]string)
+
+	var dirs []string
+	for _, path := range []string{s.NodePath, s.NpmPath, s.NpxPath, s.PodPath} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			path = filepath.Dir(path)
+		}
+		dirs = append(dirs, path)
+	}
+	if s.AndroidSDKPath != "" {
+		vars["ANDROID_HOME"] = s.AndroidSDKPath
+		vars["ANDROID_SDK_ROOT"] = s.AndroidSDKPath
+		dirs = append(dirs, filepath.Join(s.AndroidSDKPath, "platform-tools"), filepath.Join(s.AndroidSDKPath, "emulator"))
+	}
+	if len(dirs) > 0 {
+		vars["PATH"] = strings.Join(append(dirs, os.Getenv("PATH")), string(os.PathListSeparator))
+	}
+
+	if s.XcodePath != "" {
+		developerDir := s.XcodePath
+		if strings.HasSuffix(developerDir, ".app") {
+			developerDir = filepath.Join(developerDir, "Contents", "Developer")
+		}
+		vars["DEVELOPER_DIR"] = developerDir
+	}
+	switch {
+	case !s.PodInstall:
+		// The Capacitor CLI runs "$CAPACITOR_COCOAPODS_PATH install" when it
+		// syncs iOS; true does nothing and succeeds
+		vars["CAPACITOR_COCOAPODS_PATH"] = "true"
+	case s.PodPath != "":
+		vars["CAPACITOR_COCOAPODS_PATH"] = s.PodPath
+	}
+	if s.AndroidStudioPath != "" {
+		vars["CAPACITOR_ANDROID_STUDIO_PATH"] = s.AndroidStudioPath
+	}
+	if s.VerboseLogging {
+		vars["DEBUG"] = "capacitor:*"
+	}
+
+	return vars
+}
+
+// ApplyToolEnv exports the login environment of the Shell Path shell, then
+// ToolEnvVars, into lazycap's own environment. Every command lazycap starts
+// inherits it, and its adb, emulator and xcrun lookups use the configured
+// tools too.
+func (s *Settings) ApplyToolEnv() {
+	shellenv.Apply(s.ShellPath)
+	for k, v := range s.ToolEnvVars() {
+		_ = os.Setenv(k, v)
+	}
+}
+
+// ResolvedEnvVars returns the tool environment and global env vars overlaid with
+// the active profile's, plus LAZYCAP_ENV so capacitor.config.ts can branch
+// on the profile
+func (s *Settings) ResolvedEnvVars() map[string]string {
+	vars := s.ToolEnvVars()
+	for k, v := range s.EnvironmentVars {
+		vars[k] = v
+	}
+	if env := s.ActiveEnv(); env != nil {
+		for k, v := range env.EnvironmentVars {
+			vars[k] = v
+		}
+		vars["LAZYCAP_ENV"] = env.Name
+	}
+	return vars
+}
+
+// EnvList returns the resolved env vars as sorted KEY=value pairs for exec.Cmd.Env
+func (s *Settings) EnvList() []string {
+	vars := s.ResolvedEnvVars()
+	list := make([]string, 0, len(vars))
+	for k, v := range vars {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// BuildEnvList returns EnvList plus NODE_ENV=production for production builds
+// and the source map setting. Create React App reads GENERATE_SOURCEMAP,
+// which is only set to turn them off so a project's .env still applies;
+// other build configs can read LAZYCAP_SOURCE_MAPS.
+func (s *Settings) BuildEnvList() []string {
+	list := s.EnvList()
+	if s.ProductionBuild {
+		list = append(list, "NODE_ENV=production")
+	}
+	if s.SourceMaps {
+		list = append(list, "LAZYCAP_SOURCE_MAPS=true")
+	} else {
+		list = append(list, "GENERATE_SOURCEMAP=false", "LAZYCAP_SOURCE_MAPS=false")
+	}
+	return list
+}
+
+// ResolvedBuildCommand returns the build command for the active profile (empty = auto-detect)
+func (s *Settings) ResolvedBuildCommand() string {
+	if env := s.ActiveEnv(); env != nil && env.BuildCommand != "" {
+		return env.BuildCommand
+	}
+	return s.BuildCommand
+}
+
+// ResolvedLiveReloadHost returns the live reload host for the active profile
+func (s *Settings) ResolvedLiveReloadHost() string {
+	if env := s.ActiveEnv(); env != nil && env.LiveReloadHost != "" {
+		return env.LiveReloadHost
+	}
+	return s.ExternalHost
+}
+
+// ResolvedLiveReloadPort returns the live reload port for the active profile
+func (s *Settings) ResolvedLiveReloadPort() int {
+	if env := s.ActiveEnv(); env != nil && env.LiveReloadPort > 0 {
+		return env.LiveReloadPort
+	}
+	return s.LiveReloadPort
+}
+
+// ResolvedAndroidFlavor returns the Android flavor for the active profile
+func (s *Settings) ResolvedAndroidFlavor() string {
+	if env := s.ActiveEnv(); env != nil && env.AndroidFlavor != "" {
+		return env.AndroidFlavor
+	}
+	return s.AndroidFlavor
+}
+
+// ResolvedIOSScheme returns the iOS scheme for the active profile
+func (s *Settings) ResolvedIOSScheme() string {
+	if env := s.ActiveEnv(); env != nil && env.IOSScheme != "" {
+		return env.IOSScheme
+	}
+	return s.IOSScheme
+}
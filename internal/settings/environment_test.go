@@ -0,0 +1,193 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToolEnvVars(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	sep := string(os.PathListSeparator)
+	nodeDir := t.TempDir()
+
+	tests := []struct {
+		name string
+		set  func(s *Settings)
+		want map[string]string
+	}{
+		{"defaults", func(s *Settings) {}, map[string]string{}},
+		{"nodePath directory", func(s *Settings) { s.NodePath = nodeDir },
+			map[string]string{"PATH": nodeDir + sep + "/usr/bin"}},
+		{"npxPath binary", func(s *Settings) { s.NpxPath = "/opt/node/bin/npx" },
+			map[string]string{"PATH": "/opt/node/bin" + sep + "/usr/bin"}},
+		{"npmPath binary", func(s *Settings) { s.NpmPath = "/opt/npm/bin/npm" },
+			map[string]string{"PATH": "/opt/npm/bin" + sep + "/usr/bin"}},
+		{"podPath", func(s *Settings) { s.PodPath = "/opt/gems/bin/pod" },
+			map[string]string{"PATH": "/opt/gems/bin" + sep + "/usr/bin", "CAPACITOR_COCOAPODS_PATH": "/opt/gems/bin/pod"}},
+		{"podInstall off", func(s *Settings) { s.PodInstall = false },
+			map[string]string{"CAPACITOR_COCOAPODS_PATH": "true"}},
+		{"podInstall off wins over podPath", func(s *Settings) { s.PodInstall = false; s.PodPath = "/opt/gems/bin/pod" },
+			map[string]string{"PATH": "/opt/gems/bin" + sep + "/usr/bin", "CAPACITOR_COCOAPODS_PATH": "true"}},
+		{"androidSdkPath", func(s *Settings) { s.AndroidSDKPath = "/sdk" },
+			map[string]string{
+				"ANDROID_HOME":     "/sdk",
+				"ANDROID_SDK_ROOT": "/sdk",
+				"PATH":             filepath.Join("/sdk", "platform-tools") + sep + filepath.Join("/sdk", "emulator") + sep + "/usr/bin",
+			}},
+		{"xcodePath app", func(s *Settings) { s.XcodePath = "/Applications/Xcode-15.app" },
+			map[string]string{"DEVELOPER_DIR": filepath.Join("/Applications/Xcode-15.app", "Contents", "Developer")}},
+		{"xcodePath developer dir", func(s *Settings) { s.XcodePath = "/Applications/Xcode.app/Contents/Developer" },
+			map[string]string{"DEVELOPER_DIR": "/Applications/Xcode.app/Contents/Developer"}},
+		{"androidStudioPath", func(s *Settings) { s.AndroidStudioPath = "/opt/android-studio/bin/studio.sh" },
+			map[string]string{"CAPACITOR_ANDROID_STUDIO_PATH": "/opt/android-studio/bin/studio.sh"}},
+		{"verboseLogging", func(s *Settings) { s.VerboseLogging = true },
+			map[string]string{"DEBUG": "capacitor:*"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultSettings()
+			tt.set(s)
+			if got := s.ToolEnvVars(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToolEnvVars() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildEnvList(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(s *Settings)
+		want []string
+	}{
+		{"defaults", func(s *Settings) {}, []string{"LAZYCAP_SOURCE_MAPS=true"}},
+		{"environmentVars", func(s *Settings) { s.EnvironmentVars = map[string]string{"B": "2", "A": "1"} },
+			[]string{"A=1", "B=2", "LAZYCAP_SOURCE_MAPS=true"}},
+		{"productionBuild", func(s *Settings) { s.ProductionBuild = true },
+			[]string{"NODE_ENV=production", "LAZYCAP_SOURCE_MAPS=true"}},
+		{"sourceMaps off", func(s *Settings) { s.SourceMaps = false },
+			[]string{"GENERATE_SOURCEMAP=false", "LAZYCAP_SOURCE_MAPS=false"}},
+		{"active environment overlays environmentVars", func(s *Settings) {
+			s.EnvironmentVars = map[string]string{"API": "dev", "KEEP": "1"}
+			s.Environments = []Environment{{Name: "prod", EnvironmentVars: map[string]string{"API": "prod"}}}
+			s.ActiveEnvironment = "prod"
+		}, []string{"API=prod", "KEEP=1", "LAZYCAP_ENV=prod", "LAZYCAP_SOURCE_MAPS=true"}},
+		{"inactive environment is ignored", func(s *Settings) {
+			s.Environments = []Environment{{Name: "prod", EnvironmentVars: map[string]string{"API": "prod"}}}
+		}, []string{"LAZYCAP_SOURCE_MAPS=true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultSettings()
+			tt.set(s)
+			got := s.BuildEnvList()
+			if got == nil {
+				got = []string{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildEnvList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	s := DefaultSettings()
+	s.ProductionBuild = true
+	s.SourceMaps = false
+	for _, kv := range s.EnvList() {
+		if strings.HasPrefix(kv, "NODE_ENV=") || strings.HasPrefix(kv, "GENERATE_SOURCEMAP=") {
+			t.Errorf("EnvList() has %s; only builds get it", kv)
+		}
+	}
+}
+
+func TestResolvedSettings(t *testing.T) {
+	s := DefaultSettings()
+	s.BuildCommand = "npm run build"
+	s.ExternalHost = "10.0.0.1"
+	s.AndroidFlavor = "dev"
+	s.IOSScheme = "App"
+	s.Environments = []Environment{
+		{Name: "empty"},
+		{Name: "staging", BuildCommand: "npm run build:staging", LiveReloadHost: "10.0.0.2", LiveReloadPort: 9000, AndroidFlavor: "staging", IOSScheme: "App Staging"},
+	}
+
+	tests := []struct {
+		active                      string
+		build, host, flavor, scheme string
+		port                        int
+	}{
+		{"", "npm run build", "10.0.0.1", "dev", "App", 8100},
+		{"empty", "npm run build", "10.0.0.1", "dev", "App", 8100},
+		{"staging", "npm run build:staging", "10.0.0.2", "staging", "App Staging", 9000},
+	}
+	for _, tt := range tests {
+		t.Run("active="+tt.active, func(t *testing.T) {
+			s.ActiveEnvironment = tt.active
+			got := []interface{}{s.ResolvedBuildCommand(), s.ResolvedLiveReloadHost(), s.ResolvedAndroidFlavor(), s.ResolvedIOSScheme(), s.ResolvedLiveReloadPort()}
+			want := []interface{}{tt.build, tt.host, tt.flavor, tt.scheme, tt.port}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("resolved = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	tests := []struct {
+		seconds int
+		want    time.Duration
+	}{
+		{0, 0},
+		{-5, 0},
+		{90, 90 * time.Second},
+	}
+	for _, tt := range tests {
+		s := DefaultSettings()
+		s.BuildTimeout = tt.seconds
+		s.SyncTimeout = tt.seconds
+		if got := s.BuildTimeoutDuration(); got != tt.want {
+			t.Errorf("BuildTimeout %d: BuildTimeoutDuration() = %v, want %v", tt.seconds, got, tt.want)
+		}
+		if got := s.SyncTimeoutDuration(); got != tt.want {
+			t.Errorf("SyncTimeout %d: SyncTimeoutDuration() = %v, want %v", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestHooksFor(t *testing.T) {
+	s := DefaultSettings()
+	s.PreRunCommand = "  ./lint.sh  "
+	s.PostRunCommand = "say done"
+	s.PreBuildCommand = "rm -rf dist"
+	s.PostBuildCommand = ""
+	s.Hooks = map[string][]Hook{
+		HookPreRun:    {{Command: "echo second"}, {Command: "  "}},
+		HookPostBuild: {{Command: "du -sh dist"}},
+	}
+
+	tests := []struct {
+		stage string
+		want  []string
+	}{
+		{HookPreRun, []string{"./lint.sh", "echo second"}},
+		{HookPostRun, []string{"say done"}},
+		{HookPreBuild, []string{"rm -rf dist"}},
+		{HookPostBuild, []string{"du -sh dist"}},
+		{HookPreSync, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stage, func(t *testing.T) {
+			var got []string
+			for _, h := range s.HooksFor(tt.stage) {
+				got = append(got, h.Command)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HooksFor(%s) = %q, want %q", tt.stage, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package settings
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Hook stages, keys of Settings.Hooks
+const (
+	HookPreRun    = "preRun"
+	HookPostRun   = "postRun"
+	HookPreBuild  = "preBuild"
+	HookPostBuild = "postBuild"
+	HookPreSync   = "preSync"
+	HookPostSync  = "postSync"
+	HookPreOpen   = "preOpen"
+	HookPostOpen  = "postOpen"
+)
+
+// Hook failure policies
+const (
+	HookAbort    = "abort"    // Stop the pipeline (default)
+	HookContinue = "continue" // Log the failure and carry on
+)
+
+// Hook is a shell command run before or after a run, build, sync or open
+type Hook struct {
+	Name      string            `json:"name,omitempty"`      // Shown in the process list (default: the command)
+	Command   string            `json:"command"`             // Run through the configured shell
+	Dir       string            `json:"dir,omitempty"`       // Working directory, relative to the project root
+	Env       map[string]string `json:"env,omitempty"`       // Extra environment variables
+	Timeout   int               `json:"timeout,omitempty"`   // Timeout in seconds (0 = no limit)
+	OnFailure string            `json:"onFailure,omitempty"` // "abort" (default) or "continue"
+}
+
+// DisplayName returns the hook's name, or its command's first word
+func (h Hook) DisplayName() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	if fields := strings.Fields(h.Command); len(fields) > 0 {
+		return filepath.Base(fields[0])
+	}
+	return "hook"
+}
+
+// ContinueOnFailure reports whether a failure should let the pipeline carry on
+func (h Hook) ContinueOnFailure() bool {
+	return h.OnFailure == HookContinue
+}
+
+// TimeoutDuration returns the hook timeout (0 = no limit)
+func (h Hook) TimeoutDuration() time.Duration {
+	return time.Duration(h.Timeout) * time.Second
+}
+
+// WorkDir resolves the hook's working directory against the project root
+func (h Hook) WorkDir(projectDir string) string {
+	if h.Dir == "" {
+		return projectDir
+	}
+	if filepath.IsAbs(h.Dir) {
+		return h.Dir
+	}
+	return filepath.Join(projectDir, h.Dir)
+}
+
+// EnvList returns the hook's env vars as sorted KEY=VALUE pairs
+func (h Hook) EnvList() []string {
+	list := make([]string, 0, len(h.Env))
+	for k, v := range h.Env {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// HooksFor returns the hooks for a stage in run order: the single command
+// from the Hooks settings category first, then the configured list
+func (s *Settings) HooksFor(stage string) []Hook {
+	var hooks []Hook
+
+	var command string
+	switch stage {
+	case HookPreRun:
+		command = s.PreRunCommand
+	case HookPostRun:
+		command = s.PostRunCommand
+	case HookPreBuild:
+		command = s.PreBuildCommand
+	case HookPostBuild:
+		command = s.PostBuildCommand
+	}
+	if command = strings.TrimSpace(command); command != "" {
+		hooks = append(hooks, Hook{Command: command})
+	}
+
+	for _, h := range s.Hooks[stage] {
+		if strings.TrimSpace(h.Command) != "" {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks
+}
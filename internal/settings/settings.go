@@ -2,9 +2,11 @@ package settings
 
 import (
 	"encoding/json"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // LocalConfigName is the filename for per-project config
@@ -18,10 +20,10 @@ type Settings struct {
 	LiveReloadDefault bool   `json:"liveReloadDefault"` // Enable live reload by default
 	ExternalHost      string `json:"externalHost"`      // External IP for live reload (empty = auto)
 	LiveReloadPort    int    `json:"liveReloadPort"`    // Port for live reload server
-	AutoSync          bool   `json:"autoSync"`          // Sync before running
+	AndroidLiveReload string `json:"androidLiveReload"` // How Android devices reach the live reload server: "lan" or "reverse" (adb reverse)
 	AutoBuild         bool   `json:"autoBuild"`         // Build before syncing
+	AutoSync          bool   `json:"autoSync"`          // Sync before running
 	DefaultPlatform   string `json:"defaultPlatform"`   // Preferred platform: "ios", "android", ""
-	RunInBackground   bool   `json:"runInBackground"`   // Don't block UI during run
 	ClearLogsOnRun    bool   `json:"clearLogsOnRun"`    // Clear process logs on new run
 
 	// === BUILD OPTIONS ===
@@ -31,21 +33,19 @@ type Settings struct {
 	BuildTimeout    int    `json:"buildTimeout"`    // Build timeout in seconds
 
 	// === iOS OPTIONS ===
-	IOSScheme           string `json:"iosScheme"`           // Xcode scheme name
-	IOSConfiguration    string `json:"iosConfiguration"`    // Debug or Release
-	IOSSimulator        string `json:"iosSimulator"`        // Default simulator UDID
-	IOSTeamID           string `json:"iosTeamId"`           // Development team ID
-	IOSDerivedData      string `json:"iosDerivedData"`      // Custom derived data path
-	IOSCodeSignIdentity string `json:"iosCodeSignIdentity"` // Code signing identity
-	IOSAutoSigningBool  bool   `json:"iosAutoSigning"`      // Use automatic signing
+	IOSScheme        string `json:"iosScheme"`        // Xcode scheme name
+	IOSConfiguration string `json:"iosConfiguration"` // Debug or Release
+	IOSSimulator     string `json:"iosSimulator"`     // Default simulator (ID, name, alias or rule)
 
 	// === ANDROID OPTIONS ===
-	AndroidDevice       string `json:"androidDevice"`       // Default device/emulator ID
-	AndroidFlavor       string `json:"androidFlavor"`       // Build flavor
-	AndroidBuildType    string `json:"androidBuildType"`    // debug or release
-	AndroidKeystorePath string `json:"androidKeystorePath"` // Path to release keystore
-	AndroidSDKPath      string `json:"androidSdkPath"`      // Custom Android SDK path
-	AndroidStudioPath   string `json:"androidStudioPath"`   // Path to Android Studio
+	AndroidDevice     string `json:"androidDevice"`     // Default device/emulator (ID, name, alias or rule)
+	AndroidFlavor     string `json:"androidFlavor"`     // Build flavor
+	AndroidSDKPath    string `json:"androidSdkPath"`    // Custom Android SDK path
+	AndroidStudioPath string `json:"androidStudioPath"` // Path to Android Studio
+
+	// === DEVICES ===
+	DeviceAliases   map[string]string `json:"deviceAliases,omitempty"`   // Alias -> device ID, name or rule
+	WirelessDevices []WirelessDevice  `json:"wirelessDevices,omitempty"` // Known wireless adb devices, reconnected at startup
 
 	// === WEB OPTIONS ===
 	WebDevCommand  string `json:"webDevCommand"`  // Dev server command (empty = auto-detect)
@@ -53,22 +53,20 @@ type Settings struct {
 	WebOpenBrowser bool   `json:"webOpenBrowser"` // Auto-open browser on start
 	WebBrowserPath string `json:"webBrowserPath"` // Custom browser path
 	WebHost        string `json:"webHost"`        // Dev server host (localhost, 0.0.0.0)
-	WebHttps       bool   `json:"webHttps"`       // Use HTTPS for dev server
+	WebHttps       bool   `json:"webHttps"`       // Serve the dev server and live reload over HTTPS
 
 	// === UI OPTIONS ===
-	ShowSpinners       bool   `json:"showSpinners"`       // Show animated spinners
-	CompactMode        bool   `json:"compactMode"`        // Compact UI layout
-	ShowTimestamps     bool   `json:"showTimestamps"`     // Timestamps in logs
-	MaxLogLines        int    `json:"maxLogLines"`        // Max lines per process log
-	ColorTheme         string `json:"colorTheme"`         // "dark", "light", "system"
-	ShowDeviceIcons    bool   `json:"showDeviceIcons"`    // Show emoji icons for devices
-	ShowPlatformBadges bool   `json:"showPlatformBadges"` // Show iOS/Android badges
-	LogFontSize        string `json:"logFontSize"`        // "small", "normal", "large"
+	ShowSpinners       bool `json:"showSpinners"`       // Show animated spinners
+	ShowTimestamps     bool `json:"showTimestamps"`     // Timestamps in logs
+	MaxLogLines        int  `json:"maxLogLines"`        // Max lines per process log
+	ShowDeviceIcons    bool `json:"showDeviceIcons"`    // Show emoji icons for devices
+	ShowPlatformBadges bool `json:"showPlatformBadges"` // Show iOS/Android badges
 
 	// === BEHAVIOR ===
 	ConfirmBeforeKill  bool `json:"confirmBeforeKill"`  // Confirm before killing process
 	AutoScrollLogs     bool `json:"autoScrollLogs"`     // Auto-scroll to bottom
 	RefreshOnFocus     bool `json:"refreshOnFocus"`     // Refresh devices on focus
+	WatchDevices       bool `json:"watchDevices"`       // Update devices as they connect, boot or shut down
 	CheckForUpgrades   bool `json:"checkForUpgrades"`   // Check Capacitor upgrades
 	NotifyOnComplete   bool `json:"notifyOnComplete"`   // System notification when done
 	SoundOnComplete    bool `json:"soundOnComplete"`    // Play sound when done
@@ -76,47 +74,62 @@ type Settings struct {
 	KeepProcessHistory int  `json:"keepProcessHistory"` // Number of old processes to keep
 
 	// === SYNC OPTIONS ===
-	SyncOnSave   bool `json:"syncOnSave"`   // Sync when web files change
 	SyncTimeout  int  `json:"syncTimeout"`  // Sync timeout in seconds
 	CopyWebDir   bool `json:"copyWebDir"`   // Copy web dir on sync
 	UpdateNative bool `json:"updateNative"` // Update native deps on sync
 	PodInstall   bool `json:"podInstall"`   // Run pod install on iOS sync
 
 	// === PATHS & ENVIRONMENT ===
-	NodePath         string `json:"nodePath"`         // Custom node path
-	NpmPath          string `json:"npmPath"`          // Custom npm path
-	NpxPath          string `json:"npxPath"`          // Custom npx path
-	PodPath          string `json:"podPath"`          // Custom pod path
-	XcodePath        string `json:"xcodePath"`        // Custom Xcode path
-	ShellPath        string `json:"shellPath"`        // Shell to use for commands
-	WorkingDirectory string `json:"workingDirectory"` // Override working directory
+	NodePath  string `json:"nodePath"`  // Custom node path
+	NpmPath   string `json:"npmPath"`   // Custom npm path
+	NpxPath   string `json:"npxPath"`   // Custom npx path
+	PodPath   string `json:"podPath"`   // Custom pod path
+	XcodePath string `json:"xcodePath"` // Custom Xcode path
+	ShellPath string `json:"shellPath"` // Shell to use for commands
 
 	// === ADVANCED ===
-	VerboseLogging      bool              `json:"verboseLogging"`      // Verbose output
-	DebugMode           bool              `json:"debugMode"`           // Debug mode
-	PreRunCommand       string            `json:"preRunCommand"`       // Command to run before each run
-	PostRunCommand      string            `json:"postRunCommand"`      // Command to run after each run
-	PreBuildCommand     string            `json:"preBuildCommand"`     // Command to run before build
-	PostBuildCommand    string            `json:"postBuildCommand"`    // Command to run after build
-	EnvironmentVars     map[string]string `json:"environmentVars"`     // Custom env vars
-	CapacitorConfigPath string            `json:"capacitorConfigPath"` // Custom config path
-	DisableTelemetry    bool              `json:"disableTelemetry"`    // Disable telemetry
-
-	// === PLUGIN SETTINGS ===
-	EnableHotReload  bool `json:"enableHotReload"`  // Enable hot module reload
-	PreserveState    bool `json:"preserveState"`    // Preserve app state on reload
-	InlineSourceMaps bool `json:"inlineSourceMaps"` // Inline source maps
-	MinifyBuilds     bool `json:"minifyBuilds"`     // Minify production builds
-
-	// === EXPERIMENTAL ===
-	EnableBetaFeatures bool `json:"enableBetaFeatures"` // Enable beta features
-	ParallelBuilds     bool `json:"parallelBuilds"`     // Build platforms in parallel
-	CacheBuilds        bool `json:"cacheBuilds"`        // Cache build artifacts
-	IncrementalSync    bool `json:"incrementalSync"`    // Only sync changed files
+	VerboseLogging   bool              `json:"verboseLogging"`   // Verbose output
+	PreRunCommand    string            `json:"preRunCommand"`    // Command to run before each run
+	PostRunCommand   string            `json:"postRunCommand"`   // Command to run after each run
+	PreBuildCommand  string            `json:"preBuildCommand"`  // Command to run before build
+	PostBuildCommand string            `json:"postBuildCommand"` // Command to run after build
+	Hooks            map[string][]Hook `json:"hooks,omitempty"`  // Ordered hooks per stage (preRun, postBuild, ...)
+	EnvironmentVars  map[string]string `json:"environmentVars"`  // Custom env vars
+
+	// === NOT SUPPORTED ===
+	// Kept so saved values survive a save, but left out of GetCategories
+	// since nothing applies them. cap run always builds Android's debug
+	// variant, so there is no release run for androidBuildType to select.
+	RunInBackground     bool   `json:"runInBackground"`     // Don't block UI during run
+	IOSTeamID           string `json:"iosTeamId"`           // Development team ID
+	IOSDerivedData      string `json:"iosDerivedData"`      // Custom derived data path
+	IOSCodeSignIdentity string `json:"iosCodeSignIdentity"` // Code signing identity
+	IOSAutoSigningBool  bool   `json:"iosAutoSigning"`      // Use automatic signing
+	AndroidBuildType    string `json:"androidBuildType"`    // debug or release
+	AndroidKeystorePath string `json:"androidKeystorePath"` // Path to release keystore
+	CompactMode         bool   `json:"compactMode"`         // Compact UI layout
+	ColorTheme          string `json:"colorTheme"`          // "dark", "light", "system"
+	LogFontSize         string `json:"logFontSize"`         // "small", "normal", "large"
+	SyncOnSave          bool   `json:"syncOnSave"`          // Sync when web files change
+	WorkingDirectory    string `json:"workingDirectory"`    // Override working directory
+	DebugMode           bool   `json:"debugMode"`           // Debug mode
+	DisableTelemetry    bool   `json:"disableTelemetry"`    // Disable telemetry
+	EnableHotReload     bool   `json:"enableHotReload"`     // Enable hot module reload
+	PreserveState       bool   `json:"preserveState"`       // Preserve app state on reload
+	InlineSourceMaps    bool   `json:"inlineSourceMaps"`    // Inline source maps
+	MinifyBuilds        bool   `json:"minifyBuilds"`        // Minify production builds
+	EnableBetaFeatures  bool   `json:"enableBetaFeatures"`  // Enable beta features
+	ParallelBuilds      bool   `json:"parallelBuilds"`      // Build platforms in parallel
+	CacheBuilds         bool   `json:"cacheBuilds"`         // Cache build artifacts
+	IncrementalSync     bool   `json:"incrementalSync"`     // Only sync changed files
 
 	// === MCP SERVER ===
 	MCPEnabled bool            `json:"mcpEnabled"` // Enable MCP server
 	MCPTools   map[string]bool `json:"mcpTools"`   // Enabled/disabled state per tool
+
+	// === ENVIRONMENTS ===
+	Environments      []Environment `json:"environments"`      // Named dev/staging/prod profiles
+	ActiveEnvironment string        `json:"activeEnvironment"` // Selected profile (empty = none)
 }
 
 // DefaultSettings returns settings with sensible defaults
@@ -126,10 +139,10 @@ func DefaultSettings() *Settings {
 		LiveReloadDefault: false,
 		ExternalHost:      "",
 		LiveReloadPort:    8100,
-		AutoSync:          false,
+		AndroidLiveReload: "lan",
 		AutoBuild:         false,
+		AutoSync:          false,
 		DefaultPlatform:   "",
-		RunInBackground:   false,
 		ClearLogsOnRun:    false,
 
 		// Build options
@@ -139,21 +152,15 @@ func DefaultSettings() *Settings {
 		BuildTimeout:    300,
 
 		// iOS options
-		IOSScheme:           "",
-		IOSConfiguration:    "Debug",
-		IOSSimulator:        "",
-		IOSTeamID:           "",
-		IOSDerivedData:      "",
-		IOSCodeSignIdentity: "",
-		IOSAutoSigningBool:  true,
+		IOSScheme:        "",
+		IOSConfiguration: "Debug",
+		IOSSimulator:     "",
 
 		// Android options
-		AndroidDevice:       "",
-		AndroidFlavor:       "",
-		AndroidBuildType:    "debug",
-		AndroidKeystorePath: "",
-		AndroidSDKPath:      "",
-		AndroidStudioPath:   "",
+		AndroidDevice:     "",
+		AndroidFlavor:     "",
+		AndroidSDKPath:    "",
+		AndroidStudioPath: "",
 
 		// Web options
 		WebDevCommand:  "",
@@ -165,18 +172,16 @@ func DefaultSettings() *Settings {
 
 		// UI options
 		ShowSpinners:       true,
-		CompactMode:        false,
 		ShowTimestamps:     true,
 		MaxLogLines:        5000,
-		ColorTheme:         "dark",
 		ShowDeviceIcons:    true,
 		ShowPlatformBadges: true,
-		LogFontSize:        "normal",
 
 		// Behavior
 		ConfirmBeforeKill:  false,
 		AutoScrollLogs:     true,
 		RefreshOnFocus:     true,
+		WatchDevices:       true,
 		CheckForUpgrades:   true,
 		NotifyOnComplete:   false,
 		SoundOnComplete:    false,
@@ -184,47 +189,43 @@ func DefaultSettings() *Settings {
 		KeepProcessHistory: 10,
 
 		// Sync options
-		SyncOnSave:   false,
 		SyncTimeout:  120,
 		CopyWebDir:   true,
 		UpdateNative: true,
 		PodInstall:   true,
 
 		// Paths
-		NodePath:         "",
-		NpmPath:          "",
-		NpxPath:          "",
-		PodPath:          "",
-		XcodePath:        "",
-		ShellPath:        "",
-		WorkingDirectory: "",
+		NodePath:  "",
+		NpmPath:   "",
+		NpxPath:   "",
+		PodPath:   "",
+		XcodePath: "",
+		ShellPath: "",
 
 		// Advanced
-		VerboseLogging:      false,
-		DebugMode:           false,
-		PreRunCommand:       "",
-		PostRunCommand:      "",
-		PreBuildCommand:     "",
-		PostBuildCommand:    "",
-		EnvironmentVars:     make(map[string]string),
-		CapacitorConfigPath: "",
-		DisableTelemetry:    false,
-
-		// Plugin settings
-		EnableHotReload:  true,
-		PreserveState:    true,
-		InlineSourceMaps: false,
-		MinifyBuilds:     true,
-
-		// Experimental
-		EnableBetaFeatures: false,
-		ParallelBuilds:     false,
-		CacheBuilds:        false,
-		IncrementalSync:    false,
+		VerboseLogging:   false,
+		PreRunCommand:    "",
+		PostRunCommand:   "",
+		PreBuildCommand:  "",
+		PostBuildCommand: "",
+		EnvironmentVars:  make(map[string]string),
+
+		// Not supported
+		IOSAutoSigningBool: true,
+		AndroidBuildType:   "debug",
+		ColorTheme:         "dark",
+		LogFontSize:        "normal",
+		EnableHotReload:    true,
+		PreserveState:      true,
+		MinifyBuilds:       true,
 
 		// MCP Server
 		MCPEnabled: true,
 		MCPTools:   make(map[string]bool),
+
+		// Environments
+		Environments:      []Environment{},
+		ActiveEnvironment: "",
 	}
 }
 
@@ -434,11 +435,10 @@ func GetCategories() []Category {
 			Icon: "▶",
 			Settings: []SettingInfo{
 				{Key: "liveReloadDefault", Name: "Live Reload Default", Description: "Enable live reload by default when running", Type: "bool"},
-				{Key: "autoSync", Name: "Auto Sync", Description: "Automatically sync before running", Type: "bool"},
-				{Key: "autoBuild", Name: "Auto Build", Description: "Automatically build before syncing", Type: "bool"},
-				{Key: "defaultPlatform", Name: "Default Platform", Description: "Preferred platform for commands", Type: "choice", Choices: []string{"", "ios", "android"}},
-				{Key: "clearLogsOnRun", Name: "Clear Logs on Run", Description: "Clear log output when starting new run", Type: "bool"},
-				{Key: "runInBackground", Name: "Run in Background", Description: "Don't block UI during run operations", Type: "bool"},
+				{Key: "autoBuild", Name: "Auto Build", Description: "Build web assets before syncing and running", Type: "bool"},
+				{Key: "autoSync", Name: "Auto Sync", Description: "Sync with the Sync settings before running, instead of cap run's own sync (not with live reload)", Type: "bool"},
+				{Key: "defaultPlatform", Name: "Default Platform", Description: "Select a device of this platform at startup", Type: "choice", Choices: []string{"", "ios", "android"}},
+				{Key: "clearLogsOnRun", Name: "Clear Logs on Run", Description: "Replace the previous run's logs for the same device", Type: "bool"},
 			},
 		},
 		{
@@ -446,44 +446,39 @@ func GetCategories() []Category {
 			Icon: "🔨",
 			Settings: []SettingInfo{
 				{Key: "buildCommand", Name: "Build Command", Description: "Custom build command (empty = auto-detect)", Type: "string"},
-				{Key: "productionBuild", Name: "Production Build", Description: "Use production build by default", Type: "bool"},
-				{Key: "sourceMaps", Name: "Source Maps", Description: "Generate source maps", Type: "bool"},
-				{Key: "buildTimeout", Name: "Build Timeout", Description: "Build timeout in seconds", Type: "int"},
-				{Key: "minifyBuilds", Name: "Minify Builds", Description: "Minify production builds", Type: "bool"},
+				{Key: "productionBuild", Name: "Production Build", Description: "Build with NODE_ENV=production", Type: "bool"},
+				{Key: "sourceMaps", Name: "Source Maps", Description: "Generate source maps (GENERATE_SOURCEMAP, LAZYCAP_SOURCE_MAPS)", Type: "bool"},
+				{Key: "buildTimeout", Name: "Build Timeout", Description: "Build timeout in seconds (0 = no limit)", Type: "int"},
 			},
 		},
 		{
 			Name: "iOS",
 			Icon: "",
 			Settings: []SettingInfo{
-				{Key: "iosConfiguration", Name: "Configuration", Description: "Build configuration", Type: "choice", Choices: []string{"Debug", "Release"}},
+				{Key: "iosConfiguration", Name: "Configuration", Description: "iOS build configuration for cap run", Type: "choice", Choices: []string{"Debug", "Release"}},
 				{Key: "iosScheme", Name: "Scheme", Description: "Xcode scheme name", Type: "string"},
-				{Key: "iosSimulator", Name: "Default Simulator", Description: "Default simulator UDID", Type: "string"},
-				{Key: "iosTeamId", Name: "Team ID", Description: "Development team ID", Type: "string"},
-				{Key: "iosAutoSigning", Name: "Auto Signing", Description: "Use automatic code signing", Type: "bool"},
-				{Key: "iosDerivedData", Name: "Derived Data Path", Description: "Custom derived data path", Type: "string"},
+				{Key: "iosSimulator", Name: "Default Simulator", Description: "Simulator to select at startup (UDID, name, alias or rule like ios:newest)", Type: "string"},
 			},
 		},
 		{
 			Name: "Android",
 			Icon: "🤖",
 			Settings: []SettingInfo{
-				{Key: "androidBuildType", Name: "Build Type", Description: "Build type", Type: "choice", Choices: []string{"debug", "release"}},
 				{Key: "androidFlavor", Name: "Flavor", Description: "Build flavor", Type: "string"},
-				{Key: "androidDevice", Name: "Default Device", Description: "Default device/emulator ID", Type: "string"},
-				{Key: "androidSdkPath", Name: "SDK Path", Description: "Custom Android SDK path", Type: "string"},
-				{Key: "androidKeystorePath", Name: "Keystore Path", Description: "Path to release keystore", Type: "string"},
+				{Key: "androidDevice", Name: "Default Device", Description: "Device to select at startup (serial, name, alias or rule like android:booted)", Type: "string"},
+				{Key: "androidSdkPath", Name: "SDK Path", Description: "Custom Android SDK path (ANDROID_HOME)", Type: "string"},
+				{Key: "androidStudioPath", Name: "Android Studio Path", Description: "Android Studio used by cap open", Type: "string"},
 			},
 		},
 		{
 			Name: "Web",
 			Icon: "🌐",
 			Settings: []SettingInfo{
-				{Key: "webDevCommand", Name: "Dev Command", Description: "Dev server command (empty = auto-detect)", Type: "string"},
+				{Key: "webDevCommand", Name: "Dev Command", Description: "Dev server command for web and live reload (empty = auto-detect)", Type: "string"},
 				{Key: "webDevPort", Name: "Dev Port", Description: "Dev server port", Type: "int"},
 				{Key: "webHost", Name: "Host", Description: "Dev server host", Type: "choice", Choices: []string{"localhost", "0.0.0.0"}},
 				{Key: "webOpenBrowser", Name: "Open Browser", Description: "Auto-open browser on start", Type: "bool"},
-				{Key: "webHttps", Name: "Use HTTPS", Description: "Use HTTPS for dev server", Type: "bool"},
+				{Key: "webHttps", Name: "Use HTTPS", Description: "Serve the dev server and live reload over HTTPS with lazycap's local CA", Type: "bool"},
 				{Key: "webBrowserPath", Name: "Browser Path", Description: "Custom browser executable path", Type: "string"},
 			},
 		},
@@ -491,12 +486,10 @@ func GetCategories() []Category {
 			Name: "Sync",
 			Icon: "🔄",
 			Settings: []SettingInfo{
-				{Key: "syncOnSave", Name: "Sync on Save", Description: "Sync when web files change", Type: "bool"},
-				{Key: "syncTimeout", Name: "Sync Timeout", Description: "Sync timeout in seconds", Type: "int"},
-				{Key: "copyWebDir", Name: "Copy Web Dir", Description: "Copy web directory on sync", Type: "bool"},
-				{Key: "updateNative", Name: "Update Native", Description: "Update native dependencies", Type: "bool"},
-				{Key: "podInstall", Name: "Pod Install", Description: "Run pod install on iOS sync", Type: "bool"},
-				{Key: "incrementalSync", Name: "Incremental Sync", Description: "Only sync changed files (experimental)", Type: "bool"},
+				{Key: "syncTimeout", Name: "Sync Timeout", Description: "Sync timeout in seconds (0 = no limit)", Type: "int"},
+				{Key: "copyWebDir", Name: "Copy Web Dir", Description: "Copy web assets on sync (cap copy)", Type: "bool"},
+				{Key: "updateNative", Name: "Update Native", Description: "Update native plugins on sync (cap update)", Type: "bool"},
+				{Key: "podInstall", Name: "Pod Install", Description: "Run pod install when syncing iOS", Type: "bool"},
 			},
 		},
 		{
@@ -505,8 +498,7 @@ func GetCategories() []Category {
 			Settings: []SettingInfo{
 				{Key: "externalHost", Name: "External Host", Description: "External IP for live reload (empty = auto)", Type: "string"},
 				{Key: "liveReloadPort", Name: "Port", Description: "Port for live reload server", Type: "int"},
-				{Key: "enableHotReload", Name: "Hot Reload", Description: "Enable hot module replacement", Type: "bool"},
-				{Key: "preserveState", Name: "Preserve State", Description: "Preserve app state on reload", Type: "bool"},
+				{Key: "androidLiveReload", Name: "Android Mode", Description: "How Android devices reach live reload: lan, or reverse (adb reverse to localhost)", Type: "choice", Choices: []string{"lan", "reverse"}},
 			},
 		},
 		{
@@ -514,26 +506,25 @@ func GetCategories() []Category {
 			Icon: "🎨",
 			Settings: []SettingInfo{
 				{Key: "showSpinners", Name: "Show Spinners", Description: "Show animated process spinners", Type: "bool"},
-				{Key: "compactMode", Name: "Compact Mode", Description: "Use compact UI layout", Type: "bool"},
 				{Key: "showTimestamps", Name: "Timestamps", Description: "Show timestamps in logs", Type: "bool"},
 				{Key: "maxLogLines", Name: "Max Log Lines", Description: "Maximum lines per process", Type: "int"},
-				{Key: "showDeviceIcons", Name: "Device Icons", Description: "Show emoji icons for devices", Type: "bool"},
+				{Key: "showDeviceIcons", Name: "Device Icons", Description: "Show simulator/device type in the device list", Type: "bool"},
 				{Key: "showPlatformBadges", Name: "Platform Badges", Description: "Show iOS/Android badges", Type: "bool"},
-				{Key: "colorTheme", Name: "Color Theme", Description: "UI color theme", Type: "choice", Choices: []string{"dark", "light", "system"}},
 			},
 		},
 		{
 			Name: "Behavior",
 			Icon: "⚙",
 			Settings: []SettingInfo{
-				{Key: "confirmBeforeKill", Name: "Confirm Kill", Description: "Confirm before killing process", Type: "bool"},
+				{Key: "confirmBeforeKill", Name: "Confirm Kill", Description: "Press x twice to stop a running process", Type: "bool"},
 				{Key: "autoScrollLogs", Name: "Auto Scroll", Description: "Auto-scroll logs to bottom", Type: "bool"},
-				{Key: "refreshOnFocus", Name: "Refresh on Focus", Description: "Refresh devices on focus change", Type: "bool"},
+				{Key: "refreshOnFocus", Name: "Refresh on Focus", Description: "Refresh devices when the terminal regains focus", Type: "bool"},
+				{Key: "watchDevices", Name: "Watch Devices", Description: "Update devices as they connect, boot or shut down (applies on restart)", Type: "bool"},
 				{Key: "checkForUpgrades", Name: "Check Upgrades", Description: "Check for Capacitor upgrades", Type: "bool"},
 				{Key: "notifyOnComplete", Name: "Notify on Complete", Description: "System notification when done", Type: "bool"},
 				{Key: "soundOnComplete", Name: "Sound on Complete", Description: "Play sound when done", Type: "bool"},
-				{Key: "autoOpenIde", Name: "Auto Open IDE", Description: "Open IDE on build error", Type: "bool"},
-				{Key: "keepProcessHistory", Name: "Process History", Description: "Old processes to keep", Type: "int"},
+				{Key: "autoOpenIde", Name: "Auto Open IDE", Description: "Open the native IDE when a run fails", Type: "bool"},
+				{Key: "keepProcessHistory", Name: "Process History", Description: "Finished processes to keep (0 = all)", Type: "int"},
 			},
 		},
 		{
@@ -543,33 +534,26 @@ func GetCategories() []Category {
 				{Key: "nodePath", Name: "Node Path", Description: "Custom node executable path", Type: "string"},
 				{Key: "npmPath", Name: "npm Path", Description: "Custom npm executable path", Type: "string"},
 				{Key: "npxPath", Name: "npx Path", Description: "Custom npx executable path", Type: "string"},
-				{Key: "podPath", Name: "Pod Path", Description: "Custom pod executable path", Type: "string"},
-				{Key: "xcodePath", Name: "Xcode Path", Description: "Custom Xcode path", Type: "string"},
-				{Key: "shellPath", Name: "Shell Path", Description: "Shell for running commands", Type: "string"},
-				{Key: "workingDirectory", Name: "Working Directory", Description: "Override working directory", Type: "string"},
+				{Key: "podPath", Name: "Pod Path", Description: "Custom pod executable path (CAPACITOR_COCOAPODS_PATH)", Type: "string"},
+				{Key: "xcodePath", Name: "Xcode Path", Description: "Custom Xcode path (DEVELOPER_DIR)", Type: "string"},
+				{Key: "shellPath", Name: "Shell Path", Description: "Login shell for PATH, nvm, asdf and volta, and for hooks (empty = $SHELL)", Type: "string"},
 			},
 		},
 		{
 			Name: "Hooks",
 			Icon: "🪝",
 			Settings: []SettingInfo{
-				{Key: "preRunCommand", Name: "Pre-Run", Description: "Command to run before each run", Type: "string"},
-				{Key: "postRunCommand", Name: "Post-Run", Description: "Command to run after each run", Type: "string"},
-				{Key: "preBuildCommand", Name: "Pre-Build", Description: "Command to run before build", Type: "string"},
-				{Key: "postBuildCommand", Name: "Post-Build", Description: "Command to run after build", Type: "string"},
+				{Key: "preRunCommand", Name: "Pre-Run", Description: "Shell command run before each run (more in \"hooks\")", Type: "string"},
+				{Key: "postRunCommand", Name: "Post-Run", Description: "Shell command run after a successful run", Type: "string"},
+				{Key: "preBuildCommand", Name: "Pre-Build", Description: "Shell command run before build (more in \"hooks\")", Type: "string"},
+				{Key: "postBuildCommand", Name: "Post-Build", Description: "Shell command run after a successful build", Type: "string"},
 			},
 		},
 		{
 			Name: "Advanced",
 			Icon: "🔧",
 			Settings: []SettingInfo{
-				{Key: "verboseLogging", Name: "Verbose Logging", Description: "Enable verbose output", Type: "bool"},
-				{Key: "debugMode", Name: "Debug Mode", Description: "Enable debug mode", Type: "bool"},
-				{Key: "disableTelemetry", Name: "Disable Telemetry", Description: "Disable usage telemetry", Type: "bool"},
-				{Key: "capacitorConfigPath", Name: "Config Path", Description: "Custom capacitor config path", Type: "string"},
-				{Key: "enableBetaFeatures", Name: "Beta Features", Description: "Enable experimental features", Type: "bool"},
-				{Key: "parallelBuilds", Name: "Parallel Builds", Description: "Build platforms in parallel", Type: "bool"},
-				{Key: "cacheBuilds", Name: "Cache Builds", Description: "Cache build artifacts", Type: "bool"},
+				{Key: "verboseLogging", Name: "Verbose Logging", Description: "Verbose Capacitor CLI output (DEBUG=capacitor:*)", Type: "bool"},
 			},
 		},
 		{
@@ -583,7 +567,12 @@ func GetCategories() []Category {
 				{Key: "mcpTool:sync", Name: "sync", Description: "Sync web assets to native", Type: "bool"},
 				{Key: "mcpTool:build", Name: "build", Description: "Build web assets", Type: "bool"},
 				{Key: "mcpTool:open_ide", Name: "open_ide", Description: "Open native IDE", Type: "bool"},
+				{Key: "mcpTool:list_simulator_options", Name: "list_simulator_options", Description: "List simulator runtimes and device types", Type: "bool"},
+				{Key: "mcpTool:create_simulator", Name: "create_simulator", Description: "Create iOS simulators", Type: "bool"},
+				{Key: "mcpTool:manage_simulator", Name: "manage_simulator", Description: "Boot, clone, erase, rename or delete simulators", Type: "bool"},
+				{Key: "mcpTool:configure_simulator", Name: "configure_simulator", Description: "Simulator appearance, locale, status bar and location", Type: "bool"},
 				{Key: "mcpTool:get_project", Name: "get_project", Description: "Get project information", Type: "bool"},
+				{Key: "mcpTool:get_plugin_report", Name: "get_plugin_report", Description: "Capacitor plugin compatibility report", Type: "bool"},
 				{Key: "mcpTool:get_debug_actions", Name: "get_debug_actions", Description: "List debug actions", Type: "bool"},
 				{Key: "mcpTool:run_debug_action", Name: "run_debug_action", Description: "Run debug/cleanup actions", Type: "bool"},
 				{Key: "mcpTool:get_all_logs", Name: "get_all_logs", Description: "Get logs with filtering", Type: "bool"},
@@ -593,6 +582,24 @@ func GetCategories() []Category {
 	}
 }
 
+// WithChoices returns the categories with the given settings turned into
+// choice lists (e.g. flavors read from the native project). An empty choice
+// is kept first so the setting can go back to the default.
+func WithChoices(categories []Category, choices map[string][]string) []Category {
+	for ci := range categories {
+		for si, setting := range categories[ci].Settings {
+			values, ok := choices[setting.Key]
+			if !ok || len(values) == 0 {
+				continue
+			}
+			setting.Type = "choice"
+			setting.Choices = append([]string{""}, values...)
+			categories[ci].Settings[si] = setting
+		}
+	}
+	return categories
+}
+
 // GetAllSettings returns a flat list of all settings
 func GetAllSettings() []SettingInfo {
 	categories := GetCategories()
@@ -612,40 +619,24 @@ func (s *Settings) GetBool(key string) bool {
 	switch key {
 	case "liveReloadDefault":
 		return s.LiveReloadDefault
-	case "autoSync":
-		return s.AutoSync
 	case "autoBuild":
 		return s.AutoBuild
-	case "runInBackground":
-		return s.RunInBackground
+	case "autoSync":
+		return s.AutoSync
 	case "clearLogsOnRun":
 		return s.ClearLogsOnRun
 	case "productionBuild":
 		return s.ProductionBuild
 	case "sourceMaps":
 		return s.SourceMaps
-	case "minifyBuilds":
-		return s.MinifyBuilds
-	case "iosAutoSigning":
-		return s.IOSAutoSigningBool
-	case "syncOnSave":
-		return s.SyncOnSave
 	case "copyWebDir":
 		return s.CopyWebDir
 	case "updateNative":
 		return s.UpdateNative
 	case "podInstall":
 		return s.PodInstall
-	case "incrementalSync":
-		return s.IncrementalSync
-	case "enableHotReload":
-		return s.EnableHotReload
-	case "preserveState":
-		return s.PreserveState
 	case "showSpinners":
 		return s.ShowSpinners
-	case "compactMode":
-		return s.CompactMode
 	case "showTimestamps":
 		return s.ShowTimestamps
 	case "showDeviceIcons":
@@ -658,6 +649,8 @@ func (s *Settings) GetBool(key string) bool {
 		return s.AutoScrollLogs
 	case "refreshOnFocus":
 		return s.RefreshOnFocus
+	case "watchDevices":
+		return s.WatchDevices
 	case "checkForUpgrades":
 		return s.CheckForUpgrades
 	case "notifyOnComplete":
@@ -668,18 +661,6 @@ func (s *Settings) GetBool(key string) bool {
 		return s.AutoOpenIDE
 	case "verboseLogging":
 		return s.VerboseLogging
-	case "debugMode":
-		return s.DebugMode
-	case "disableTelemetry":
-		return s.DisableTelemetry
-	case "enableBetaFeatures":
-		return s.EnableBetaFeatures
-	case "parallelBuilds":
-		return s.ParallelBuilds
-	case "cacheBuilds":
-		return s.CacheBuilds
-	case "inlineSourceMaps":
-		return s.InlineSourceMaps
 	case "webOpenBrowser":
 		return s.WebOpenBrowser
 	case "webHttps":
@@ -700,40 +681,24 @@ func (s *Settings) SetBool(key string, value bool) {
 	switch key {
 	case "liveReloadDefault":
 		s.LiveReloadDefault = value
-	case "autoSync":
-		s.AutoSync = value
 	case "autoBuild":
 		s.AutoBuild = value
-	case "runInBackground":
-		s.RunInBackground = value
+	case "autoSync":
+		s.AutoSync = value
 	case "clearLogsOnRun":
 		s.ClearLogsOnRun = value
 	case "productionBuild":
 		s.ProductionBuild = value
 	case "sourceMaps":
 		s.SourceMaps = value
-	case "minifyBuilds":
-		s.MinifyBuilds = value
-	case "iosAutoSigning":
-		s.IOSAutoSigningBool = value
-	case "syncOnSave":
-		s.SyncOnSave = value
 	case "copyWebDir":
 		s.CopyWebDir = value
 	case "updateNative":
 		s.UpdateNative = value
 	case "podInstall":
 		s.PodInstall = value
-	case "incrementalSync":
-		s.IncrementalSync = value
-	case "enableHotReload":
-		s.EnableHotReload = value
-	case "preserveState":
-		s.PreserveState = value
 	case "showSpinners":
 		s.ShowSpinners = value
-	case "compactMode":
-		s.CompactMode = value
 	case "showTimestamps":
 		s.ShowTimestamps = value
 	case "showDeviceIcons":
@@ -746,6 +711,8 @@ func (s *Settings) SetBool(key string, value bool) {
 		s.AutoScrollLogs = value
 	case "refreshOnFocus":
 		s.RefreshOnFocus = value
+	case "watchDevices":
+		s.WatchDevices = value
 	case "checkForUpgrades":
 		s.CheckForUpgrades = value
 	case "notifyOnComplete":
@@ -756,18 +723,6 @@ func (s *Settings) SetBool(key string, value bool) {
 		s.AutoOpenIDE = value
 	case "verboseLogging":
 		s.VerboseLogging = value
-	case "debugMode":
-		s.DebugMode = value
-	case "disableTelemetry":
-		s.DisableTelemetry = value
-	case "enableBetaFeatures":
-		s.EnableBetaFeatures = value
-	case "parallelBuilds":
-		s.ParallelBuilds = value
-	case "cacheBuilds":
-		s.CacheBuilds = value
-	case "inlineSourceMaps":
-		s.InlineSourceMaps = value
 	case "webOpenBrowser":
 		s.WebOpenBrowser = value
 	case "webHttps":
@@ -796,30 +751,18 @@ func (s *Settings) GetString(key string) string {
 		return s.IOSConfiguration
 	case "iosSimulator":
 		return s.IOSSimulator
-	case "iosTeamId":
-		return s.IOSTeamID
-	case "iosDerivedData":
-		return s.IOSDerivedData
-	case "iosCodeSignIdentity":
-		return s.IOSCodeSignIdentity
 	case "androidDevice":
 		return s.AndroidDevice
 	case "androidFlavor":
 		return s.AndroidFlavor
-	case "androidBuildType":
-		return s.AndroidBuildType
-	case "androidKeystorePath":
-		return s.AndroidKeystorePath
 	case "androidSdkPath":
 		return s.AndroidSDKPath
 	case "androidStudioPath":
 		return s.AndroidStudioPath
 	case "externalHost":
 		return s.ExternalHost
-	case "colorTheme":
-		return s.ColorTheme
-	case "logFontSize":
-		return s.LogFontSize
+	case "androidLiveReload":
+		return s.AndroidLiveReload
 	case "nodePath":
 		return s.NodePath
 	case "npmPath":
@@ -832,8 +775,6 @@ func (s *Settings) GetString(key string) string {
 		return s.XcodePath
 	case "shellPath":
 		return s.ShellPath
-	case "workingDirectory":
-		return s.WorkingDirectory
 	case "preRunCommand":
 		return s.PreRunCommand
 	case "postRunCommand":
@@ -842,14 +783,14 @@ func (s *Settings) GetString(key string) string {
 		return s.PreBuildCommand
 	case "postBuildCommand":
 		return s.PostBuildCommand
-	case "capacitorConfigPath":
-		return s.CapacitorConfigPath
 	case "webDevCommand":
 		return s.WebDevCommand
 	case "webBrowserPath":
 		return s.WebBrowserPath
 	case "webHost":
 		return s.WebHost
+	case "activeEnvironment":
+		return s.ActiveEnvironment
 	}
 	return ""
 }
@@ -867,30 +808,18 @@ func (s *Settings) SetString(key string, value string) {
 		s.IOSConfiguration = value
 	case "iosSimulator":
 		s.IOSSimulator = value
-	case "iosTeamId":
-		s.IOSTeamID = value
-	case "iosDerivedData":
-		s.IOSDerivedData = value
-	case "iosCodeSignIdentity":
-		s.IOSCodeSignIdentity = value
 	case "androidDevice":
 		s.AndroidDevice = value
 	case "androidFlavor":
 		s.AndroidFlavor = value
-	case "androidBuildType":
-		s.AndroidBuildType = value
-	case "androidKeystorePath":
-		s.AndroidKeystorePath = value
 	case "androidSdkPath":
 		s.AndroidSDKPath = value
 	case "androidStudioPath":
 		s.AndroidStudioPath = value
 	case "externalHost":
 		s.ExternalHost = value
-	case "colorTheme":
-		s.ColorTheme = value
-	case "logFontSize":
-		s.LogFontSize = value
+	case "androidLiveReload":
+		s.AndroidLiveReload = value
 	case "nodePath":
 		s.NodePath = value
 	case "npmPath":
@@ -903,8 +832,6 @@ func (s *Settings) SetString(key string, value string) {
 		s.XcodePath = value
 	case "shellPath":
 		s.ShellPath = value
-	case "workingDirectory":
-		s.WorkingDirectory = value
 	case "preRunCommand":
 		s.PreRunCommand = value
 	case "postRunCommand":
@@ -913,14 +840,14 @@ func (s *Settings) SetString(key string, value string) {
 		s.PreBuildCommand = value
 	case "postBuildCommand":
 		s.PostBuildCommand = value
-	case "capacitorConfigPath":
-		s.CapacitorConfigPath = value
 	case "webDevCommand":
 		s.WebDevCommand = value
 	case "webBrowserPath":
 		s.WebBrowserPath = value
 	case "webHost":
 		s.WebHost = value
+	case "activeEnvironment":
+		s.ActiveEnvironment = value
 	}
 }
 
@@ -986,6 +913,107 @@ func (s *Settings) CycleChoice(key string, choices []string) string {
 	return ""
 }
 
+// BuildTimeoutDuration returns the build timeout (0 = no limit)
+func (s *Settings) BuildTimeoutDuration() time.Duration {
+	if s == nil || s.BuildTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(s.BuildTimeout) * time.Second
+}
+
+// SyncTimeoutDuration returns the sync timeout (0 = no limit)
+func (s *Settings) SyncTimeoutDuration() time.Duration {
+	if s == nil || s.SyncTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(s.SyncTimeout) * time.Second
+}
+
+// ADBReverse reports whether Android devices reach the live reload server
+// through adb reverse at localhost rather than at this machine's address
+func (s *Settings) ADBReverse() bool {
+	return s.AndroidLiveReload == "reverse"
+}
+
+// DefaultDeviceQueries returns the configured default devices, the one for the
+// default platform first. Each may be a device ID, name, alias or rule.
+func (s *Settings) DefaultDeviceQueries() []string {
+	preferred := []string{s.AndroidDevice, s.IOSSimulator}
+	if s.DefaultPlatform == "ios" {
+		preferred = []string{s.IOSSimulator, s.AndroidDevice}
+	}
+	var queries []string
+	for _, q := range preferred {
+		if q != "" {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// WirelessDevice is an Android device lazycap has connected to over Wi-Fi
+type WirelessDevice struct {
+	Name    string `json:"name"`
+	Serial  string `json:"serial,omitempty"` // Hardware serial number, when the device reports one
+	Address string `json:"address"`          // host:port for adb connect
+}
+
+// WirelessAddresses returns the addresses of the known wireless devices
+func (s *Settings) WirelessAddresses() []string {
+	addresses := make([]string, 0, len(s.WirelessDevices))
+	for _, d := range s.WirelessDevices {
+		addresses = append(addresses, d.Address)
+	}
+	return addresses
+}
+
+// RememberWirelessDevice adds a wireless device, or updates the one it was
+// connected as before. Devices are matched by serial number when both are
+// known, otherwise by host, since the port changes each time wireless
+// debugging is turned on.
+func (s *Settings) RememberWirelessDevice(name, serial, address string) {
+	for i, d := range s.WirelessDevices {
+		if !sameWirelessDevice(d, serial, address) {
+			continue
+		}
+		if name == "" {
+			name = d.Name
+		}
+		if serial == "" {
+			serial = d.Serial
+		}
+		s.WirelessDevices[i] = WirelessDevice{Name: name, Serial: serial, Address: address}
+		return
+	}
+	s.WirelessDevices = append(s.WirelessDevices, WirelessDevice{Name: name, Serial: serial, Address: address})
+}
+
+func sameWirelessDevice(d WirelessDevice, serial, address string) bool {
+	if d.Serial != "" && serial != "" {
+		return d.Serial == serial
+	}
+	return d.Address == address || addressHost(d.Address) == addressHost(address)
+}
+
+// addressHost returns the host of a host:port address
+func addressHost(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// ForgetWirelessDevice removes a known wireless device by address
+func (s *Settings) ForgetWirelessDevice(address string) {
+	kept := s.WirelessDevices[:0]
+	for _, d := range s.WirelessDevices {
+		if d.Address != address {
+			kept = append(kept, d)
+		}
+	}
+	s.WirelessDevices = kept
+}
+
 // ConfigPath returns the path to the global config file (for backwards compatibility)
 func ConfigPath() (string, error) {
 	return globalConfigPath()
@@ -1018,6 +1046,10 @@ func AllMCPTools() []string {
 		"sync",
 		"build",
 		"open_ide",
+		"list_simulator_options",
+		"create_simulator",
+		"manage_simulator",
+		"configure_simulator",
 		"get_project",
 		"get_debug_actions",
 		"run_debug_action",
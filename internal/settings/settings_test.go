@@ -0,0 +1,72 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRememberWirelessDevice(t *testing.T) {
+	known := []WirelessDevice{
+		{Name: "Pixel 8", Serial: "35A1", Address: "192.168.1.20:37001"},
+		{Name: "Pixel 8", Serial: "47B2", Address: "192.168.1.21:41234"},
+		{Name: "Galaxy", Address: "192.168.1.30:5555"},
+	}
+
+	tests := []struct {
+		name                string
+		model, serial, addr string
+		want                []WirelessDevice
+	}{
+		{"same model, new device", "Pixel 8", "9C03", "192.168.1.40:40000", append(append([]WirelessDevice{}, known...),
+			WirelessDevice{Name: "Pixel 8", Serial: "9C03", Address: "192.168.1.40:40000"})},
+		{"serial with new port and host", "Pixel 8", "47B2", "192.168.1.50:39000", []WirelessDevice{
+			known[0], {Name: "Pixel 8", Serial: "47B2", Address: "192.168.1.50:39000"}, known[2]}},
+		{"host with new port, no serial", "", "", "192.168.1.30:42000", []WirelessDevice{
+			known[0], known[1], {Name: "Galaxy", Address: "192.168.1.30:42000"}}},
+		{"serial learned on reconnect", "Galaxy S23", "R5CW", "192.168.1.30:5555", []WirelessDevice{
+			known[0], known[1], {Name: "Galaxy S23", Serial: "R5CW", Address: "192.168.1.30:5555"}}},
+		{"host reused by another device", "Pixel 7", "11AA", "192.168.1.20:37001", append(append([]WirelessDevice{}, known...),
+			WirelessDevice{Name: "Pixel 7", Serial: "11AA", Address: "192.168.1.20:37001"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultSettings()
+			s.WirelessDevices = append([]WirelessDevice{}, known...)
+			s.RememberWirelessDevice(tt.model, tt.serial, tt.addr)
+			if !reflect.DeepEqual(s.WirelessDevices, tt.want) {
+				t.Errorf("WirelessDevices = %+v, want %+v", s.WirelessDevices, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnsupportedSettingsSurviveSave(t *testing.T) {
+	dir := t.TempDir()
+	saved := `{"androidBuildType": "release", "iosTeamId": "ABCDE12345", "colorTheme": "light", "podInstall": false}`
+	if err := os.WriteFile(filepath.Join(dir, LocalConfigName), []byte(saved), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = LoadAt(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.AndroidBuildType != "release" || s.IOSTeamID != "ABCDE12345" || s.ColorTheme != "light" || s.PodInstall {
+		t.Errorf("after save: androidBuildType=%q iosTeamId=%q colorTheme=%q podInstall=%v", s.AndroidBuildType, s.IOSTeamID, s.ColorTheme, s.PodInstall)
+	}
+
+	for _, setting := range GetAllSettings() {
+		switch setting.Key {
+		case "androidBuildType", "iosTeamId", "colorTheme":
+			t.Errorf("%s is in the settings screen but nothing applies it", setting.Key)
+		}
+	}
+}
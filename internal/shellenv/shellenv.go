@@ -0,0 +1,239 @@
+package shellenv
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// marker brackets the env output so anything the startup files print is skipped
+const marker = "__LAZYCAP_ENV__"
+
+// cacheTTL is how long a resolved environment is reused from disk when no
+// shell startup file changed
+const cacheTTL = 24 * time.Hour
+
+// vars and prefixes name the variables taken from the login shell: PATH, the
+// node version managers and the JDK/Android SDK locations set in profiles
+var (
+	vars     = []string{"PATH", "JAVA_HOME", "ANDROID_HOME", "ANDROID_SDK_ROOT"}
+	prefixes = []string{"NVM_", "ASDF_", "VOLTA_", "FNM_"}
+)
+
+// startupFiles are the files, relative to the home directory, whose changes
+// make a cached environment stale
+var startupFiles = []string{
+	".profile", ".bash_profile", ".bash_login", ".bashrc",
+	".zshenv", ".zprofile", ".zshrc", ".zlogin",
+	".config/fish/config.fish", ".config/fish/conf.d",
+	".tool-versions", ".nvmrc",
+}
+
+var (
+	mu       sync.Mutex
+	resolved = map[string]map[string]string{}
+)
+
+// Shell returns the shell commands run with: the Shell Path setting, then
+// $SHELL, then sh
+func Shell(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "sh"
+}
+
+// Command returns the argv that runs a command line written by the user (a
+// hook or custom build command) with shell
+func Command(shell, command string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", command}
+	}
+	return []string{Shell(shell), "-c", command}
+}
+
+// Resolve returns the PATH, nvm, asdf, volta and SDK variables of shell's
+// login environment. It starts the shell once per lazycap run, reusing the
+// result cached on disk until a startup file changes. Returns nil on Windows
+// or when the shell can't be run.
+func Resolve(shell string) map[string]string {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	shell = Shell(shell)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if env, ok := resolved[shell]; ok {
+		return env
+	}
+	env := loadCache(shell)
+	if env == nil {
+		env = capture(shell)
+		if env != nil {
+			saveCache(shell, env)
+		}
+	}
+	resolved[shell] = env
+	return env
+}
+
+// Apply fills in lazycap's own environment from shell's login environment,
+// so the commands it starts and the tools it looks up (node, npx, adb, pod)
+// are found the same way as in the user's terminal even when lazycap was
+// started from an IDE or launcher. The login shell's PATH entries come
+// first, followed by any inherited entries it lacks; its other variables only
+// fill in those that are unset. Tools set in lazycap's settings (nodePath,
+// androidSdkPath, ...) are put ahead of both afterwards.
+func Apply(shell string) {
+	for k, v := range Resolve(shell) {
+		current := os.Getenv(k)
+		switch {
+		case k == "PATH":
+			v = mergePath(v, current)
+		case current != "":
+			continue
+		}
+		_ = os.Setenv(k, v)
+	}
+}
+
+// capture runs shell as an interactive login shell, where version managers
+// are usually set up, and reads its environment. Shells that refuse -i are
+// run as plain login shells.
+func capture(shell string) map[string]string {
+	script := "echo " + marker + "; env; echo " + marker
+	for _, flags := range [][]string{{"-l", "-i", "-c"}, {"-l", "-c"}} {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		cmd := exec.CommandContext(ctx, shell, append(flags, script)...)
+		detach(cmd)
+		output, _ := cmd.Output()
+		cancel()
+		if env := parse(string(output)); env != nil {
+			return env
+		}
+	}
+	return nil
+}
+
+// parse reads the wanted variables from the env output between the markers
+func parse(output string) map[string]string {
+	start := strings.Index(output, marker+"\n")
+	end := strings.LastIndex(output, marker)
+	if start < 0 || end <= start {
+		return nil
+	}
+
+	env := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(output[start+len(marker)+1 : end]))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), "=")
+		if ok && wanted(k) {
+			env[k] = v
+		}
+	}
+	if env["PATH"] == "" {
+		return nil
+	}
+	return env
+}
+
+func wanted(name string) bool {
+	for _, v := range vars {
+		if name == v {
+			return true
+		}
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergePath joins the entries of the paths in order, dropping empty and
+// repeated entries
+func mergePath(paths ...string) string {
+	seen := map[string]bool{}
+	var parts []string
+	for _, path := range paths {
+		for _, p := range filepath.SplitList(path) {
+			if p != "" && !seen[p] {
+				seen[p] = true
+				parts = append(parts, p)
+			}
+		}
+	}
+	return strings.Join(parts, string(os.PathListSeparator))
+}
+
+// cacheFile is where resolved environments are kept, by shell
+func cacheFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lazycap", "login-env.json")
+}
+
+func loadCache(shell string) map[string]string {
+	path := cacheFile()
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > cacheTTL || startupFilesChangedSince(info.ModTime()) {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cache map[string]map[string]string
+	if json.Unmarshal(data, &cache) != nil {
+		return nil
+	}
+	return cache[shell]
+}
+
+func saveCache(shell string, env map[string]string) {
+	path := cacheFile()
+	if path == "" {
+		return
+	}
+	cache := map[string]map[string]string{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &cache)
+	}
+	cache[shell] = env
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o600)
+}
+
+func startupFilesChangedSince(t time.Time) bool {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return true
+	}
+	for _, name := range startupFiles {
+		if info, err := os.Stat(filepath.Join(home, name)); err == nil && info.ModTime().After(t) {
+			return true
+		}
+	}
+	return false
+}
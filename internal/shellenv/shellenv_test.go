@@ -0,0 +1,66 @@
+package shellenv
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMergePath(t *testing.T) {
+	join := func(parts ...string) string { return strings.Join(parts, string(os.PathListSeparator)) }
+	tests := []struct {
+		name           string
+		login, current string
+		want           string
+	}{
+		{"login first", join("/nvm/bin", "/usr/bin"), join("/usr/bin", "/ide/bin"), join("/nvm/bin", "/usr/bin", "/ide/bin")},
+		{"duplicates in each", join("/a", "/b", "/a"), join("/b", "/c", "/c"), join("/a", "/b", "/c")},
+		{"empty entries", join("", "/a", ""), join("/b", ""), join("/a", "/b")},
+		{"no current", "/a", "", "/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergePath(tt.login, tt.current); got != tt.want {
+				t.Errorf("mergePath(%q, %q) = %q, want %q", tt.login, tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	output := "Welcome!\n" + marker + "\nPATH=/nvm/bin:/usr/bin\nNVM_DIR=/home/me/.nvm\nHOME=/home/me\nJAVA_HOME=/jdk\n" + marker + "\n"
+	env := parse(output)
+	want := map[string]string{"PATH": "/nvm/bin:/usr/bin", "NVM_DIR": "/home/me/.nvm", "JAVA_HOME": "/jdk"}
+	if len(env) != len(want) {
+		t.Fatalf("parse() = %v, want %v", env, want)
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("parse()[%s] = %q, want %q", k, env[k], v)
+		}
+	}
+	if parse("no markers\nPATH=/usr/bin\n") != nil {
+		t.Error("parse() without markers returned an environment")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands run with cmd on Windows")
+	}
+	t.Setenv("SHELL", "/bin/zsh")
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"", []string{"/bin/zsh", "-c", "npm run build && echo ok"}},
+		{"/usr/bin/fish", []string{"/usr/bin/fish", "-c", "npm run build && echo ok"}},
+	}
+	for _, tt := range tests {
+		got := Command(tt.shell, "npm run build && echo ok")
+		if strings.Join(got, "\x00") != strings.Join(tt.want, "\x00") {
+			t.Errorf("Command(%q) = %q, want %q", tt.shell, got, tt.want)
+		}
+	}
+}
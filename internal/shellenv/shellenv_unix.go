@@ -0,0 +1,15 @@
+//go:build !windows
+
+package shellenv
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach starts the shell in a new session without a controlling terminal,
+// so interactive startup files (prompt themes, instant prompts) can't draw
+// on the terminal lazycap is about to use
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package shellenv
+
+import "os/exec"
+
+// Login shells aren't resolved on Windows
+func detach(cmd *exec.Cmd) {}
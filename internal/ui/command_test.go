@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/icarus-itcs/lazycap/internal/cap"
+	"github.com/icarus-itcs/lazycap/internal/device"
+	"github.com/icarus-itcs/lazycap/internal/settings"
+)
+
+func TestShellJoin(t *testing.T) {
+	tests := []struct {
+		argv []string
+		want string
+	}{
+		{[]string{"npx", "cap", "run", "android"}, "npx cap run android"},
+		{[]string{"npx", "cap", "run", "ios", "--scheme", "App Staging"}, "npx cap run ios --scheme 'App Staging'"},
+		{[]string{"echo", ""}, "echo ''"},
+		{[]string{"echo", "it's"}, `echo 'it'\''s'`},
+		{[]string{"echo", `say "hi"`}, `echo 'say "hi"'`},
+		{[]string{"echo", "$HOME"}, "echo '$HOME'"},
+		{[]string{"echo", "a;b", "a&b", "a|b", "a<b", "a>b"}, "echo 'a;b' 'a&b' 'a|b' 'a<b' 'a>b'"},
+		{[]string{"echo", "(x)", "*.js", "?", "`id`", `C:\dir`}, "echo '(x)' '*.js' '?' '`id`' 'C:\\dir'"},
+		{[]string{"echo", "tab\there"}, "echo 'tab\there'"},
+		{[]string{"--flavor=dev", "key=value", "path/to/file.ts"}, "--flavor=dev key=value path/to/file.ts"},
+	}
+	for _, tt := range tests {
+		if got := shellJoin(tt.argv); got != tt.want {
+			t.Errorf("shellJoin(%q) = %s, want %s", tt.argv, got, tt.want)
+		}
+	}
+}
+
+func TestProcessAddLogMaxLines(t *testing.T) {
+	tests := []struct {
+		maxLines, added int
+		want            []string
+	}{
+		{3, 2, []string{"0", "1"}},
+		{3, 5, []string{"2", "3", "4"}},
+		{1, 2, []string{"1"}},
+	}
+	for _, tt := range tests {
+		p := &Process{MaxLines: tt.maxLines}
+		for i := 0; i < tt.added; i++ {
+			p.AddLog(fmt.Sprint(i))
+		}
+		if !reflect.DeepEqual(p.Logs, tt.want) {
+			t.Errorf("MaxLines %d, %d lines: Logs = %q, want %q", tt.maxLines, tt.added, p.Logs, tt.want)
+		}
+	}
+
+	p := &Process{}
+	for i := 0; i < 5001; i++ {
+		p.AddLog(fmt.Sprint(i))
+	}
+	if len(p.Logs) != 5000 || p.Logs[0] != "1" {
+		t.Errorf("default MaxLines kept %d lines starting at %s, want 5000 starting at 1", len(p.Logs), p.Logs[0])
+	}
+}
+
+func TestRunCommandAutoSync(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(s *settings.Settings)
+		want string
+	}{
+		{"defaults", func(s *settings.Settings) {},
+			"npx cap run android --target dev1"},
+		{"autoBuild", func(s *settings.Settings) { s.AutoBuild = true },
+			"make web && npx cap run android --target dev1"},
+		{"autoSync", func(s *settings.Settings) { s.AutoSync = true },
+			"npx cap sync android && npx cap run android --target dev1 --no-sync"},
+		{"autoSync and autoBuild", func(s *settings.Settings) { s.AutoSync = true; s.AutoBuild = true },
+			"make web && npx cap sync android && npx cap run android --target dev1 --no-sync"},
+		{"autoSync with copyWebDir off", func(s *settings.Settings) { s.AutoSync = true; s.AutoBuild = true; s.CopyWebDir = false },
+			"npx cap update android && npx cap run android --target dev1 --no-sync"},
+		{"autoSync with nothing to sync", func(s *settings.Settings) { s.AutoSync = true; s.CopyWebDir = false; s.UpdateNative = false },
+			"npx cap run android --target dev1 --no-sync"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := settings.DefaultSettings()
+			s.BuildCommand = "make web"
+			tt.set(s)
+			m := &Model{
+				settings:  s,
+				project:   &cap.Project{RootDir: t.TempDir(), PackageManager: cap.NPM},
+				pipelines: make(map[string]*pipeline),
+			}
+			m.startRunCommand(&device.Device{ID: "dev1", Name: "Pixel", Platform: "android"}, false)
+			if len(m.processes) != 1 {
+				t.Fatalf("started %d processes, want 1", len(m.processes))
+			}
+			if got := m.processes[0].Command; got != tt.want {
+				t.Errorf("command = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,777 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/icarus-itcs/lazycap/internal/cap"
+	"github.com/icarus-itcs/lazycap/internal/device"
+)
+
+// deviceAction is an entry of the device manager panel
+type deviceAction struct {
+	name        string
+	description string
+	dangerous   bool        // Needs a second enter
+	fields      []formField // Asked for before running
+	run         func(m *Model, values []string) tea.Cmd
+}
+
+// formField is a value a device action asks for: one of a list of choices,
+// or free text when there are none
+type formField struct {
+	label   string
+	choices func(values []string) ([]string, error) // Loaded when the field is reached, given the earlier values
+	value   string                                  // Default text
+}
+
+// deviceManager is the state of the device manager panel for one device
+type deviceManager struct {
+	device  device.Device
+	actions []deviceAction
+	cursor  int
+	confirm bool // Dangerous action awaiting a second enter
+
+	// Form of the action being filled in
+	action  *deviceAction
+	field   int
+	values  []string
+	choices []string
+	choice  int
+	input   string
+	err     string
+	loading bool // Choices are being loaded
+	load    int  // Counts choice loads so a stale result is dropped
+}
+
+// fieldChoicesMsg carries the choices loaded for a form field
+type fieldChoicesMsg struct {
+	load    int
+	choices []string
+	err     error
+}
+
+// openDeviceManager shows the management actions for the selected device
+func (m *Model) openDeviceManager() {
+	dev := m.getSelectedDevice()
+	if dev == nil {
+		m.setStatus("No device selected")
+		return
+	}
+	actions := m.deviceActions(dev)
+	if len(actions) == 0 {
+		m.setStatus("Nothing to manage for " + dev.Name)
+		return
+	}
+	m.deviceManager = &deviceManager{device: *dev, actions: actions}
+	m.showDeviceManager = true
+	m.showHelp = false
+	m.showPreflight = false
+}
+
+// deviceActions returns what can be done to a device from the panel,
+// followed by creating new emulators and simulators and connecting to
+// wireless Android devices
+func (m *Model) deviceActions(dev *device.Device) []deviceAction {
+	var actions []deviceAction
+	switch {
+	case dev.Platform == "android" && dev.IsEmulator:
+		actions = append(actions, avdActions(dev)...)
+	case dev.Platform == "ios" && dev.IsEmulator:
+		actions = append(actions, simulatorActions(dev)...)
+	}
+	if !dev.IsEmulator && !dev.IsWeb {
+		actions = append(actions, m.liveReloadHostAction(dev))
+	}
+	if cap.AndroidSDKRoot() != "" {
+		actions = append(actions, createAVDAction())
+	}
+	if _, err := exec.LookPath("xcrun"); err == nil {
+		actions = append(actions, createSimulatorAction())
+	}
+	if _, err := exec.LookPath("adb"); err == nil {
+		actions = append(actions, m.wirelessActions(dev)...)
+	}
+	return actions
+}
+
+// avdActions are the actions for an Android emulator. Offline emulators are
+// listed by AVD name; running ones by adb serial with the AVD as their name.
+func avdActions(dev *device.Device) []deviceAction {
+	avd := dev.Name
+	if !dev.Online {
+		actions := []deviceAction{
+			{name: "Quick boot", description: "Start from the quick-boot snapshot", run: func(m *Model, _ []string) tea.Cmd {
+				return m.bootAVD(avd, cap.AVDBootOptions{})
+			}},
+			{name: "Cold boot", description: "Start without loading the quick-boot snapshot", run: func(m *Model, _ []string) tea.Cmd {
+				return m.bootAVD(avd, cap.AVDBootOptions{ColdBoot: true})
+			}},
+			{name: "Boot from snapshot", description: "Start from a saved snapshot",
+				fields: []formField{{label: "Snapshot", choices: func([]string) ([]string, error) {
+					info, err := cap.FindAVD(avd)
+					if err != nil {
+						return nil, err
+					}
+					if len(info.Snapshots) == 0 {
+						return nil, fmt.Errorf("%s has no saved snapshots", avd)
+					}
+					return info.Snapshots, nil
+				}}},
+				run: func(m *Model, values []string) tea.Cmd {
+					return m.bootAVD(avd, cap.AVDBootOptions{Snapshot: values[0]})
+				}},
+			{name: "Wipe data", description: "Factory reset the emulator and boot it", dangerous: true, run: func(m *Model, _ []string) tea.Cmd {
+				return m.bootAVD(avd, cap.AVDBootOptions{WipeData: true})
+			}},
+			{name: "Clone", description: "Copy this AVD under a new name",
+				fields: []formField{{label: "Name", value: avd + "_copy"}},
+				run: func(m *Model, values []string) tea.Cmd {
+					return m.deviceOp("Clone "+avd, "clone AVD "+avd+" → "+values[0], func() error {
+						return cap.CloneAVD(avd, values[0])
+					})
+				}},
+			{name: "Delete", description: "Delete this AVD and its data", dangerous: true, run: func(m *Model, _ []string) tea.Cmd {
+				return m.deviceTask("Delete "+avd, "avdmanager delete avd -n "+avd, func(opts cap.ExecOptions) (*cap.Execution, error) {
+					return cap.DeleteAVD(context.Background(), avd, opts)
+				})
+			}},
+		}
+		return actions
+	}
+
+	serial := dev.ID
+	return []deviceAction{
+		{name: "Save snapshot", description: "Save the emulator's current state",
+			fields: []formField{{label: "Snapshot name", value: "snap_" + time.Now().Format("20060102_150405")}},
+			run: func(m *Model, values []string) tea.Cmd {
+				return m.deviceTask("Snapshot "+avd, "adb -s "+serial+" emu avd snapshot save "+values[0], func(opts cap.ExecOptions) (*cap.Execution, error) {
+					return cap.SaveEmulatorSnapshot(context.Background(), serial, values[0], opts)
+				})
+			}},
+		{name: "Stop", description: "Shut down the emulator", run: func(m *Model, _ []string) tea.Cmd {
+			return m.deviceTask("Stop "+avd, "adb -s "+serial+" emu kill", func(opts cap.ExecOptions) (*cap.Execution, error) {
+				return cap.StopEmulator(context.Background(), serial, opts)
+			})
+		}},
+	}
+}
+
+// createAVDAction creates an AVD from an installed system image
+func createAVDAction() deviceAction {
+	images := map[string]cap.SystemImage{}
+	return deviceAction{
+		name:        "Create AVD",
+		description: "New emulator from an installed system image",
+		fields: []formField{
+			{label: "System image", choices: func([]string) ([]string, error) {
+				list, err := cap.ListSystemImages()
+				if err != nil {
+					return nil, err
+				}
+				if len(list) == 0 {
+					return nil, fmt.Errorf("no system images installed (sdkmanager \"system-images;android-34;google_apis;arm64-v8a\")")
+				}
+				choices := make([]string, len(list))
+				for i, img := range list {
+					choices[i] = img.Package
+					images[img.Package] = img
+				}
+				return choices, nil
+			}},
+			{label: "Hardware profile", choices: func([]string) ([]string, error) {
+				return cap.ListDeviceProfiles()
+			}},
+			{label: "Name"},
+		},
+		run: func(m *Model, values []string) tea.Cmd {
+			image, profile, name := values[0], values[1], values[2]
+			if name == "" {
+				name = fmt.Sprintf("%s_API_%s", profile, images[image].APILevel)
+			}
+			return m.deviceTask("Create "+name, fmt.Sprintf("avdmanager create avd -n %s -k %s -d %s", name, image, profile), func(opts cap.ExecOptions) (*cap.Execution, error) {
+				return cap.CreateAVD(context.Background(), name, image, profile, opts)
+			})
+		},
+	}
+}
+
+// simulatorActions are the actions for an iOS simulator. Appearance, locale,
+// status bar and location need it booted; cloning needs it shut down.
+func simulatorActions(dev *device.Device) []deviceAction {
+	udid, name := dev.ID, dev.Name
+	simOp := func(m *Model, title, command string, fn func() error) tea.Cmd {
+		return m.deviceOp(title+" "+name, "xcrun simctl "+command, fn)
+	}
+	rename := deviceAction{name: "Rename", description: "Give the simulator a new name",
+		fields: []formField{{label: "Name", value: name}},
+		run: func(m *Model, values []string) tea.Cmd {
+			return simOp(m, "Rename", "rename "+udid+" "+values[0], func() error {
+				return cap.RenameSimulator(udid, values[0])
+			})
+		}}
+	erase := deviceAction{name: "Erase", description: "Erase all content and settings", dangerous: true, run: func(m *Model, _ []string) tea.Cmd {
+		return simOp(m, "Erase", "erase "+udid, func() error {
+			return cap.EraseSimulator(udid)
+		})
+	}}
+	remove := deviceAction{name: "Delete", description: "Delete the simulator and its data", dangerous: true, run: func(m *Model, _ []string) tea.Cmd {
+		return simOp(m, "Delete", "delete "+udid, func() error {
+			return cap.DeleteSimulator(udid)
+		})
+	}}
+
+	if !dev.Online {
+		return []deviceAction{
+			{name: "Boot", description: "Start the simulator", run: func(m *Model, _ []string) tea.Cmd {
+				return simOp(m, "Boot", "boot "+udid, func() error {
+					return cap.BootDevice(udid, "ios", true)
+				})
+			}},
+			{name: "Clone", description: "Copy the simulator with its apps and data",
+				fields: []formField{{label: "Name", value: name + " Copy"}},
+				run: func(m *Model, values []string) tea.Cmd {
+					return simOp(m, "Clone", "clone "+udid+" "+values[0], func() error {
+						_, err := cap.CloneSimulator(udid, values[0])
+						return err
+					})
+				}},
+			rename,
+			erase,
+			remove,
+		}
+	}
+
+	return []deviceAction{
+		{name: "Appearance", description: "Switch between dark and light mode",
+			fields: []formField{{label: "Appearance", choices: func([]string) ([]string, error) {
+				return []string{"dark", "light"}, nil
+			}}},
+			run: func(m *Model, values []string) tea.Cmd {
+				return simOp(m, "Appearance", "ui "+udid+" appearance "+values[0], func() error {
+					return cap.SetSimulatorAppearance(udid, values[0])
+				})
+			}},
+		{name: "Locale", description: "Set language and region (restarts the simulator)",
+			fields: []formField{{label: "Locale", value: "en_US"}},
+			run: func(m *Model, values []string) tea.Cmd {
+				return simOp(m, "Locale", "spawn "+udid+" defaults write \"Apple Global Domain\" AppleLocale "+values[0], func() error {
+					return cap.SetSimulatorLocale(udid, values[0])
+				})
+			}},
+		{name: "Status bar", description: "Override the status bar for screenshots",
+			fields: []formField{{label: "Status bar", choices: func([]string) ([]string, error) {
+				return []string{"clean (9:41, full battery and signal)", "clear overrides"}, nil
+			}}},
+			run: func(m *Model, values []string) tea.Cmd {
+				if strings.HasPrefix(values[0], "clear") {
+					return simOp(m, "Status bar", "status_bar "+udid+" clear", func() error {
+						return cap.ClearStatusBar(udid)
+					})
+				}
+				command := "status_bar " + udid + " override " + strings.Join(cap.StatusBarArgs(cap.CleanStatusBar), " ")
+				return simOp(m, "Status bar", command, func() error {
+					return cap.OverrideStatusBar(udid, cap.CleanStatusBar)
+				})
+			}},
+		{name: "Location", description: "Simulate a location (empty to clear)",
+			fields: []formField{{label: "Latitude,longitude", value: "37.3349,-122.0090"}},
+			run: func(m *Model, values []string) tea.Cmd {
+				if values[0] == "" {
+					return simOp(m, "Location", "location "+udid+" clear", func() error {
+						return cap.ClearSimulatorLocation(udid)
+					})
+				}
+				return simOp(m, "Location", "location "+udid+" set "+values[0], func() error {
+					return cap.SetSimulatorLocation(udid, values[0])
+				})
+			}},
+		{name: "Shutdown", description: "Shut down the simulator", run: func(m *Model, _ []string) tea.Cmd {
+			return simOp(m, "Shutdown", "shutdown "+udid, func() error {
+				return cap.ShutdownSimulator(udid)
+			})
+		}},
+		rename,
+		erase,
+		remove,
+	}
+}
+
+// createSimulatorAction creates a simulator from an installed runtime and
+// one of the device types it supports
+func createSimulatorAction() deviceAction {
+	runtimes := map[string]*cap.SimRuntime{}
+	types := map[string]cap.SimDeviceType{}
+	return deviceAction{
+		name:        "Create simulator",
+		description: "New iOS simulator from an installed runtime",
+		fields: []formField{
+			{label: "Runtime", choices: func([]string) ([]string, error) {
+				list, err := cap.ListSimRuntimes()
+				if err != nil {
+					return nil, err
+				}
+				if len(list) == 0 {
+					return nil, fmt.Errorf("no iOS simulator runtimes installed (Xcode > Settings > Platforms)")
+				}
+				choices := make([]string, len(list))
+				for i := range list {
+					choices[i] = list[i].Name
+					runtimes[list[i].Name] = &list[i]
+				}
+				return choices, nil
+			}},
+			{label: "Device type", choices: func(values []string) ([]string, error) {
+				list, err := cap.ListSimDeviceTypes(runtimes[values[0]])
+				if err != nil {
+					return nil, err
+				}
+				// Newest models first
+				choices := make([]string, 0, len(list))
+				for i := len(list) - 1; i >= 0; i-- {
+					choices = append(choices, list[i].Name)
+					types[list[i].Name] = list[i]
+				}
+				return choices, nil
+			}},
+			{label: "Name"},
+		},
+		run: func(m *Model, values []string) tea.Cmd {
+			runtime, deviceType, name := runtimes[values[0]], types[values[1]], values[2]
+			if name == "" {
+				name = deviceType.Name
+			}
+			command := fmt.Sprintf("xcrun simctl create %q %s %s", name, deviceType.Identifier, runtime.Identifier)
+			return m.deviceOp("Create "+name, command, func() error {
+				_, err := cap.CreateSimulator(name, deviceType.Identifier, runtime.Identifier)
+				return err
+			})
+		},
+	}
+}
+
+// wirelessActions disconnect or forget the selected wireless device, then
+// reconnect to known wireless devices and pair with or connect to new ones
+func (m *Model) wirelessActions(dev *device.Device) []deviceAction {
+	var actions []deviceAction
+	if dev.Platform == "android" && dev.IsNetwork() && dev.Address != "" {
+		address := dev.Address
+		actions = append(actions,
+			deviceAction{name: "Disconnect", description: "Disconnect from " + address, run: func(m *Model, _ []string) tea.Cmd {
+				return m.deviceOp("Disconnect "+dev.Name, "adb disconnect "+address, func() error {
+					return cap.DisconnectADB(context.Background(), address)
+				})
+			}},
+			deviceAction{name: "Forget", description: "Stop reconnecting to " + address + " at startup", run: func(m *Model, _ []string) tea.Cmd {
+				m.settings.ForgetWirelessDevice(address)
+				_ = m.settings.Save()
+				m.setStatus("Forgot " + address)
+				return nil
+			}})
+	}
+
+	connected := map[string]bool{}
+	for _, d := range m.devices {
+		if d.Address != "" {
+			connected[d.Address] = true
+		}
+	}
+	for _, known := range m.settings.WirelessDevices {
+		if connected[known.Address] {
+			continue
+		}
+		address := known.Address
+		actions = append(actions, deviceAction{name: "Reconnect", description: known.Name + " at " + address, run: func(m *Model, _ []string) tea.Cmd {
+			return m.connectWireless("", "", address)
+		}})
+	}
+
+	return append(actions,
+		deviceAction{name: "Pair over Wi-Fi", description: "Android 11+: Wireless debugging > Pair device with pairing code",
+			fields: []formField{
+				{label: "Pairing IP address & port"},
+				{label: "Pairing code"},
+				{label: "IP address & port (on the Wireless debugging screen)"},
+			},
+			run: func(m *Model, values []string) tea.Cmd {
+				return m.connectWireless(values[0], values[1], values[2])
+			}},
+		deviceAction{name: "Connect over Wi-Fi", description: "adb connect to a paired or adb tcpip device",
+			fields: []formField{{label: "IP address[:port]"}},
+			run: func(m *Model, values []string) tea.Cmd {
+				return m.connectWireless("", "", values[0])
+			}},
+	)
+}
+
+// liveReloadHostAction picks the address physical devices load the live
+// reload dev server from: detected from the device's subnet, or one of this
+// machine's network interfaces. The choice is saved as externalHost. Android
+// devices can also use adb reverse, saved as the androidLiveReload mode.
+func (m *Model) liveReloadHostAction(dev *device.Device) deviceAction {
+	const auto, reverse = "Auto", "adb reverse"
+	android := cap.CanReverse(dev)
+	current := m.settings.ExternalHost
+	description := "Address the device loads the dev server from: auto"
+	switch {
+	case android && m.settings.ADBReverse():
+		description = "Address the device loads the dev server from: localhost over adb reverse"
+	case current != "":
+		description = "Address the device loads the dev server from: " + current
+	}
+	target := *dev
+	return deviceAction{name: "Live reload host", description: description,
+		fields: []formField{{label: "Host", choices: func([]string) ([]string, error) {
+			choices := []string{auto}
+			if host, reason := cap.DetectLANHost(&target); host != "" {
+				choices[0] = fmt.Sprintf("%s: %s %s", auto, host, reason)
+			}
+			if android {
+				choices = append(choices, reverse+": localhost, forwarded over USB or Wi-Fi adb")
+			}
+			found := current == ""
+			for _, iface := range cap.LANInterfaces() {
+				choices = append(choices, iface.String())
+				found = found || iface.IP == current
+			}
+			if !found {
+				choices = append(choices, current)
+			}
+			return choices, nil
+		}}},
+		run: func(m *Model, values []string) tea.Cmd {
+			if strings.HasPrefix(values[0], reverse) {
+				m.settings.AndroidLiveReload = "reverse"
+				_ = m.settings.Save()
+				m.setStatus("Live reload on Android: adb reverse to localhost")
+				return nil
+			}
+			host := ""
+			if !strings.HasPrefix(values[0], auto) {
+				host = strings.Fields(values[0])[0]
+			}
+			m.settings.ExternalHost = host
+			if android {
+				m.settings.AndroidLiveReload = "lan"
+			}
+			_ = m.settings.Save()
+			switch {
+			case m.settings.ResolvedLiveReloadHost() != host:
+				m.setStatus("Saved, but the active environment's liveReloadHost is used instead")
+			case host == "":
+				m.setStatus("Live reload host: detected per device")
+			default:
+				m.setStatus("Live reload host: " + host)
+			}
+			return nil
+		}}
+}
+
+// connectWireless pairs with a device when given a pairing code, connects to
+// it in a process tab and remembers it for reconnecting at startup
+func (m *Model) connectWireless(pairAddress, code, address string) tea.Cmd {
+	address = cap.ADBAddress(address)
+	command := "adb connect " + address
+	if code != "" {
+		command = "adb pair " + pairAddress + " && " + command
+	}
+	p := m.createProcess("Connect "+address, command)
+	p.RefreshDevices = true
+	processID := p.ID
+	return tea.Batch(func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if code != "" {
+			if err := cap.PairADB(ctx, pairAddress, code); err != nil {
+				return wirelessConnectedMsg{processID: processID, err: err}
+			}
+		}
+		if err := cap.ConnectADB(ctx, address); err != nil {
+			return wirelessConnectedMsg{processID: processID, err: err}
+		}
+		return wirelessConnectedMsg{processID: processID, name: cap.AndroidModel(address), serial: cap.AndroidSerialNumber(address), address: address}
+	}, m.spinner.Tick)
+}
+
+// bootAVD starts an emulator and waits in a process tab until it has booted
+func (m *Model) bootAVD(avd string, opts cap.AVDBootOptions) tea.Cmd {
+	command := "emulator " + strings.Join(cap.EmulatorArgs(avd, opts), " ")
+	return m.deviceOp("Boot "+avd, command, func() error {
+		if err := cap.BootAVD(avd, opts); err != nil {
+			return err
+		}
+		for i := 0; i < 180; i++ {
+			time.Sleep(time.Second)
+			if cap.IsDeviceBooted(avd, "android") {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s did not boot within 3 minutes", avd)
+	})
+}
+
+// deviceTask runs a device management command in a process tab and
+// refreshes the device list when it finishes
+func (m *Model) deviceTask(name, command string, start func(opts cap.ExecOptions) (*cap.Execution, error)) tea.Cmd {
+	p := m.createProcess(name, command)
+	p.RefreshDevices = true
+	processID := p.ID
+	return tea.Batch(func() tea.Msg {
+		e, err := start(cap.ExecOptions{})
+		if err != nil {
+			return processFinishedMsg{processID: processID, err: err}
+		}
+		return processStartedMsg{processID: processID, exec: e}
+	}, m.spinner.Tick)
+}
+
+// deviceOp runs a device management function in the background with a
+// process tab for its result, then refreshes the device list
+func (m *Model) deviceOp(name, command string, fn func() error) tea.Cmd {
+	p := m.createProcess(name, command)
+	p.RefreshDevices = true
+	processID := p.ID
+	return tea.Batch(func() tea.Msg {
+		return processFinishedMsg{processID: processID, err: fn()}
+	}, m.spinner.Tick)
+}
+
+func (m Model) handleDeviceManagerInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	dm := m.deviceManager
+	if msg.String() == "ctrl+c" {
+		m.gracefulShutdown()
+		return m, tea.Quit
+	}
+
+	if dm.action != nil {
+		return m.handleDeviceFormInput(msg)
+	}
+
+	switch msg.String() {
+	case "esc", "q", "m":
+		m.showDeviceManager = false
+		m.deviceManager = nil
+		return m, nil
+
+	case "up", "k":
+		if dm.cursor > 0 {
+			dm.cursor--
+		}
+		dm.confirm = false
+		return m, nil
+
+	case "down", "j":
+		if dm.cursor < len(dm.actions)-1 {
+			dm.cursor++
+		}
+		dm.confirm = false
+		return m, nil
+
+	case "enter", " ":
+		action := &dm.actions[dm.cursor]
+		if action.dangerous && !dm.confirm {
+			dm.confirm = true
+			return m, nil
+		}
+		dm.confirm = false
+		if len(action.fields) > 0 {
+			dm.action = action
+			dm.values = nil
+			return m, dm.startField(0)
+		}
+		m.showDeviceManager = false
+		m.deviceManager = nil
+		return m, action.run(&m, nil)
+	}
+	return m, nil
+}
+
+// startField moves the form to field i and returns the command that loads
+// its choices, which can take a while (e.g. listing system images)
+func (dm *deviceManager) startField(i int) tea.Cmd {
+	field := dm.action.fields[i]
+	dm.field = i
+	dm.choices = nil
+	dm.choice = 0
+	dm.input = field.value
+	dm.err = ""
+	dm.load++
+	dm.loading = field.choices != nil
+	if !dm.loading {
+		return nil
+	}
+	load, values := dm.load, append([]string(nil), dm.values...)
+	return func() tea.Msg {
+		choices, err := field.choices(values)
+		return fieldChoicesMsg{load: load, choices: choices, err: err}
+	}
+}
+
+// setFieldChoices applies loaded choices if they are for the current field
+func (dm *deviceManager) setFieldChoices(msg fieldChoicesMsg) {
+	if dm.action == nil || msg.load != dm.load {
+		return
+	}
+	dm.loading = false
+	dm.choices = msg.choices
+	if msg.err != nil {
+		dm.err = msg.err.Error()
+	}
+}
+
+func (m Model) handleDeviceFormInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	dm := m.deviceManager
+	field := dm.action.fields[dm.field]
+
+	switch msg.String() {
+	case "esc":
+		dm.action = nil
+		dm.loading = false
+		return m, nil
+
+	case "up":
+		if dm.choice > 0 {
+			dm.choice--
+		}
+		return m, nil
+
+	case "down":
+		if dm.choice < len(dm.choices)-1 {
+			dm.choice++
+		}
+		return m, nil
+
+	case "backspace":
+		if field.choices == nil && len(dm.input) > 0 {
+			dm.input = dm.input[:len(dm.input)-1]
+		}
+		return m, nil
+
+	case "enter":
+		value := strings.TrimSpace(dm.input)
+		if field.choices != nil {
+			if dm.loading || len(dm.choices) == 0 {
+				return m, nil
+			}
+			value = dm.choices[dm.choice]
+		}
+		dm.values = append(dm.values, value)
+		if dm.field < len(dm.action.fields)-1 {
+			return m, dm.startField(dm.field + 1)
+		}
+		action := dm.action
+		values := dm.values
+		m.showDeviceManager = false
+		m.deviceManager = nil
+		return m, action.run(&m, values)
+
+	default:
+		if field.choices == nil && msg.Type == tea.KeyRunes {
+			dm.input += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) renderDeviceManager() string {
+	dm := m.deviceManager
+	title := lipgloss.NewStyle().
+		Foreground(capBlue).
+		Bold(true).
+		Render("  📱 Manage " + dm.device.Name)
+
+	var lines []string
+	lines = append(lines, "")
+	lines = append(lines, title)
+	lines = append(lines, "")
+	for _, detail := range deviceDetails(&dm.device) {
+		lines = append(lines, mutedStyle.Render("  "+detail))
+	}
+	lines = append(lines, "")
+
+	if dm.action != nil {
+		lines = append(lines, m.renderDeviceForm()...)
+		return strings.Join(lines, "\n")
+	}
+
+	for i, action := range dm.actions {
+		desc := mutedStyle.Render(action.description)
+		if action.dangerous {
+			desc = errorStyle.Render("⚠ ") + desc
+		}
+		if i == dm.cursor {
+			arrow := lipgloss.NewStyle().Foreground(capBlue).Bold(true).Render("▶")
+			name := lipgloss.NewStyle().Foreground(capCyan).Bold(true).Width(22).Render(action.name)
+			lines = append(lines, fmt.Sprintf(" %s %s %s", arrow, name, desc))
+		} else {
+			lines = append(lines, fmt.Sprintf("   %s %s", lipgloss.NewStyle().Width(22).Render(action.name), desc))
+		}
+	}
+
+	lines = append(lines, "")
+	if dm.confirm {
+		lines = append(lines, errorStyle.Render(fmt.Sprintf("  Press enter again to %s", strings.ToLower(dm.actions[dm.cursor].name))))
+		lines = append(lines, "")
+	}
+	lines = append(lines, helpStyle.Render("  "+
+		helpKeyStyle.Render("↑/↓")+" navigate  "+
+		helpKeyStyle.Render("enter")+" run  "+
+		helpKeyStyle.Render("esc")+" close"))
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) renderDeviceForm() []string {
+	dm := m.deviceManager
+	var lines []string
+	lines = append(lines, "  "+lipgloss.NewStyle().Foreground(capCyan).Bold(true).Render(dm.action.name))
+	for i, value := range dm.values {
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("  %s: %s", dm.action.fields[i].label, value)))
+	}
+	lines = append(lines, "")
+
+	field := dm.action.fields[dm.field]
+	lines = append(lines, "  "+field.label+":")
+	switch {
+	case dm.loading:
+		lines = append(lines, "  "+m.spinnerView()+mutedStyle.Render(" Loading..."))
+	case dm.err != "":
+		lines = append(lines, "  "+errorStyle.Render(dm.err))
+	case field.choices != nil:
+		// Keep the cursor in a window of 12 choices
+		start := 0
+		if dm.choice >= 12 {
+			start = dm.choice - 11
+		}
+		for i := start; i < len(dm.choices) && i < start+12; i++ {
+			if i == dm.choice {
+				arrow := lipgloss.NewStyle().Foreground(capBlue).Bold(true).Render("▶")
+				lines = append(lines, fmt.Sprintf(" %s %s", arrow, lipgloss.NewStyle().Foreground(capCyan).Render(dm.choices[i])))
+			} else {
+				lines = append(lines, "   "+dm.choices[i])
+			}
+		}
+		if len(dm.choices) > 12 {
+			lines = append(lines, mutedStyle.Render(fmt.Sprintf("   (%d/%d)", dm.choice+1, len(dm.choices))))
+		}
+	default:
+		lines = append(lines, "  "+lipgloss.NewStyle().Foreground(capLight).Render(dm.input+"█"))
+	}
+
+	lines = append(lines, "")
+	help := "  "
+	if field.choices != nil {
+		help += helpKeyStyle.Render("↑/↓") + " choose  "
+	} else {
+		help += helpKeyStyle.Render("type") + " to edit  "
+	}
+	help += helpKeyStyle.Render("enter") + " next  " + helpKeyStyle.Render("esc") + " back"
+	lines = append(lines, helpStyle.Render(help))
+	return lines
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeviceFormLoadsChoicesInBackground(t *testing.T) {
+	calls := 0
+	dm := &deviceManager{action: &deviceAction{fields: []formField{
+		{label: "Image", choices: func([]string) ([]string, error) {
+			calls++
+			return []string{"a", "b"}, nil
+		}},
+		{label: "Name", value: "Pixel"},
+	}}}
+
+	cmd := dm.startField(0)
+	if calls != 0 || !dm.loading || cmd == nil {
+		t.Fatalf("startField loaded choices in place: calls=%d loading=%v", calls, dm.loading)
+	}
+	msg := cmd().(fieldChoicesMsg)
+
+	// A result for a field the form has left is dropped
+	stale := msg
+	stale.load--
+	dm.setFieldChoices(stale)
+	if !dm.loading || dm.choices != nil {
+		t.Fatalf("stale choices applied: %q", dm.choices)
+	}
+
+	dm.setFieldChoices(msg)
+	if dm.loading || !reflect.DeepEqual(dm.choices, []string{"a", "b"}) {
+		t.Errorf("choices = %q, loading = %v", dm.choices, dm.loading)
+	}
+
+	if cmd := dm.startField(1); cmd != nil || dm.loading || dm.input != "Pixel" {
+		t.Errorf("text field: cmd=%v loading=%v input=%q", cmd != nil, dm.loading, dm.input)
+	}
+}
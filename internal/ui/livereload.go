@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/icarus-itcs/lazycap/internal/cap"
+	"github.com/icarus-itcs/lazycap/internal/device"
+	"github.com/icarus-itcs/lazycap/internal/settings"
+)
+
+// devServerName is the process tab of a live reload dev server
+const devServerName = "Dev server"
+
+// liveReload is a live reload run waiting for its dev server to listen
+type liveReload struct {
+	runID string
+	lr    *cap.LiveReload
+	opts  cap.ExecOptions
+}
+
+// devServerReadyMsg says the dev server of a live reload run is listening
+// and reachable at its run host
+type devServerReadyMsg struct {
+	processID string // The run
+	lr        *cap.LiveReload
+	opts      cap.ExecOptions
+}
+
+// startLiveReload starts the project's dev server with the host and port
+// flags its framework takes, waits for it to listen and then runs the app
+// against it. The run is the parent of the dev server: when either ends,
+// the other is stopped. cap.LiveReload decides how the device reaches the
+// dev server: over the network, or adb reverse, and over HTTPS with webHttps.
+func (m *Model) startLiveReload(dev *device.Device, run cap.RunOptions, name string) tea.Cmd {
+	opts := m.execOptions(m.getProjectDir(), 0)
+	lr, err := cap.NewLiveReload(m.getProjectDir(), dev, run, cap.LiveReloadOptions{
+		DevCommand: m.settings.WebDevCommand,
+		ADBReverse: m.settings.ADBReverse(),
+		HTTPS:      m.settings.WebHttps,
+		HostHint:   "choose another host with m > Live reload host",
+	})
+	if err != nil {
+		m.setStatus("Live reload: " + err.Error())
+		return nil
+	}
+
+	return m.startPipeline("run", settings.HookPreRun, settings.HookPostRun, func(m *Model) (*Process, tea.Cmd) {
+		m.clearPreviousRuns(devServerName)
+		m.clearPreviousRuns(name)
+
+		srv := m.createProcess(devServerName, shellJoin(lr.Argv))
+		// Kill any existing process on the port first
+		if cap.KillPort(lr.Run.Port) {
+			srv.AddLog(fmt.Sprintf("Killed existing process on port %d", lr.Run.Port))
+		}
+
+		p := m.createProcess(name, shellJoin(m.packageManager().Exec(cap.RunArgs(dev.Platform, dev.ID, lr.Run)...)))
+		p.Platform = dev.Platform
+		p.Children = []string{srv.ID}
+		srv.Parent = p.ID
+		p.AddLog(fmt.Sprintf("Waiting for %s on port %d...", lr.Server.String(), lr.Run.Port))
+
+		m.liveReloads[srv.ID] = &liveReload{runID: p.ID, lr: lr, opts: opts}
+		serverOpts := opts
+		serverOpts.Env = append(append([]string{}, opts.Env...), lr.Env...)
+		return p, m.runCmd(srv.ID, serverOpts, lr.Argv[0], lr.Argv[1:]...)
+	})
+}
+
+// devServerStarted waits in the background for a live reload dev server that
+// just started to be ready for its run. A web dev server behind a TLS proxy
+// gets the proxy started once it listens.
+func (m *Model) devServerStarted(msg processStartedMsg) tea.Cmd {
+	proxy := m.tlsProxies[msg.processID]
+	delete(m.tlsProxies, msg.processID)
+	live, ok := m.liveReloads[msg.processID]
+	if !ok {
+		if proxy == nil {
+			return nil
+		}
+		return func() tea.Msg {
+			if err := proxy.Serve(msg.exec); err != nil {
+				msg.exec.Cancel()
+				return processFinishedMsg{processID: msg.processID, err: err}
+			}
+			return nil
+		}
+	}
+	delete(m.liveReloads, msg.processID)
+	return func() tea.Msg {
+		if err := live.lr.Ready(msg.exec); err != nil {
+			return processFinishedMsg{processID: live.runID, err: err}
+		}
+		return devServerReadyMsg{processID: live.runID, lr: live.lr, opts: live.opts}
+	}
+}
+
+// devServerReady runs the app against the dev server once it is reachable
+func (m *Model) devServerReady(msg devServerReadyMsg) tea.Cmd {
+	lr := msg.lr
+	p := m.findProcess(msg.processID)
+	if p == nil || p.Status != ProcessRunning || p.Exec != nil {
+		if lr.Reverse != nil {
+			// The run was stopped while the forward was set up
+			return func() tea.Msg {
+				_ = lr.Reverse.Remove()
+				return nil
+			}
+		}
+		return nil
+	}
+	if lr.Run.Https {
+		p.AddLog(fmt.Sprintf("Dev server ready on port %d over HTTPS", lr.Run.Port))
+	} else {
+		p.AddLog(fmt.Sprintf("Dev server ready on port %d", lr.Run.Port))
+	}
+	if lr.Reverse != nil {
+		m.adbReverses[p.ID] = *lr.Reverse
+		p.AddLog(fmt.Sprintf("Forwarding %s on %s to this machine with adb reverse", lr.Reverse, lr.Device.Name))
+	}
+	if lr.HostReason != "" {
+		p.AddLog(fmt.Sprintf("Live reload host %s: %s", lr.Run.Host, lr.HostReason))
+	}
+	argv := m.packageManager().Exec(cap.RunArgs(lr.Device.Platform, lr.Device.ID, lr.Run)...)
+	p.Command = shellJoin(argv)
+	p.AddLog(fmt.Sprintf("[%s] $ %s", time.Now().Format("15:04:05"), p.Command))
+	return m.runCmd(p.ID, msg.opts, argv[0], argv[1:]...)
+}
+
+// stopLinked stops the running parent and children of a process that ended
+// or was removed, and tears down the adb reverse forward of their run.
+// Those that haven't started, like a run still waiting for its dev server,
+// are marked canceled.
+func (m *Model) stopLinked(p *Process) {
+	delete(m.liveReloads, p.ID)
+	delete(m.tlsProxies, p.ID)
+	m.removeADBReverse(p.ID)
+	m.removeADBReverse(p.Parent)
+	for _, id := range append([]string{p.Parent}, p.Children...) {
+		linked := m.findProcess(id)
+		if linked == nil || linked.Status != ProcessRunning {
+			continue
+		}
+		if linked.Exec != nil {
+			linked.Exec.Cancel()
+			continue
+		}
+		delete(m.liveReloads, linked.ID)
+		m.dropPipeline(linked.ID)
+		linked.Status = ProcessCancelled
+		linked.EndTime = time.Now()
+		linked.AddLog(fmt.Sprintf("○ Canceled: %s stopped", p.Name))
+	}
+}
+
+// removeADBReverse tears down the adb reverse forward of a live reload run in
+// the background
+func (m *Model) removeADBReverse(processID string) {
+	r, ok := m.adbReverses[processID]
+	if !ok {
+		return
+	}
+	delete(m.adbReverses, processID)
+	go func() {
+		_ = r.Remove()
+	}()
+}
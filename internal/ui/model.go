@@ -1,7 +1,8 @@
 package ui
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -25,6 +26,7 @@ import (
 	"github.com/icarus-itcs/lazycap/internal/plugin"
 	"github.com/icarus-itcs/lazycap/internal/preflight"
 	"github.com/icarus-itcs/lazycap/internal/settings"
+	"github.com/icarus-itcs/lazycap/internal/shellenv"
 	"github.com/icarus-itcs/lazycap/internal/update"
 )
 
@@ -104,14 +106,21 @@ type Model struct {
 	projectCursor       int
 
 	// Devices
-	devices        []device.Device
-	selectedDevice int
+	devices         []device.Device
+	selectedDevice  int
+	devicesLoaded   bool                   // First device list has arrived
+	deviceUpdates   <-chan []device.Device // Lists from the device watcher
+	stopDeviceWatch context.CancelFunc
 
 	// Processes (tabs above logs)
 	processes       []*Process
 	selectedProcess int
 	nextProcessID   int
-	outputChans     map[string]chan string
+	pluginActions   chan pluginActionMsg
+	pipelines       map[string]*pipeline      // Hook pipelines by the process ID of their current step
+	liveReloads     map[string]*liveReload    // Live reload runs by the process ID of their dev server
+	adbReverses     map[string]cap.ADBReverse // adb reverse forwards by the process ID of their run
+	tlsProxies      map[string]*cap.TLSProxy  // HTTPS proxies waiting for the dev server with this process ID
 
 	// Preflight checks
 	preflightResults *preflight.Results
@@ -151,6 +160,9 @@ type Model struct {
 	confirmQuit bool
 	quitTime    time.Time
 
+	// Kill confirmation (process ID awaiting a second press)
+	confirmKill string
+
 	// Debug panel
 	showDebug       bool
 	debugActions    []debug.Action
@@ -160,6 +172,27 @@ type Model struct {
 	debugResult     *debug.Result
 	debugResultTime time.Time
 
+	// Capacitor plugin inventory panel
+	showPluginReport bool
+	pluginReport     *cap.PluginReport
+	pluginReportErr  error
+
+	// Capacitor upgrade assistant
+	showMigration    bool
+	migration        *cap.Migration
+	migrating        bool
+	migrationProcess string // Process tab that collects step output
+
+	// Device manager panel
+	showDeviceManager bool
+	deviceManager     *deviceManager
+
+	// Version bump panel
+	showVersionBump bool
+	versionCursor   int
+	versionTag      bool              // Commit and tag after bumping
+	versionPlans    []versionBumpPlan // One per cap.BumpParts, planned when the panel opens
+
 	// Memory tracking
 	memoryUsage uint64 // Total memory in bytes (lazycap + child processes)
 }
@@ -188,6 +221,10 @@ type keyMap struct {
 	Plugins    key.Binding
 	Enter      key.Binding
 	Workspace  key.Binding
+	Env        key.Binding
+	Version    key.Binding
+	CapPlugins key.Binding
+	Manage     key.Binding
 }
 
 func defaultKeyMap() keyMap {
@@ -215,6 +252,10 @@ func defaultKeyMap() keyMap {
 		Plugins:    key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "plugins")),
 		Enter:      key.NewBinding(key.WithKeys("enter", " "), key.WithHelp("enter", "toggle")),
 		Workspace:  key.NewBinding(key.WithKeys("W"), key.WithHelp("W", "projects")),
+		Env:        key.NewBinding(key.WithKeys("E"), key.WithHelp("E", "environment")),
+		Version:    key.NewBinding(key.WithKeys("V"), key.WithHelp("V", "version bump")),
+		CapPlugins: key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "cap plugins")),
+		Manage:     key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "manage device")),
 	}
 }
 
@@ -226,7 +267,8 @@ func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Tab},
 		{k.Run, k.Sync, k.Build},
-		{k.Open, k.Kill, k.Refresh},
+		{k.Open, k.Kill, k.Refresh, k.Manage},
+		{k.Env, k.Version, k.CapPlugins},
 		{k.Help, k.Quit},
 	}
 }
@@ -264,8 +306,9 @@ func NewModelWithPlugins(project *cap.Project, pluginMgr *plugin.Manager, appCtx
 	// Run preflight checks
 	preflightResults := preflight.Run()
 
-	// Load settings
+	// Load settings; tool paths apply to device discovery as well as processes
 	userSettings, _ := settings.Load()
+	userSettings.ApplyToolEnv()
 
 	m := Model{
 		project:          project,
@@ -277,7 +320,11 @@ func NewModelWithPlugins(project *cap.Project, pluginMgr *plugin.Manager, appCtx
 		keys:             defaultKeyMap(),
 		loading:          true,
 		processes:        make([]*Process, 0),
-		outputChans:      make(map[string]chan string),
+		pluginActions:    make(chan pluginActionMsg),
+		pipelines:        make(map[string]*pipeline),
+		liveReloads:      make(map[string]*liveReload),
+		adbReverses:      make(map[string]cap.ADBReverse),
+		tlsProxies:       make(map[string]*cap.TLSProxy),
 		nextProcessID:    1,
 		preflightResults: preflightResults,
 		showPreflight:    preflightResults.HasErrors, // Show automatically if errors
@@ -286,8 +333,18 @@ func NewModelWithPlugins(project *cap.Project, pluginMgr *plugin.Manager, appCtx
 		pluginContext:    appCtx,
 	}
 
-	// Set up plugin context callbacks if plugins are enabled
+	// Keep the device list current as devices come and go
+	if userSettings.WatchDevices {
+		ctx, cancel := context.WithCancel(context.Background())
+		m.deviceUpdates = cap.WatchDevices(ctx, 2*time.Second)
+		m.stopDeviceWatch = cancel
+	}
+
+	// Set up plugin context callbacks if plugins are enabled.
+	// Actions go through a channel because the callbacks outlive this copy of
+	// the model; Update starts the process and replies with its handle.
 	if appCtx != nil {
+		actions := m.pluginActions
 		appCtx.SetSettings(userSettings)
 		appCtx.SetCallbacks(
 			// GetDevices
@@ -296,36 +353,33 @@ func NewModelWithPlugins(project *cap.Project, pluginMgr *plugin.Manager, appCtx
 			func() *device.Device { return m.getSelectedDevice() },
 			// RefreshDevices
 			func() error {
-				// Trigger device refresh - this is called from plugins
-				return nil
+				_, err := requestPluginAction(actions, pluginActionMsg{action: "refresh"})
+				return err
 			},
 			// RunOnDevice
-			func(deviceID string, liveReload bool) error {
-				for _, d := range m.devices {
-					if d.ID == deviceID {
-						// This would need to integrate with the tea.Cmd system
-						return nil
-					}
-				}
-				return fmt.Errorf("device %s not found", deviceID)
+			func(deviceID string, liveReload bool) (*plugin.ProcessHandle, error) {
+				return requestPluginAction(actions, pluginActionMsg{action: "run", deviceID: deviceID, liveReload: liveReload})
 			},
 			// RunWeb
-			func() error { return nil },
+			func() (*plugin.ProcessHandle, error) {
+				return requestPluginAction(actions, pluginActionMsg{action: "web"})
+			},
 			// Sync
-			func(platform string) error { return nil },
+			func(platform string) (*plugin.ProcessHandle, error) {
+				return requestPluginAction(actions, pluginActionMsg{action: "sync", platform: platform})
+			},
 			// Build
-			func() error { return nil },
+			func() (*plugin.ProcessHandle, error) {
+				return requestPluginAction(actions, pluginActionMsg{action: "build"})
+			},
 			// OpenIDE
-			func(platform string) error { return nil },
+			func(platform string) (*plugin.ProcessHandle, error) {
+				return requestPluginAction(actions, pluginActionMsg{action: "open", platform: platform})
+			},
 			// KillProcess
 			func(processID string) error {
-				for _, p := range m.processes {
-					if p.ID == processID && p.Status == ProcessRunning && p.Cmd != nil && p.Cmd.Process != nil {
-						_ = p.Cmd.Process.Kill()
-						return nil
-					}
-				}
-				return fmt.Errorf("process %s not found or not running", processID)
+				_, err := requestPluginAction(actions, pluginActionMsg{action: "kill", processID: processID})
+				return err
 			},
 			// GetProcesses
 			func() []plugin.ProcessInfo {
@@ -377,6 +431,10 @@ func NewModelWithPlugins(project *cap.Project, pluginMgr *plugin.Manager, appCtx
 func NewDemoModel(project *cap.Project, pluginMgr *plugin.Manager, appCtx *plugin.AppContext, version string) Model {
 	m := NewModelWithPlugins(project, pluginMgr, appCtx, version)
 	m.loading = false
+	if m.stopDeviceWatch != nil {
+		m.stopDeviceWatch()
+		m.deviceUpdates = nil
+	}
 
 	// Mock devices - mix of physical devices, emulators, and web
 	m.devices = []device.Device{
@@ -587,12 +645,24 @@ func NewDemoModel(project *cap.Project, pluginMgr *plugin.Manager, appCtx *plugi
 
 // Messages
 type devicesLoadedMsg struct{ devices []device.Device }
+type devicesChangedMsg struct{ devices []device.Device }
 type upgradeCheckedMsg struct{ info *cap.UpgradeInfo }
+type versionBumpedMsg struct {
+	processID string
+	bump      *cap.VersionBump
+	tag       string
+	tagErr    error
+	err       error
+}
+type migrationStepMsg struct {
+	migration *cap.Migration // The migration the step ran on, replacing the model's
+	step      *cap.MigrationStep
+	err       error
+}
 type errMsg struct{ err error }
 type processStartedMsg struct {
-	processID  string
-	cmd        *exec.Cmd
-	outputChan chan string
+	processID string
+	exec      *cap.Execution
 }
 type processOutputMsg struct {
 	processID string
@@ -602,12 +672,36 @@ type processFinishedMsg struct {
 	processID string
 	err       error
 }
+type wirelessConnectedMsg struct {
+	processID string
+	name      string // Device model
+	serial    string // Hardware serial number
+	address   string
+	err       error
+}
 type deviceBootedMsg struct {
 	device     *device.Device
+	id         string // ID to run on once booted, the adb serial for emulators
 	liveReload bool
 	err        error
 }
 
+// pluginActionMsg asks the UI to start (or kill) a process for a plugin such
+// as the MCP server; the result is sent back on reply
+type pluginActionMsg struct {
+	action     string // run, web, sync, build, open, kill
+	deviceID   string
+	platform   string
+	processID  string
+	liveReload bool
+	reply      chan pluginActionResult
+}
+
+type pluginActionResult struct {
+	handle *plugin.ProcessHandle
+	err    error
+}
+
 type pluginLogMsg struct {
 	pluginID string
 	message  string
@@ -634,9 +728,35 @@ func loadDevices() tea.Msg {
 	return devicesLoadedMsg{devices}
 }
 
-func checkUpgrade() tea.Msg {
-	info, _ := cap.CheckForUpgrade()
-	return upgradeCheckedMsg{info}
+// listenForDeviceUpdates waits for the next list from the device watcher
+func listenForDeviceUpdates(updates <-chan []device.Device) tea.Cmd {
+	return func() tea.Msg {
+		devices, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return devicesChangedMsg{devices}
+	}
+}
+
+// reconnectWirelessDevices connects to the known wireless Android devices
+// and reloads the device list if any of them answered
+func reconnectWirelessDevices(addresses []string) tea.Cmd {
+	return func() tea.Msg {
+		if len(cap.ReconnectADB(addresses)) == 0 {
+			return nil
+		}
+		return loadDevices()
+	}
+}
+
+// checkUpgrade checks for a Capacitor upgrade of the current project
+func (m *Model) checkUpgrade() tea.Cmd {
+	dir := m.getProjectDir()
+	return func() tea.Msg {
+		info, _ := cap.CheckForUpgrade(dir)
+		return upgradeCheckedMsg{info}
+	}
 }
 
 func checkForUpdate(version string) tea.Cmd {
@@ -674,9 +794,8 @@ func (m *Model) calculateMemoryUsage() uint64 {
 
 	// Get memory usage of child processes (capacitor runs, builds, syncs)
 	for _, p := range m.processes {
-		if p.Status == ProcessRunning && p.Cmd != nil && p.Cmd.Process != nil {
-			pid := p.Cmd.Process.Pid
-			childMem := getProcessMemory(pid)
+		if p.Status == ProcessRunning && p.Exec != nil {
+			childMem := getProcessMemory(p.Exec.Pid())
 			totalBytes += childMem
 		}
 	}
@@ -760,6 +879,93 @@ func (m *Model) getSelectedDevice() *device.Device {
 	return &m.devices[m.selectedDevice]
 }
 
+// setDevices replaces the device list, keeping the selected device selected
+// when the list reorders, and tells plugins what changed
+func (m *Model) setDevices(devices []device.Device) {
+	var selected string
+	if dev := m.getSelectedDevice(); dev != nil {
+		selected = dev.Key()
+	}
+	changes := device.Diff(m.devices, devices)
+	m.devices = devices
+
+	for i := range devices {
+		if devices[i].Key() == selected {
+			m.selectedDevice = i
+			break
+		}
+	}
+	if m.selectedDevice >= len(devices) {
+		m.selectedDevice = max(len(devices)-1, 0)
+	}
+
+	if changes.Empty() {
+		return
+	}
+	if status := deviceChangeStatus(changes); status != "" {
+		m.setStatus(status)
+	}
+	if m.pluginContext != nil {
+		m.pluginContext.NotifyDevicesChanged(devices, changes)
+		for _, d := range changes.Booted {
+			m.pluginContext.NotifyDeviceBooted(d)
+		}
+	}
+}
+
+// deviceChangeStatus describes the most notable device change
+func deviceChangeStatus(c device.Changes) string {
+	switch {
+	case len(c.Booted) > 0:
+		return c.Booted[0].Name + " booted"
+	case len(c.Added) > 0 && c.Added[0].IsEmulator:
+		return c.Added[0].Name + " added"
+	case len(c.Added) > 0:
+		return c.Added[0].Name + " connected"
+	case len(c.Removed) > 0 && c.Removed[0].IsEmulator:
+		return c.Removed[0].Name + " removed"
+	case len(c.Removed) > 0:
+		return c.Removed[0].Name + " disconnected"
+	case len(c.ShutDown) > 0:
+		return c.ShutDown[0].Name + " shut down"
+	}
+	return ""
+}
+
+// selectDefaultDevice selects the configured default simulator or Android
+// device (an ID, alias, rule or name), falling back to the first online
+// device of the default platform
+func (m *Model) selectDefaultDevice() {
+	for _, query := range m.settings.DefaultDeviceQueries() {
+		dev, err := device.Resolve(m.devices, query, m.settings.DeviceAliases)
+		if err != nil {
+			continue
+		}
+		for i := range m.devices {
+			if &m.devices[i] == dev {
+				m.selectedDevice = i
+				return
+			}
+		}
+	}
+
+	if m.settings.DefaultPlatform == "" {
+		return
+	}
+	for i, d := range m.devices {
+		if d.Platform == m.settings.DefaultPlatform && d.Online {
+			m.selectedDevice = i
+			return
+		}
+	}
+	for i, d := range m.devices {
+		if d.Platform == m.settings.DefaultPlatform {
+			m.selectedDevice = i
+			return
+		}
+	}
+}
+
 func (m *Model) getSelectedProcess() *Process {
 	if len(m.processes) == 0 || m.selectedProcess >= len(m.processes) {
 		return nil
@@ -771,11 +977,14 @@ func (m *Model) getSelectedProcess() *Process {
 func (m *Model) removeProcess(processID string) {
 	for i, p := range m.processes {
 		if p.ID == processID {
-			// Close the output channel if it exists
-			if ch, ok := m.outputChans[processID]; ok {
-				close(ch)
-				delete(m.outputChans, processID)
+			// Stop it if it's still running
+			if p.Status == ProcessRunning {
+				if p.Exec != nil {
+					p.Exec.Cancel()
+				}
+				m.stopLinked(p)
 			}
+			m.dropPipeline(processID)
 			// Remove from slice
 			m.processes = append(m.processes[:i], m.processes[i+1:]...)
 			// Adjust selected index if needed
@@ -789,6 +998,50 @@ func (m *Model) removeProcess(processID string) {
 	}
 }
 
+// clearPreviousRuns removes finished tabs from earlier runs with the same
+// name when Clear Logs on Run is enabled
+func (m *Model) clearPreviousRuns(name string) {
+	if !m.settings.ClearLogsOnRun {
+		return
+	}
+	for i := len(m.processes) - 1; i >= 0; i-- {
+		if p := m.processes[i]; p.Name == name && p.Status != ProcessRunning {
+			m.removeProcess(p.ID)
+		}
+	}
+}
+
+// pruneProcessHistory drops the oldest finished processes beyond the
+// Process History setting (0 keeps them all)
+func (m *Model) pruneProcessHistory() {
+	keep := m.settings.KeepProcessHistory
+	if keep <= 0 {
+		return
+	}
+	finished := 0
+	for _, p := range m.processes {
+		if p.Status != ProcessRunning && p.ID != "system" {
+			finished++
+		}
+	}
+	for i := 0; i < len(m.processes) && finished > keep; {
+		if p := m.processes[i]; p.Status != ProcessRunning && p.ID != "system" {
+			m.removeProcess(p.ID)
+			finished--
+			continue
+		}
+		i++
+	}
+}
+
+// spinnerView renders the running indicator, animated unless spinners are off
+func (m *Model) spinnerView() string {
+	if !m.settings.ShowSpinners {
+		return lipgloss.NewStyle().Foreground(capBlue).Render("◐")
+	}
+	return m.spinner.View()
+}
+
 func (m *Model) hasRunningProcesses() bool {
 	for _, p := range m.processes {
 		if p.Status == ProcessRunning {
@@ -798,11 +1051,11 @@ func (m *Model) hasRunningProcesses() bool {
 	return false
 }
 
-func waitForOutput(processID string, ch chan string) tea.Cmd {
+func waitForOutput(processID string, e *cap.Execution) tea.Cmd {
 	return func() tea.Msg {
-		line, ok := <-ch
+		line, ok := <-e.Lines()
 		if !ok {
-			return processFinishedMsg{processID: processID}
+			return processFinishedMsg{processID: processID, err: e.Wait()}
 		}
 		return processOutputMsg{processID: processID, line: line}
 	}
@@ -817,7 +1070,8 @@ func bootDevice(dev *device.Device, liveReload bool) tea.Cmd {
 			time.Sleep(time.Second)
 			if cap.IsDeviceBooted(dev.ID, dev.Platform) {
 				dev.Online = true
-				return deviceBootedMsg{device: dev, liveReload: liveReload}
+				dev.State = device.StateBooted
+				return deviceBootedMsg{device: dev, id: cap.BootedID(dev), liveReload: liveReload}
 			}
 		}
 		return deviceBootedMsg{device: dev, liveReload: liveReload, err: fmt.Errorf("timeout")}
@@ -846,14 +1100,45 @@ func listenForPluginLogs(ctx *plugin.AppContext) tea.Cmd {
 	}
 }
 
+// listenForPluginActions waits for the next process a plugin asks the UI to start
+func listenForPluginActions(actions chan pluginActionMsg) tea.Cmd {
+	return func() tea.Msg {
+		return <-actions
+	}
+}
+
+// requestPluginAction hands an action to the UI loop and waits for the
+// process it starts
+func requestPluginAction(actions chan pluginActionMsg, req pluginActionMsg) (*plugin.ProcessHandle, error) {
+	req.reply = make(chan pluginActionResult, 1)
+	select {
+	case actions <- req:
+	case <-time.After(5 * time.Second):
+		return nil, fmt.Errorf("lazycap is not accepting actions right now")
+	}
+	select {
+	case res := <-req.reply:
+		return res.handle, res.err
+	case <-time.After(10 * time.Minute): // Pre hooks run first
+		return nil, fmt.Errorf("timed out waiting for %s to start", req.action)
+	}
+}
+
 // gracefulShutdown kills all running processes and stops plugins
 func (m *Model) gracefulShutdown() {
+	if m.stopDeviceWatch != nil {
+		m.stopDeviceWatch()
+	}
+
 	// Stop all running processes
 	for _, p := range m.processes {
-		if p.Status == ProcessRunning && p.Cmd != nil && p.Cmd.Process != nil {
-			_ = p.Cmd.Process.Kill()
+		if p.Status == ProcessRunning && p.Exec != nil {
+			p.Exec.Cancel()
 		}
 	}
+	for _, r := range m.adbReverses {
+		_ = r.Remove()
+	}
 
 	// Record which plugins were running before stopping them
 	if m.pluginManager != nil {
@@ -868,15 +1153,23 @@ func (m *Model) gracefulShutdown() {
 func (m Model) Init() tea.Cmd {
 	cmds := []tea.Cmd{
 		loadDevices,
-		checkUpgrade,
 		checkForUpdate(m.version),
 		m.spinner.Tick,
 		setTerminalTitle(m.getTerminalTitle()),
 		scheduleMemoryUpdate(),
 	}
-	// Start listening for plugin logs if plugin context is available
+	if m.settings.CheckForUpgrades {
+		cmds = append(cmds, m.checkUpgrade())
+	}
+	// Start listening for plugin logs and actions if plugin context is available
 	if m.pluginContext != nil {
-		cmds = append(cmds, listenForPluginLogs(m.pluginContext))
+		cmds = append(cmds, listenForPluginLogs(m.pluginContext), listenForPluginActions(m.pluginActions))
+	}
+	if m.deviceUpdates != nil {
+		cmds = append(cmds, listenForDeviceUpdates(m.deviceUpdates))
+	}
+	if addresses := m.settings.WirelessAddresses(); len(addresses) > 0 {
+		cmds = append(cmds, reconnectWirelessDevices(addresses))
 	}
 	return tea.Batch(cmds...)
 }
@@ -894,6 +1187,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		if !key.Matches(msg, m.keys.Kill) {
+			m.confirmKill = ""
+		}
+
 		// Handle settings mode input
 		if m.showSettings {
 			return m.handleSettingsInput(msg)
@@ -914,6 +1211,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleProjectSelectorInput(msg)
 		}
 
+		// Handle upgrade assistant input
+		if m.showMigration {
+			return m.handleMigrationInput(msg)
+		}
+
+		// Handle version bump panel input
+		if m.showVersionBump {
+			return m.handleVersionBumpInput(msg)
+		}
+
+		// Handle plugin inventory panel input
+		if m.showPluginReport {
+			return m.handlePluginReportInput(msg)
+		}
+
+		// Handle device manager input
+		if m.showDeviceManager {
+			return m.handleDeviceManagerInput(msg)
+		}
+
 		switch {
 		case key.Matches(msg, m.keys.Quit):
 			// Check if Ctrl+C (force quit)
@@ -1009,6 +1326,47 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
+		case key.Matches(msg, m.keys.CapPlugins):
+			if m.project == nil {
+				m.setStatus("No project loaded")
+				return m, nil
+			}
+			m.showPluginReport = true
+			m.showHelp = false
+			m.showPreflight = false
+			m.pluginReport, m.pluginReportErr = cap.GetPluginReport(m.project)
+			return m, nil
+
+		case key.Matches(msg, m.keys.Version):
+			if m.project == nil {
+				m.setStatus("No project loaded")
+				return m, nil
+			}
+			m.showVersionBump = true
+			m.showHelp = false
+			m.showPreflight = false
+			m.versionCursor = 0
+			m.versionTag = false
+			m.planVersionBumps()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Manage):
+			m.openDeviceManager()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Env):
+			if len(m.settings.Environments) == 0 {
+				m.setStatus("No environments defined in settings")
+				return m, nil
+			}
+			name := m.settings.CycleEnvironment()
+			_ = m.settings.Save()
+			if name == "" {
+				name = "none"
+			}
+			m.setStatus("Environment: " + name)
+			return m, nil
+
 		case key.Matches(msg, m.keys.Tab):
 			if m.focus == FocusDevices {
 				m.focus = FocusLogs
@@ -1063,11 +1421,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.runAction("open", false)
 		case key.Matches(msg, m.keys.Refresh):
 			m.loading = true
-			return m, tea.Batch(loadDevices, checkUpgrade)
-		case key.Matches(msg, m.keys.Upgrade):
-			if m.upgradeInfo != nil && m.upgradeInfo.HasUpgrade {
-				return m, m.startUpgrade()
+			if m.settings.CheckForUpgrades {
+				return m, tea.Batch(loadDevices, m.checkUpgrade())
 			}
+			return m, loadDevices
+		case key.Matches(msg, m.keys.Upgrade):
+			m.openMigration()
+			return m, nil
 		case key.Matches(msg, m.keys.SelfUpdate):
 			if m.updateInfo != nil && m.updateInfo.UpdateAvailable && !m.updating {
 				m.updating = true
@@ -1083,11 +1443,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.Kill):
 			p := m.getSelectedProcess()
 			if p != nil {
-				// Kill the process if running
-				if p.Status == ProcessRunning && p.Cmd != nil && p.Cmd.Process != nil {
-					_ = p.Cmd.Process.Kill()
+				if p.Status == ProcessRunning && m.settings.ConfirmBeforeKill && m.confirmKill != p.ID {
+					m.confirmKill = p.ID
+					m.setStatus(fmt.Sprintf("Press x again to stop %s", p.Name))
+					return m, nil
 				}
-				// Remove the process from the list (remove the tab)
+				m.confirmKill = ""
+				// Remove the process from the list (remove the tab), stopping it if running
 				m.removeProcess(p.ID)
 				m.setStatus(fmt.Sprintf("Removed %s", p.Name))
 			}
@@ -1124,6 +1486,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+	case tea.FocusMsg:
+		if m.settings.RefreshOnFocus && !m.loading {
+			m.loading = true
+			cmds = append(cmds, loadDevices)
+		}
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -1134,13 +1502,78 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.spinner, cmd = m.spinner.Update(msg)
 		cmds = append(cmds, cmd)
 
+	case fieldChoicesMsg:
+		if m.deviceManager != nil {
+			m.deviceManager.setFieldChoices(msg)
+		}
+
 	case devicesLoadedMsg:
 		m.loading = false
-		m.devices = msg.devices
+		if !m.devicesLoaded {
+			m.devices = msg.devices
+			m.devicesLoaded = true
+			m.selectDefaultDevice()
+		} else {
+			m.setDevices(msg.devices)
+		}
 		cmds = append(cmds, setTerminalTitle(m.getTerminalTitle()))
 
+	case devicesChangedMsg:
+		// The first full load picks the default device
+		if m.devicesLoaded {
+			m.setDevices(msg.devices)
+			cmds = append(cmds, setTerminalTitle(m.getTerminalTitle()))
+		}
+		cmds = append(cmds, listenForDeviceUpdates(m.deviceUpdates))
+
 	case upgradeCheckedMsg:
 		m.upgradeInfo = msg.info
+		// Pick up a migration that failed or was interrupted last time
+		if m.migration == nil && m.project != nil {
+			if mig, err := cap.LoadMigration(m.project.RootDir); err == nil && !mig.Done() {
+				m.migration = mig
+			}
+		}
+
+	case migrationStepMsg:
+		m.migration = msg.migration
+		if msg.step != nil {
+			if p := m.findProcess(m.migrationProcess); p != nil {
+				icon := "✓"
+				switch msg.step.Status {
+				case cap.StepFailed:
+					icon = "✗"
+				case cap.StepSkipped:
+					icon = "–"
+				}
+				p.AddLog(fmt.Sprintf("%s %s", icon, msg.step.Name))
+				for _, line := range strings.Split(strings.TrimSpace(msg.step.Output), "\n") {
+					if clean := strings.TrimSpace(ansiRegex.ReplaceAllString(line, "")); clean != "" {
+						p.AddLog("  " + clean)
+					}
+				}
+				if msg.err != nil {
+					p.AddLog("Error: " + msg.err.Error())
+				}
+			}
+		}
+
+		switch {
+		case msg.err != nil:
+			m.migrating = false
+			m.finishMigrationProcess(ProcessFailed)
+			m.setStatus("Upgrade paused: " + msg.err.Error() + " (u to resume)")
+		case !m.migration.Done():
+			cmds = append(cmds, runMigrationStep(m.migration))
+		default:
+			m.migrating = false
+			m.finishMigrationProcess(ProcessSuccess)
+			_ = m.migration.Discard()
+			m.reloadProject()
+			m.setStatus(fmt.Sprintf("Upgraded to Capacitor %s", m.migration.ToVersion))
+			cmds = append(cmds, m.checkUpgrade())
+		}
+		m.updateLogViewport()
 
 	case updateCheckedMsg:
 		if msg.err == nil && msg.info != nil {
@@ -1160,21 +1593,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, scheduleMemoryUpdate())
 
 	case processStartedMsg:
-		for _, p := range m.processes {
-			if p.ID == msg.processID {
-				p.Cmd = msg.cmd
-				p.OutputChan = msg.outputChan
-				m.outputChans[msg.processID] = msg.outputChan
-				break
-			}
+		p := m.findProcess(msg.processID)
+		if p == nil || p.Status != ProcessRunning {
+			// Removed or stopped with a linked process before it started
+			msg.exec.Cancel()
+			break
+		}
+		p.Exec = msg.exec
+		if len(p.then) == 0 {
+			m.pipelineStarted(msg)
 		}
-		cmds = append(cmds, waitForOutput(msg.processID, msg.outputChan), m.spinner.Tick, setTerminalTitle(m.getTerminalTitle()))
+		cmds = append(cmds, waitForOutput(msg.processID, msg.exec), m.devServerStarted(msg), m.spinner.Tick, setTerminalTitle(m.getTerminalTitle()))
+
+	case devServerReadyMsg:
+		cmds = append(cmds, m.devServerReady(msg))
 
 	case processOutputMsg:
 		for _, p := range m.processes {
 			if p.ID == msg.processID && msg.line != "" {
 				clean := strings.TrimSpace(ansiRegex.ReplaceAllString(msg.line, ""))
 				if clean != "" {
+					if m.settings.ShowTimestamps {
+						clean = fmt.Sprintf("[%s] %s", time.Now().Format("15:04:05"), clean)
+					}
 					p.AddLog(clean)
 				}
 				if m.getSelectedProcess() == p {
@@ -1183,15 +1624,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 		}
-		if ch, ok := m.outputChans[msg.processID]; ok {
-			cmds = append(cmds, waitForOutput(msg.processID, ch))
+		if p := m.findProcess(msg.processID); p != nil && p.Exec != nil {
+			cmds = append(cmds, waitForOutput(msg.processID, p.Exec))
 		}
 		cmds = append(cmds, m.spinner.Tick)
 
 	case processFinishedMsg:
 		for _, p := range m.processes {
 			if p.ID == msg.processID && p.Status == ProcessRunning {
-				if msg.err != nil {
+				if msg.err == nil && len(p.then) > 0 {
+					// Start the next step in the same process
+					step := p.then[0]
+					p.then = p.then[1:]
+					p.Exec = nil
+					p.AddLog(fmt.Sprintf("[%s] $ %s", time.Now().Format("15:04:05"), step.command))
+					cmds = append(cmds, step.start(p.ID))
+					break
+				}
+				if errors.Is(msg.err, cap.ErrCanceled) {
+					p.Status = ProcessCancelled
+					p.AddLog("○ Canceled")
+				} else if msg.err != nil {
 					p.Status = ProcessFailed
 					p.AddLog(fmt.Sprintf("Error: %v", msg.err))
 				} else {
@@ -1199,13 +1652,45 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					p.AddLog("✓ Done")
 				}
 				p.EndTime = time.Now()
+				m.stopLinked(p)
+				cmds = append(cmds, m.processCompleted(p), m.advancePipeline(p, msg.err))
+				if p.RefreshDevices {
+					cmds = append(cmds, loadDevices)
+				}
 				break
 			}
 		}
-		delete(m.outputChans, msg.processID)
 		m.updateLogViewport()
 		cmds = append(cmds, setTerminalTitle(m.getTerminalTitle()))
 
+	case versionBumpedMsg:
+		if p := m.findProcess(msg.processID); p != nil {
+			switch {
+			case msg.tagErr != nil:
+				p.AddLog("Tag failed: " + msg.tagErr.Error())
+			case msg.tag != "":
+				p.AddLog("Tagged " + msg.tag)
+			}
+		}
+		if msg.err == nil {
+			m.reloadProject()
+			m.setStatus(fmt.Sprintf("Version %s (%d)", msg.bump.NewVersion, msg.bump.NewBuild))
+		} else {
+			m.setStatus("Version bump failed")
+		}
+		cmds = append(cmds, func() tea.Msg {
+			return processFinishedMsg{processID: msg.processID, err: msg.err}
+		})
+
+	case wirelessConnectedMsg:
+		if msg.err == nil {
+			m.settings.RememberWirelessDevice(msg.name, msg.serial, msg.address)
+			_ = m.settings.Save()
+		}
+		cmds = append(cmds, func() tea.Msg {
+			return processFinishedMsg{processID: msg.processID, err: msg.err}
+		})
+
 	case deviceBootedMsg:
 		if msg.err != nil {
 			m.addLog(fmt.Sprintf("Boot failed: %v", msg.err))
@@ -1214,15 +1699,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		for i, d := range m.devices {
 			if d.ID == msg.device.ID {
 				m.devices[i].Online = true
+				m.devices[i].State = device.StateBooted
+				if m.pluginContext != nil {
+					m.pluginContext.NotifyDeviceBooted(m.devices[i])
+				}
 				break
 			}
 		}
-		return m, m.startRunCommand(msg.device, msg.liveReload)
+		booted := *msg.device
+		booted.ID = msg.id
+		return m, m.startRunCommand(&booted, msg.liveReload)
 
 	case errMsg:
 		m.loading = false
 		m.addLog(fmt.Sprintf("Error: %v", msg.err))
 
+	case pluginActionMsg:
+		cmds = append(cmds, m.handlePluginAction(msg), listenForPluginActions(m.pluginActions))
+
 	case pluginLogMsg:
 		// Find or create a process tab for this plugin
 		var pluginProcess *Process
@@ -1304,7 +1798,9 @@ func (m *Model) updateLogViewport() {
 		content = lipgloss.NewStyle().Width(m.logViewport.Width).Render(content)
 	}
 	m.logViewport.SetContent(content)
-	m.logViewport.GotoBottom()
+	if m.settings.AutoScrollLogs {
+		m.logViewport.GotoBottom()
+	}
 }
 
 func (m *Model) addLog(line string) {
@@ -1329,10 +1825,12 @@ func (m *Model) setStatus(msg string) {
 func (m *Model) createProcess(name, command string) *Process {
 	id := fmt.Sprintf("p%d", m.nextProcessID)
 	m.nextProcessID++
+	m.pruneProcessHistory()
 	p := &Process{
 		ID: id, Name: name, Command: command, Status: ProcessRunning,
 		StartTime: time.Now(),
 		Logs:      []string{fmt.Sprintf("[%s] $ %s", time.Now().Format("15:04:05"), command)},
+		MaxLines:  m.settings.MaxLogLines,
 	}
 	m.processes = append(m.processes, p)
 	m.selectedProcess = len(m.processes) - 1
@@ -1348,6 +1846,14 @@ func (m *Model) getProjectDir() string {
 	return ""
 }
 
+// packageManager returns the package manager for the current project
+func (m *Model) packageManager() cap.PackageManager {
+	if m.project != nil && m.project.PackageManager != "" {
+		return m.project.PackageManager
+	}
+	return cap.DetectPackageManager(m.getProjectDir())
+}
+
 func (m *Model) runAction(action string, liveReload bool) tea.Cmd {
 	dev := m.getSelectedDevice()
 
@@ -1414,113 +1920,182 @@ func (m *Model) startRunCommand(dev *device.Device, liveReload bool) tea.Cmd {
 	}
 
 	name := shortName
-	args := []string{"cap", "run", dev.Platform, "--target", dev.ID}
+	run := cap.RunOptions{
+		Flavor:        m.settings.ResolvedAndroidFlavor(),
+		Scheme:        m.settings.ResolvedIOSScheme(),
+		Configuration: m.settings.IOSConfiguration,
+	}
+	opts := m.execOptions(m.getProjectDir(), 0)
+	pm := m.packageManager()
 
 	if liveReload {
-		args = append(args, "-l")
-
-		// Get port and host from settings
-		port := m.settings.GetInt("liveReloadPort")
-		host := m.settings.GetString("externalHost")
-
-		if port > 0 {
-			args = append(args, "--port", fmt.Sprintf("%d", port))
-		}
-		if host != "" {
-			args = append(args, "--host", host)
-		}
+		// Get port and host from settings (active environment wins)
+		run.Port = m.settings.ResolvedLiveReloadPort()
+		// Empty picks this machine's address on the device's network once the
+		// dev server is up
+		run.Host = m.settings.ResolvedLiveReloadHost()
 		name = shortName + " (live)"
+		return m.startLiveReload(dev, run, name)
+	}
 
-		// For live reload, we need to start the dev server AND the cap run command
-		// Start dev server first, then run cap command
-		p := m.createProcess(name, "vite + cap run")
-		projectDir := m.getProjectDir()
-
-		// Kill any existing process on the port first
-		if port > 0 {
-			if cap.KillPort(port) {
-				p.AddLog(fmt.Sprintf("Killed existing process on port %d", port))
-			}
+	// Auto Sync syncs with lazycap's sync settings, so cap run skips its own
+	run.NoSync = m.settings.AutoSync
+	argv := pm.Exec(cap.RunArgs(dev.Platform, dev.ID, run)...)
+	return m.startPipeline("run", settings.HookPreRun, settings.HookPostRun, func(m *Model) (*Process, tea.Cmd) {
+		m.clearPreviousRuns(name)
+		var steps []processStep
+		switch {
+		case m.settings.AutoSync:
+			steps = m.syncSteps(dev.Platform)
+		case m.settings.AutoBuild:
+			steps = []processStep{m.buildStep()}
 		}
+		steps = append(steps, m.argvStep(opts, argv))
+		p := m.createProcess(name, stepsCommand(steps))
+		p.Platform = dev.Platform
+		return p, m.runSteps(p, steps)
+	})
+}
 
-		return func() tea.Msg {
-			ch := make(chan string, 100)
-
-			// Build the combined command that starts vite in background and then runs cap
-			viteHost := "0.0.0.0" // Bind to all interfaces for external access
-			if host != "" {
-				viteHost = host
-			}
-
-			// Command: start vite in background, wait for it, then run cap
-			// Use /bin/sleep for macOS compatibility
-			cmdStr := fmt.Sprintf(
-				"npx vite --host %s --port %d --strictPort & VITE_PID=$!; /bin/sleep 3; npx %s; kill $VITE_PID 2>/dev/null",
-				viteHost,
-				port,
-				strings.Join(args, " "),
-			)
-
-			shell := os.Getenv("SHELL")
-			if shell == "" {
-				shell = "/bin/zsh"
-			}
+func (m *Model) startSyncCommand(platform string) tea.Cmd {
+	if cap.SyncArgs(platform, m.settings.CopyWebDir, m.settings.UpdateNative) == nil {
+		m.setStatus("Nothing to sync: enable Copy Web Dir or Update Native in settings")
+		return nil
+	}
+	return m.startPipeline("sync", settings.HookPreSync, settings.HookPostSync, func(m *Model) (*Process, tea.Cmd) {
+		steps := m.syncSteps(platform)
+		p := m.createProcess("Sync", stepsCommand(steps))
+		return p, m.runSteps(p, steps)
+	})
+}
 
-			shellCmd := fmt.Sprintf("source ~/.zshrc 2>/dev/null; source ~/.zprofile 2>/dev/null; %s", cmdStr)
-			cmd := exec.Command(shell, "-c", shellCmd)
-			cmd.Env = os.Environ()
+// syncSteps returns the steps that sync the native projects the way the
+// settings say: cap sync, copy or update, after a build when Auto Build is on
+// and web assets are copied. It returns nil when there is nothing to sync.
+func (m *Model) syncSteps(platform string) []processStep {
+	args := cap.SyncArgs(platform, m.settings.CopyWebDir, m.settings.UpdateNative)
+	if args == nil {
+		return nil
+	}
+	opts := m.execOptions(m.getProjectDir(), m.settings.SyncTimeoutDuration())
+	steps := []processStep{m.argvStep(opts, m.packageManager().Exec(args...))}
+	if m.settings.AutoBuild && m.settings.CopyWebDir {
+		steps = append([]processStep{m.buildStep()}, steps...)
+	}
+	return steps
+}
 
-			if projectDir != "" {
-				cmd.Dir = projectDir
-			} else if cwd, err := os.Getwd(); err == nil {
-				cmd.Dir = cwd
-			}
+func (m *Model) startBuildCommand() tea.Cmd {
+	return m.startPipeline("build", settings.HookPreBuild, settings.HookPostBuild, func(m *Model) (*Process, tea.Cmd) {
+		step := m.buildStep()
+		p := m.createProcess("Build", step.command)
+		return p, m.runSteps(p, []processStep{step})
+	})
+}
 
-			return runCmdWithPipes(p.ID, cmd, ch)
+// buildStep returns the step that builds the web assets: the custom build
+// command through the configured shell, or the project's build script, run
+// through the workspace runner (nx, turbo, pnpm --filter, ...) for monorepo
+// members
+func (m *Model) buildStep() processStep {
+	opts := m.execOptions(m.getProjectDir(), m.settings.BuildTimeoutDuration())
+	opts.Env = m.settings.BuildEnvList()
+	if command := m.settings.ResolvedBuildCommand(); command != "" {
+		return m.shellStep(opts, command)
+	}
+	argv := m.packageManager().RunScript("build")
+	if m.project != nil {
+		var dir string
+		argv, dir = m.project.BuildCommand()
+		if dir != "" {
+			opts.Dir = dir
 		}
 	}
-
-	p := m.createProcess(name, "npx "+strings.Join(args, " "))
-	return runCmd(p.ID, m.getProjectDir(), "npx", args...)
+	return m.argvStep(opts, argv)
 }
 
-func (m *Model) startSyncCommand(platform string) tea.Cmd {
-	args := []string{"cap", "sync"}
-	if platform != "" {
-		args = append(args, platform)
-	}
-	p := m.createProcess("Sync", "npx "+strings.Join(args, " "))
-	return runCmd(p.ID, m.getProjectDir(), "npx", args...)
+func (m *Model) startOpenCommand(platform string) tea.Cmd {
+	argv := m.packageManager().Exec("cap", "open", platform)
+	opts := m.execOptions(m.getProjectDir(), 0)
+	return m.startPipeline("open", settings.HookPreOpen, settings.HookPostOpen, func(m *Model) (*Process, tea.Cmd) {
+		p := m.createProcess("Open", strings.Join(argv, " "))
+		return p, m.runCmd(p.ID, opts, argv[0], argv[1:]...)
+	})
 }
 
-func (m *Model) startBuildCommand() tea.Cmd {
-	p := m.createProcess("Build", "npm run build")
-	return runCmd(p.ID, m.getProjectDir(), "npm", "run", "build")
+// execOptions returns the options for a process in dir: the tool paths and
+// active environment profile, plus an optional timeout
+func (m *Model) execOptions(dir string, timeout time.Duration) cap.ExecOptions {
+	return cap.ExecOptions{
+		Dir:     dir,
+		Env:     m.settings.EnvList(),
+		Timeout: timeout,
+	}
 }
 
-func (m *Model) startOpenCommand(platform string) tea.Cmd {
-	p := m.createProcess("Open", "npx cap open "+platform)
-	return runCmd(p.ID, m.getProjectDir(), "npx", "cap", "open", platform)
+// openMigration shows the upgrade assistant, resuming a saved migration when
+// there is one and planning a new one otherwise
+func (m *Model) openMigration() {
+	if m.migration == nil || m.migration.Done() {
+		if m.upgradeInfo == nil || !m.upgradeInfo.HasUpgrade || m.project == nil {
+			return
+		}
+		mig, err := cap.NewMigration(m.project, m.upgradeInfo.CurrentVersion, m.upgradeInfo.LatestVersion)
+		if err != nil {
+			m.setStatus("Cannot plan upgrade: " + err.Error())
+			return
+		}
+		m.migration = mig
+	}
+	m.showMigration = true
+	m.showHelp = false
+	m.showPreflight = false
 }
 
-func (m *Model) startUpgrade() tea.Cmd {
-	p := m.createProcess("Upgrade", "npm install @capacitor/core@latest @capacitor/cli@latest")
-	return runCmd(p.ID, m.getProjectDir(), "npm", "install", "@capacitor/core@latest", "@capacitor/cli@latest")
+// runMigrationStep runs the next migration step in the background, on a copy
+// so the model's migration is only changed by Update
+func runMigrationStep(mig *cap.Migration) tea.Cmd {
+	mig = mig.Clone()
+	return func() tea.Msg {
+		step, err := mig.RunNext()
+		return migrationStepMsg{migration: mig, step: step, err: err}
+	}
 }
 
 func (m *Model) startWebDevCommand() tea.Cmd {
 	// Get web settings
-	command := m.settings.GetString("webDevCommand")
-	if command == "" {
-		command = cap.DetectWebDevCommand()
-	}
+	server := cap.DetectDevServer(m.getProjectDir(), m.settings.GetString("webDevCommand"))
 	port := m.settings.GetInt("webDevPort")
 	host := m.settings.GetString("webHost")
 	openBrowser := m.settings.GetBool("webOpenBrowser")
 	browserPath := m.settings.GetString("webBrowserPath")
 	https := m.settings.GetBool("webHttps")
 
-	p := m.createProcess("Web", command)
+	// Pass the port and host in the form the framework takes; without a port
+	// the dev server uses its own defaults
+	argv, env := server.Argv, []string(nil)
+	var proxy *cap.TLSProxy
+	if port > 0 {
+		listenHost := host
+		if listenHost == "" {
+			listenHost = "localhost"
+		}
+		argv, env = server.Listen(listenHost, port)
+		if https {
+			var err error
+			if argv, env, proxy, err = server.ListenHTTPS(listenHost, port, host); err != nil {
+				m.setStatus("Web: " + err.Error())
+				return nil
+			}
+		}
+	}
+	opts := m.execOptions(m.getProjectDir(), 0)
+	opts.Env = append(opts.Env, env...)
+
+	p := m.createProcess("Web", shellJoin(argv))
+	if proxy != nil {
+		m.tlsProxies[p.ID] = proxy
+	}
 
 	// Kill any process using the port first
 	if cap.KillPort(port) {
@@ -1547,146 +2122,156 @@ func (m *Model) startWebDevCommand() tea.Cmd {
 		}()
 	}
 
-	// Run the command directly - let the dev server use its own defaults
-	// The command should be the full command like "npm run dev" or "npx vite"
-	return runWebCmd(p.ID, m.getProjectDir(), command, port, host)
+	return m.runCmd(p.ID, opts, argv[0], argv[1:]...)
 }
 
-func runCmd(processID, workDir, name string, args ...string) tea.Cmd {
+// runCmd starts a command directly, without a shell. Its PATH is the login
+// shell's (see settings.ApplyToolEnv), so version-managed node and npx are found.
+func (m *Model) runCmd(processID string, opts cap.ExecOptions, name string, args ...string) tea.Cmd {
+	argv := append([]string{name}, args...)
 	return func() tea.Msg {
-		ch := make(chan string, 100)
-
-		// Build the command string
-		cmdStr := name
-		for _, arg := range args {
-			if strings.Contains(arg, " ") {
-				cmdStr += fmt.Sprintf(" %q", arg)
-			} else {
-				cmdStr += " " + arg
-			}
-		}
-
-		// Run through user's shell with full environment
-		// Using 'source' to load shell config ensures proper PATH
-		shell := os.Getenv("SHELL")
-		if shell == "" {
-			shell = "/bin/zsh"
-		}
-
-		// Source the profile explicitly and run command
-		shellCmd := fmt.Sprintf("source ~/.zshrc 2>/dev/null; source ~/.zprofile 2>/dev/null; %s", cmdStr)
-		cmd := exec.Command(shell, "-c", shellCmd)
-
-		// Inherit full environment
-		cmd.Env = os.Environ()
-
-		// Set working directory - use project dir if provided, otherwise cwd
-		if workDir != "" {
-			cmd.Dir = workDir
-		} else if cwd, err := os.Getwd(); err == nil {
-			cmd.Dir = cwd
-		}
-
-		return runCmdWithPipes(processID, cmd, ch)
+		return startProcess(processID, argv, opts)
 	}
 }
 
-// runWebCmd runs a web dev server command with proper port/host handling
-func runWebCmd(processID, workDir, command string, port int, host string) tea.Cmd {
+// runShell runs a command line written by the user, like a hook or custom
+// build command, with the configured shell (Shell Path setting, then $SHELL)
+func (m *Model) runShell(processID string, opts cap.ExecOptions, cmdStr string) tea.Cmd {
+	argv := shellenv.Command(m.settings.ShellPath, cmdStr)
 	return func() tea.Msg {
-		ch := make(chan string, 100)
-
-		// Build the full command
-		// For npm/yarn/pnpm run commands, we need to use -- to pass args to the script
-		cmdStr := command
+		return startProcess(processID, argv, opts)
+	}
+}
 
-		// Check if we need to add port/host args
-		hasExtraArgs := port > 0 || (host != "" && host != "localhost")
+// processStep is one of the commands a process runs one after another
+type processStep struct {
+	command string
+	start   func(processID string) tea.Cmd
+}
 
-		if hasExtraArgs {
-			// For npm/yarn/pnpm run commands, add -- separator
-			if strings.HasPrefix(command, "npm run") ||
-				strings.HasPrefix(command, "yarn run") ||
-				strings.HasPrefix(command, "pnpm run") ||
-				strings.HasPrefix(command, "yarn ") ||
-				strings.HasPrefix(command, "pnpm ") {
-				cmdStr += " --"
-			}
+// argvStep runs argv directly
+func (m *Model) argvStep(opts cap.ExecOptions, argv []string) processStep {
+	return processStep{command: shellJoin(argv), start: func(processID string) tea.Cmd {
+		return m.runCmd(processID, opts, argv[0], argv[1:]...)
+	}}
+}
 
-			if port > 0 {
-				cmdStr += fmt.Sprintf(" --port %d", port)
-			}
-			if host != "" && host != "localhost" {
-				cmdStr += fmt.Sprintf(" --host %s", host)
-			}
-		}
+// shellStep runs a command line written by the user with the configured shell
+func (m *Model) shellStep(opts cap.ExecOptions, command string) processStep {
+	return processStep{command: command, start: func(processID string) tea.Cmd {
+		return m.runShell(processID, opts, command)
+	}}
+}
 
-		// Run through user's shell
-		shell := os.Getenv("SHELL")
-		if shell == "" {
-			shell = "/bin/zsh"
-		}
+// runSteps starts the first step in p; each following step starts once the
+// one before it succeeds
+func (m *Model) runSteps(p *Process, steps []processStep) tea.Cmd {
+	p.then = steps[1:]
+	return steps[0].start(p.ID)
+}
 
-		shellCmd := fmt.Sprintf("source ~/.zshrc 2>/dev/null; source ~/.zprofile 2>/dev/null; %s", cmdStr)
-		cmd := exec.Command(shell, "-c", shellCmd)
-		cmd.Env = os.Environ()
+// stepsCommand is the command line shown for a process running steps
+func stepsCommand(steps []processStep) string {
+	commands := make([]string, len(steps))
+	for i, step := range steps {
+		commands[i] = step.command
+	}
+	return strings.Join(commands, " && ")
+}
 
-		// Set working directory - use project dir if provided, otherwise cwd
-		if workDir != "" {
-			cmd.Dir = workDir
-		} else if cwd, err := os.Getwd(); err == nil {
-			cmd.Dir = cwd
-		}
+// shellJoin joins argv into a command line, quoting arguments with spaces
+func shellJoin(argv []string) string {
+	parts := make([]string, len(argv))
+	for i, arg := range argv {
+		parts[i] = shellQuote(arg)
+	}
+	return strings.Join(parts, " ")
+}
 
-		return runCmdWithPipes(processID, cmd, ch)
+func shellQuote(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t'\"$&;|<>()*?`\\") {
+		return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
 	}
+	return arg
 }
 
-// runCmdWithPipes runs command using pipes instead of PTY
-func runCmdWithPipes(processID string, cmd *exec.Cmd, ch chan string) tea.Msg {
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		close(ch)
-		return processFinishedMsg{processID: processID, err: err}
+// startProcess starts argv through the cap exec API, in the current
+// directory unless opts names one
+func startProcess(processID string, argv []string, opts cap.ExecOptions) tea.Msg {
+	if opts.Dir == "" {
+		if cwd, err := os.Getwd(); err == nil {
+			opts.Dir = cwd
+		}
 	}
-	stderr, err := cmd.StderrPipe()
+	e, err := cap.Start(context.Background(), argv, opts)
 	if err != nil {
-		close(ch)
 		return processFinishedMsg{processID: processID, err: err}
 	}
+	return processStartedMsg{processID: processID, exec: e}
+}
 
-	if err := cmd.Start(); err != nil {
-		close(ch)
-		return processFinishedMsg{processID: processID, err: err}
+// handlePluginAction starts the process a plugin asked for and replies with its handle
+func (m *Model) handlePluginAction(msg pluginActionMsg) tea.Cmd {
+	reply := func(err error) tea.Cmd {
+		msg.reply <- pluginActionResult{err: err}
+		return nil
 	}
 
-	// Read both stdout and stderr
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			select {
-			case ch <- scanner.Text():
-			default:
-			}
+	var cmd tea.Cmd
+	switch msg.action {
+	case "run":
+		dev, err := device.Resolve(m.devices, msg.deviceID, m.settings.DeviceAliases)
+		if err != nil {
+			return reply(err)
+		}
+		if !dev.Online {
+			return reply(fmt.Errorf("device %s is not booted", dev.Name))
+		}
+		cmd = m.startRunCommand(dev, msg.liveReload)
+	case "web":
+		cmd = m.startWebDevCommand()
+	case "sync":
+		cmd = m.startSyncCommand(msg.platform)
+	case "build":
+		cmd = m.startBuildCommand()
+	case "open":
+		cmd = m.startOpenCommand(msg.platform)
+	case "refresh":
+		m.loading = true
+		msg.reply <- pluginActionResult{}
+		return loadDevices
+	case "kill":
+		p := m.findProcess(msg.processID)
+		if p == nil || p.Status != ProcessRunning || p.Exec == nil {
+			return reply(fmt.Errorf("process %s not found or not running", msg.processID))
 		}
-	}()
+		p.Exec.Cancel()
+		return reply(nil)
+	default:
+		return reply(fmt.Errorf("unknown action %q", msg.action))
+	}
 
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			select {
-			case ch <- scanner.Text():
-			default:
-			}
+	if cmd == nil {
+		return reply(fmt.Errorf("%s did not start: %s", msg.action, m.statusMessage))
+	}
+	// Run, build, sync and open reply once their hooks have run and the main command starts
+	if pl := m.latestPipeline(); pl != nil {
+		pl.reply = msg.reply
+		return cmd
+	}
+
+	return func() tea.Msg {
+		result := cmd()
+		switch result := result.(type) {
+		case processStartedMsg:
+			msg.reply <- pluginActionResult{handle: &plugin.ProcessHandle{ProcessID: result.processID, Exec: result.exec}}
+		case processFinishedMsg:
+			msg.reply <- pluginActionResult{err: result.err}
+		default:
+			msg.reply <- pluginActionResult{err: fmt.Errorf("%s did not start", msg.action)}
 		}
-	}()
-
-	go func() {
-		_ = cmd.Wait()
-		close(ch)
-	}()
-
-	return processStartedMsg{processID: processID, cmd: cmd, outputChan: ch}
+		return result
+	}
 }
 
 // View renders the UI
@@ -1715,6 +2300,22 @@ func (m Model) View() string {
 		return m.renderProjectSelector()
 	}
 
+	if m.showVersionBump {
+		return m.renderVersionBump()
+	}
+
+	if m.showMigration {
+		return m.renderMigration()
+	}
+
+	if m.showPluginReport {
+		return m.renderPluginReport()
+	}
+
+	if m.showDeviceManager {
+		return m.renderDeviceManager()
+	}
+
 	// Build the view
 	left := m.renderLeft()
 	right := m.renderRight()
@@ -1767,10 +2368,22 @@ func (m *Model) renderHeader() string {
 	}
 	platformStr := strings.Join(platforms, " ")
 
+	// Active environment profile
+	var envStr string
+	if len(m.settings.Environments) > 0 {
+		envName := m.settings.ActiveEnvironment
+		if envName == "" {
+			envName = "none"
+		}
+		envStr = "  " + mutedStyle.Render("env:") + lipgloss.NewStyle().Foreground(capCyan).Render(envName) + mutedStyle.Render(" (E)")
+	}
+
 	// Capacitor upgrade notice
 	var upgrade string
-	if m.upgradeInfo != nil && m.upgradeInfo.HasUpgrade {
-		upgrade = upgradeStyle.Render(fmt.Sprintf("  ↑ v%s available", m.upgradeInfo.LatestVersion))
+	if m.migration != nil && !m.migration.Done() {
+		upgrade = upgradeStyle.Render(fmt.Sprintf("  ↑ v%s upgrade paused (u)", m.migration.ToVersion))
+	} else if m.upgradeInfo != nil && m.upgradeInfo.HasUpgrade {
+		upgrade = upgradeStyle.Render(fmt.Sprintf("  ↑ v%s available (u)", m.upgradeInfo.LatestVersion))
 	}
 
 	// lazycap update notice
@@ -1796,7 +2409,7 @@ func (m *Model) renderHeader() string {
 		statusMsg = "  " + successStyle.Render(m.statusMessage)
 	}
 
-	headerLine := fmt.Sprintf("%s  %s%s  %s%s%s%s%s", logo, project, workspaceHint, platformStr, upgrade, lazycapUpdate, preflightIndicator, statusMsg)
+	headerLine := fmt.Sprintf("%s  %s%s  %s%s%s%s%s%s", logo, project, workspaceHint, platformStr, envStr, upgrade, lazycapUpdate, preflightIndicator, statusMsg)
 
 	return headerLine
 }
@@ -1813,7 +2426,7 @@ func (m *Model) renderStatusBar() string {
 		}
 	}
 	if m.loading {
-		statusItems = append(statusItems, m.spinner.View()+" Devices...")
+		statusItems = append(statusItems, m.spinnerView()+" Devices...")
 	} else {
 		deviceStatus := fmt.Sprintf("%d/%d", onlineCount, len(m.devices))
 		if onlineCount > 0 {
@@ -1831,7 +2444,7 @@ func (m *Model) renderStatusBar() string {
 		}
 	}
 	if runningCount > 0 {
-		statusItems = append(statusItems, m.spinner.View()+" "+fmt.Sprintf("%d running", runningCount))
+		statusItems = append(statusItems, m.spinnerView()+" "+fmt.Sprintf("%d running", runningCount))
 	} else if len(m.processes) > 0 {
 		statusItems = append(statusItems, statusOfflineStyle.Render("○")+" "+mutedStyle.Render("idle"))
 	}
@@ -1927,30 +2540,36 @@ func (m *Model) renderLeft() string {
 	for i, d := range m.devices {
 		// Status indicator
 		var status string
-		if d.Online {
+		switch {
+		case d.Transitioning():
+			status = transitionStyle.Render("◐")
+		case d.State == device.StateUnauthorized:
+			status = transitionStyle.Render("!")
+		case d.Online:
 			status = onlineStyle.Render("●")
-		} else {
+		default:
 			status = offlineStyle.Render("○")
 		}
 
 		// Platform badge
 		var platform string
-		switch d.Platform {
-		case "ios":
+		switch {
+		case !m.settings.ShowPlatformBadges:
+		case d.Platform == "ios":
 			platform = iosBadge.Render("iOS")
-		case "android":
+		case d.Platform == "android":
 			platform = androidBadge.Render("And")
-		case "web":
+		case d.Platform == "web":
 			platform = webBadge.Render("Web")
 		}
 
 		// Device type indicator
 		var deviceType string
-		if d.IsWeb {
-			deviceType = mutedStyle.Render("dev")
-		} else if d.IsEmulator {
+		switch {
+		case !m.settings.ShowDeviceIcons:
+		case d.IsEmulator && !d.IsWeb:
 			deviceType = mutedStyle.Render("sim")
-		} else {
+		default:
 			deviceType = mutedStyle.Render("dev")
 		}
 
@@ -1984,6 +2603,16 @@ func (m *Model) renderLeft() string {
 		}
 	}
 
+	if dev := m.getSelectedDevice(); dev != nil && !dev.IsWeb {
+		items = append(items, "")
+		for _, line := range deviceDetails(dev) {
+			if r := []rune(line); len(r) > 26 {
+				line = string(r[:23]) + "..."
+			}
+			items = append(items, mutedStyle.Render("   "+line))
+		}
+	}
+
 	if len(items) == 0 {
 		items = append(items, mutedStyle.Render("  No devices found"))
 		items = append(items, "")
@@ -2005,6 +2634,41 @@ func (m *Model) renderLeft() string {
 	return inactivePaneStyle.Width(32).Height(paneHeight).Render(inner)
 }
 
+// deviceDetails describes a device's OS, state, model and connection for the
+// device pane
+func deviceDetails(d *device.Device) []string {
+	osName := "iOS"
+	if d.Platform == "android" {
+		osName = "Android"
+	}
+	first := osName
+	if d.OSVersion != "" {
+		first += " " + d.OSVersion
+	}
+	if d.APILevel != "" {
+		first += " (API " + d.APILevel + ")"
+	}
+	first += " · " + string(d.CurrentState())
+
+	var second []string
+	if d.Model != "" && d.Model != d.Name {
+		second = append(second, d.Model)
+	}
+	if d.ABI != "" {
+		second = append(second, d.ABI)
+	}
+	if d.Connection != "" {
+		second = append(second, d.Connection)
+	}
+	if d.BatteryLevel > 0 {
+		second = append(second, fmt.Sprintf("%d%%", d.BatteryLevel))
+	}
+	if len(second) == 0 {
+		return []string{first}
+	}
+	return []string{first, strings.Join(second, " · ")}
+}
+
 func (m *Model) renderRight() string {
 	paneWidth := m.width - 36 - 6
 	paneHeight := m.height - 9 // Account for header + status bar
@@ -2055,7 +2719,7 @@ func (m *Model) renderRight() string {
 		var icon string
 		switch p.Status {
 		case ProcessRunning:
-			icon = m.spinner.View()
+			icon = m.spinnerView()
 		case ProcessSuccess:
 			icon = successStyle.Render("✓")
 		case ProcessFailed:
@@ -2286,8 +2950,22 @@ func (m *Model) renderPreflight() string {
 	return strings.Join(lines, "\n")
 }
 
+// settingsCategories returns the settings categories with flavor, scheme and
+// configuration choices filled from the native projects
+func (m *Model) settingsCategories() []settings.Category {
+	choices := make(map[string][]string)
+	if m.project != nil && m.project.Android != nil {
+		choices["androidFlavor"] = m.project.Android.Flavors
+	}
+	if m.project != nil && m.project.IOS != nil {
+		choices["iosScheme"] = m.project.IOS.Schemes
+		choices["iosConfiguration"] = m.project.IOS.Configurations
+	}
+	return settings.WithChoices(settings.GetCategories(), choices)
+}
+
 func (m Model) handleSettingsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	categories := settings.GetCategories()
+	categories := m.settingsCategories()
 	currentCategory := categories[m.settingsCategory]
 
 	// If we're editing a text field, handle text input
@@ -2431,7 +3109,7 @@ func (m Model) handleSettingsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) renderSettings() string {
-	categories := settings.GetCategories()
+	categories := m.settingsCategories()
 
 	// Title
 	title := lipgloss.NewStyle().
@@ -2841,6 +3519,400 @@ func (m Model) handleProjectSelectorInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m Model) handlePluginReportInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.gracefulShutdown()
+		return m, tea.Quit
+
+	case "esc", "i":
+		m.showPluginReport = false
+		return m, nil
+
+	case "r":
+		m.pluginReport, m.pluginReportErr = cap.GetPluginReport(m.project)
+		m.setStatus("Plugin report refreshed")
+		return m, nil
+
+	case "s":
+		// Most registry issues are fixed by a sync
+		m.showPluginReport = false
+		return m, m.startSyncCommand("")
+	}
+
+	return m, nil
+}
+
+func (m *Model) renderPluginReport() string {
+	title := lipgloss.NewStyle().
+		Foreground(capBlue).
+		Bold(true).
+		Render("  🔌 Capacitor Plugins")
+
+	var lines []string
+	lines = append(lines, "")
+	lines = append(lines, title)
+	lines = append(lines, "")
+
+	switch {
+	case m.pluginReportErr != nil:
+		lines = append(lines, "  "+errorStyle.Render(m.pluginReportErr.Error()))
+	case m.pluginReport == nil || len(m.pluginReport.Plugins) == 0:
+		lines = append(lines, "  "+mutedStyle.Render("No Capacitor packages found in package.json"))
+	default:
+		report := m.pluginReport
+		summary := fmt.Sprintf("  @capacitor/core %s  •  %d packages", report.CoreVersion, len(report.Plugins))
+		if report.IssueCount > 0 {
+			summary += "  •  " + lipgloss.NewStyle().Foreground(warnColor).Render(fmt.Sprintf("%d issue(s)", report.IssueCount))
+		} else {
+			summary += "  •  " + successStyle.Render("all compatible")
+		}
+		lines = append(lines, mutedStyle.Render(summary))
+		lines = append(lines, "")
+
+		nameStyle := lipgloss.NewStyle().Foreground(capLight).Width(40)
+		versionStyle := lipgloss.NewStyle().Foreground(capCyan).Width(10)
+		for _, p := range report.Plugins {
+			icon := successStyle.Render("✓")
+			if len(p.Issues) > 0 {
+				icon = lipgloss.NewStyle().Foreground(warnColor).Render("⚠")
+			}
+
+			version := p.Version
+			if version == "" {
+				version = "-"
+			}
+			var platforms []string
+			if p.Android {
+				platforms = append(platforms, "android")
+			}
+			if p.IOS {
+				platforms = append(platforms, "ios")
+			}
+
+			lines = append(lines, fmt.Sprintf("  %s %s %s %s", icon, nameStyle.Render(p.Name), versionStyle.Render(version), mutedStyle.Render(strings.Join(platforms, " "))))
+			for _, issue := range p.Issues {
+				lines = append(lines, "      "+lipgloss.NewStyle().Foreground(warnColor).Render(issue))
+			}
+		}
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, helpStyle.Render("  "+
+		helpKeyStyle.Render("r")+" refresh  "+
+		helpKeyStyle.Render("s")+" cap sync  "+
+		helpKeyStyle.Render("esc")+" close"))
+
+	return strings.Join(lines, "\n")
+}
+
+// findProcess returns the process with the given ID, or nil
+func (m *Model) findProcess(id string) *Process {
+	for _, p := range m.processes {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (m *Model) finishMigrationProcess(status ProcessStatus) {
+	if p := m.findProcess(m.migrationProcess); p != nil {
+		p.Status = status
+		p.EndTime = time.Now()
+	}
+}
+
+func (m Model) handleMigrationInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.gracefulShutdown()
+		return m, tea.Quit
+
+	case "esc", "u":
+		m.showMigration = false
+		return m, nil
+
+	case "enter", " ":
+		if m.migrating || m.migration == nil || m.migration.Done() {
+			return m, nil
+		}
+		m.migrating = true
+		p := m.createProcess("Upgrade", fmt.Sprintf("upgrade Capacitor %s → %s", m.migration.FromVersion, m.migration.ToVersion))
+		m.migrationProcess = p.ID
+		m.setStatus("Upgrading Capacitor...")
+		return m, tea.Batch(runMigrationStep(m.migration), m.spinner.Tick)
+
+	case "s":
+		// Skip a failed step and carry on from the next one
+		if !m.migrating && m.migration != nil {
+			if step := m.migration.NextStep(); step != nil && step.Status == cap.StepFailed {
+				_ = m.migration.SkipStep(step.ID)
+				m.setStatus("Skipped: " + step.Name)
+			}
+		}
+		return m, nil
+
+	case "d":
+		// Forget the saved migration (files already changed stay changed)
+		if !m.migrating && m.migration != nil {
+			_ = m.migration.Discard()
+			m.migration = nil
+			m.showMigration = false
+			m.setStatus("Upgrade plan discarded")
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m *Model) renderMigration() string {
+	title := lipgloss.NewStyle().
+		Foreground(capBlue).
+		Bold(true).
+		Render("  ⬆ Capacitor Upgrade")
+
+	var lines []string
+	lines = append(lines, "")
+	lines = append(lines, title)
+	lines = append(lines, "")
+
+	mig := m.migration
+	if mig == nil {
+		lines = append(lines, "  "+mutedStyle.Render("No upgrade available"))
+		return strings.Join(lines, "\n")
+	}
+
+	lines = append(lines, mutedStyle.Render(fmt.Sprintf("  %s → %s  •  %s", mig.FromVersion, mig.ToVersion, strings.Join(mig.Packages, ", "))))
+	lines = append(lines, "")
+
+	next := mig.NextStep()
+	for i := range mig.Steps {
+		step := &mig.Steps[i]
+		var icon string
+		switch step.Status {
+		case cap.StepDone:
+			icon = successStyle.Render("✓")
+		case cap.StepFailed:
+			icon = errorStyle.Render("✗")
+		case cap.StepSkipped:
+			icon = mutedStyle.Render("–")
+		default:
+			icon = mutedStyle.Render("○")
+		}
+		if m.migrating && step == next {
+			icon = m.spinnerView()
+		}
+
+		lines = append(lines, fmt.Sprintf("  %s %d. %s", icon, i+1, step.Name))
+		if step.Error != "" {
+			lines = append(lines, "       "+errorStyle.Render(step.Error))
+		}
+	}
+
+	// Checklist of native files touched by the upgrade
+	if native := mig.NativeChanges(); len(native) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, "  "+lipgloss.NewStyle().Foreground(capCyan).Bold(true).Render("Review these native changes:"))
+		for _, path := range native {
+			lines = append(lines, "    "+mutedStyle.Render("☐ "+path))
+		}
+		if others := len(mig.Changes) - len(native); others > 0 {
+			lines = append(lines, "    "+mutedStyle.Render(fmt.Sprintf("(+%d other files, see git status)", others)))
+		}
+	}
+
+	lines = append(lines, "")
+	action := "start"
+	if next != nil && next.Status == cap.StepFailed {
+		action = "retry"
+	} else if mig.Done() {
+		action = ""
+	}
+	help := "  "
+	if action != "" && !m.migrating {
+		help += helpKeyStyle.Render("enter") + " " + action + "  "
+		if next != nil && next.Status == cap.StepFailed {
+			help += helpKeyStyle.Render("s") + " skip step  "
+		}
+		help += helpKeyStyle.Render("d") + " discard  "
+	}
+	help += helpKeyStyle.Render("esc") + " close"
+	lines = append(lines, helpStyle.Render(help))
+
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) handleVersionBumpInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.gracefulShutdown()
+		return m, tea.Quit
+
+	case "esc", "V":
+		m.showVersionBump = false
+		return m, nil
+
+	case "up", "k":
+		if m.versionCursor > 0 {
+			m.versionCursor--
+		}
+		return m, nil
+
+	case "down", "j":
+		if m.versionCursor < len(cap.BumpParts)-1 {
+			m.versionCursor++
+		}
+		return m, nil
+
+	case "t":
+		m.versionTag = !m.versionTag
+		return m, nil
+
+	case "enter", " ":
+		m.showVersionBump = false
+		return m, m.applyVersionBump(m.versionPlans[m.versionCursor])
+	}
+
+	return m, nil
+}
+
+// versionBumpPlan is the planned bump for one version part
+type versionBumpPlan struct {
+	part string
+	bump *cap.VersionBump
+	err  error
+}
+
+// planVersionBumps plans every version part for the panel to preview
+func (m *Model) planVersionBumps() {
+	m.versionPlans = make([]versionBumpPlan, len(cap.BumpParts))
+	for i, part := range cap.BumpParts {
+		bump, err := cap.PlanVersionBump(m.project, part)
+		m.versionPlans[i] = versionBumpPlan{part: part, bump: bump, err: err}
+	}
+}
+
+// applyVersionBump logs the planned diff to a process tab and writes the bump
+// in the background, committing and tagging it when that is switched on.
+// The project is reloaded afterwards so the new version shows up everywhere.
+func (m *Model) applyVersionBump(plan versionBumpPlan) tea.Cmd {
+	if plan.err != nil {
+		m.setStatus("Version bump failed: " + plan.err.Error())
+		return nil
+	}
+
+	bump, tag, dir := plan.bump, m.versionTag, m.project.RootDir
+	p := m.createProcess("Version", "lazycap version bump "+plan.part)
+	for _, line := range strings.Split(strings.TrimRight(bump.Diff(), "\n"), "\n") {
+		p.AddLog(line)
+	}
+	m.updateLogViewport()
+	processID := p.ID
+
+	return tea.Batch(func() tea.Msg {
+		msg := versionBumpedMsg{processID: processID, bump: bump}
+		if msg.err = bump.Apply(); msg.err == nil && tag {
+			msg.tag, msg.tagErr = cap.TagVersion(dir, bump)
+		}
+		return msg
+	}, m.spinner.Tick)
+}
+
+// reloadProject re-reads the active project from disk, keeping workspace membership
+func (m *Model) reloadProject() {
+	if m.project == nil {
+		return
+	}
+	reloaded, err := cap.LoadProjectAt(m.project.RootDir)
+	if err != nil {
+		return
+	}
+	reloaded.Workspace = m.project.Workspace
+	reloaded.WorkspacePackage = m.project.WorkspacePackage
+	for i, p := range m.projects {
+		if p == m.project {
+			m.projects[i] = reloaded
+		}
+	}
+	m.project = reloaded
+	if m.pluginContext != nil {
+		m.pluginContext.SetProject(reloaded)
+	}
+}
+
+func (m *Model) renderVersionBump() string {
+	title := lipgloss.NewStyle().
+		Foreground(capBlue).
+		Bold(true).
+		Render("  🏷  Version Bump")
+
+	var lines []string
+	lines = append(lines, "")
+	lines = append(lines, title)
+	lines = append(lines, "")
+	lines = append(lines, mutedStyle.Render("  Updates package.json, build.gradle and project.pbxproj together"))
+	lines = append(lines, "")
+
+	var selected *cap.VersionBump
+	for i, plan := range m.versionPlans {
+		part, bump := plan.part, plan.bump
+		preview := ""
+		if plan.err != nil {
+			preview = errorStyle.Render(plan.err.Error())
+		} else {
+			preview = mutedStyle.Render(fmt.Sprintf("%s (%d) → %s (%d)", bump.OldVersion, bump.OldBuild, bump.NewVersion, bump.NewBuild))
+		}
+
+		if i == m.versionCursor {
+			selected = bump
+			arrow := lipgloss.NewStyle().Foreground(capBlue).Bold(true).Render("▶")
+			name := lipgloss.NewStyle().Foreground(capCyan).Bold(true).Width(8).Render(part)
+			lines = append(lines, fmt.Sprintf(" %s %s %s", arrow, name, preview))
+		} else {
+			lines = append(lines, fmt.Sprintf("   %s %s", lipgloss.NewStyle().Width(8).Render(part), preview))
+		}
+	}
+
+	lines = append(lines, "")
+	tagBox := "[ ]"
+	if m.versionTag {
+		tagBox = successStyle.Render("[✓]")
+	}
+	lines = append(lines, fmt.Sprintf("  %s %s", tagBox, "Commit and create git tag"))
+
+	// Preview the diff for the highlighted part
+	if selected != nil {
+		lines = append(lines, "")
+		cwd, _ := os.Getwd()
+		for _, line := range strings.Split(strings.TrimRight(selected.Diff(), "\n"), "\n") {
+			switch {
+			case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+				if rel, err := filepath.Rel(cwd, strings.TrimSpace(line[3:])); err == nil && !strings.HasPrefix(rel, "..") {
+					line = line[:3] + " " + rel
+				}
+				lines = append(lines, "  "+mutedStyle.Render(line))
+			case strings.HasPrefix(line, "-"):
+				lines = append(lines, "  "+errorStyle.Render(line))
+			case strings.HasPrefix(line, "+"):
+				lines = append(lines, "  "+successStyle.Render(line))
+			default:
+				lines = append(lines, "  "+mutedStyle.Render(line))
+			}
+		}
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, helpStyle.Render("  "+
+		helpKeyStyle.Render("↑/↓")+" navigate  "+
+		helpKeyStyle.Render("t")+" toggle tag  "+
+		helpKeyStyle.Render("enter")+" apply  "+
+		helpKeyStyle.Render("esc")+" close"))
+
+	return strings.Join(lines, "\n")
+}
+
 func (m Model) handlePluginsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.pluginManager == nil {
 		// No plugin manager, just close
@@ -3033,10 +4105,30 @@ func (m *Model) renderProjectSelector() string {
 		lines = append(lines, "")
 		lines = append(lines, mutedStyle.Render("  Make sure you're in a directory with capacitor.config.ts/js/json"))
 	} else {
+		// Group headers are only shown when at least one project is in a monorepo
+		grouped := false
+		for _, p := range m.projects {
+			if p.Workspace != nil {
+				grouped = true
+				break
+			}
+		}
+
 		for i, p := range m.projects {
 			isSelected := i == m.projectCursor
 			isCurrent := m.project != nil && p.RootDir == m.project.RootDir
 
+			if grouped && (i == 0 || m.projects[i-1].WorkspaceRoot() != p.WorkspaceRoot()) {
+				if i > 0 {
+					lines = append(lines, "")
+				}
+				header := "Standalone"
+				if p.Workspace != nil {
+					header = fmt.Sprintf("%s (%s workspace)", p.Workspace.Name, p.Workspace.Tool)
+				}
+				lines = append(lines, "  "+lipgloss.NewStyle().Foreground(capBlue).Render("◆ "+header))
+			}
+
 			// Platform indicators
 			var platforms []string
 			if p.HasIOS {
@@ -3075,6 +4167,16 @@ func (m *Model) renderProjectSelector() string {
 				if p.AppID != "" {
 					lines = append(lines, fmt.Sprintf("      %s", mutedStyle.Render("ID: "+p.AppID)))
 				}
+				if p.WorkspacePackage != "" && p.WorkspacePackage != p.Name {
+					lines = append(lines, fmt.Sprintf("      %s", mutedStyle.Render("Package: "+p.WorkspacePackage)))
+				}
+				if p.Config != nil && p.Config.Server.URL != "" {
+					lines = append(lines, fmt.Sprintf("      %s", mutedStyle.Render("Server: "+p.Config.Server.URL)))
+				}
+				if len(p.ConfigWarnings) > 0 {
+					lines = append(lines, fmt.Sprintf("      %s", lipgloss.NewStyle().Foreground(warnColor).Render(
+						fmt.Sprintf("⚠ %d config value(s) lazycap couldn't read", len(p.ConfigWarnings)))))
+				}
 			} else {
 				prefix := "  "
 				name := p.Name
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// processCompleted runs the Notify/Sound on Complete and Auto Open IDE
+// settings for a process that just finished
+func (m *Model) processCompleted(p *Process) tea.Cmd {
+	var cmds []tea.Cmd
+
+	if m.settings.NotifyOnComplete || m.settings.SoundOnComplete {
+		result := "finished"
+		switch p.Status {
+		case ProcessFailed:
+			result = "failed"
+		case ProcessCancelled:
+			result = "was canceled"
+		}
+		message := fmt.Sprintf("%s %s after %s", p.Name, result, p.Duration().Round(time.Second))
+		notify, sound := m.settings.NotifyOnComplete, m.settings.SoundOnComplete
+		cmds = append(cmds, func() tea.Msg {
+			if notify {
+				sendNotification("lazycap", message)
+			}
+			if sound {
+				// Terminal bell; most terminals play the system alert sound
+				_, _ = os.Stdout.WriteString("\a")
+			}
+			return nil
+		})
+	}
+
+	// A failed run is usually a native build error, which is easiest to read in the IDE
+	if m.settings.AutoOpenIDE && p.Status == ProcessFailed && p.Platform != "" && p.Platform != "web" {
+		p.AddLog("Opening native IDE...")
+		cmds = append(cmds, m.startOpenCommand(p.Platform))
+	}
+
+	return tea.Batch(cmds...)
+}
+
+// sendNotification shows a desktop notification where the platform has a
+// command-line way to do it
+func sendNotification(title, message string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux", "freebsd":
+		cmd = exec.Command("notify-send", title, message)
+	default:
+		return
+	}
+	_ = cmd.Run()
+}
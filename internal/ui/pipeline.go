@@ -0,0 +1,162 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/icarus-itcs/lazycap/internal/plugin"
+	"github.com/icarus-itcs/lazycap/internal/settings"
+)
+
+// pipeline runs a command's pre hooks, the command itself and its post hooks
+// one after another, each as its own entry in the process list
+type pipeline struct {
+	action  string // run, build, sync or open
+	steps   []pipelineStep
+	current int
+	reply   chan pluginActionResult // Plugin waiting for the main command, if any
+}
+
+// pipelineStep is a hook, or the main command when stage is empty
+type pipelineStep struct {
+	stage string
+	hook  settings.Hook
+	start func(m *Model) (*Process, tea.Cmd)
+}
+
+// startPipeline runs the hooks for preStage, then main, then the hooks for
+// postStage. Post hooks only run once the main command has succeeded.
+func (m *Model) startPipeline(action, preStage, postStage string, main func(m *Model) (*Process, tea.Cmd)) tea.Cmd {
+	pl := &pipeline{action: action}
+	for _, h := range m.settings.HooksFor(preStage) {
+		pl.steps = append(pl.steps, pipelineStep{stage: preStage, hook: h})
+	}
+	pl.steps = append(pl.steps, pipelineStep{start: main})
+	for _, h := range m.settings.HooksFor(postStage) {
+		pl.steps = append(pl.steps, pipelineStep{stage: postStage, hook: h})
+	}
+	return m.startStep(pl)
+}
+
+func (m *Model) startStep(pl *pipeline) tea.Cmd {
+	step := pl.steps[pl.current]
+	var p *Process
+	var cmd tea.Cmd
+	if step.stage == "" {
+		p, cmd = step.start(m)
+	} else {
+		p, cmd = m.startHook(step.stage, step.hook)
+	}
+	if p == nil {
+		pl.replyErr(fmt.Errorf("%s did not start", pl.action))
+		return nil
+	}
+	m.pipelines[p.ID] = pl
+	return cmd
+}
+
+// startHook runs a hook through the configured shell in its own process
+func (m *Model) startHook(stage string, h settings.Hook) (*Process, tea.Cmd) {
+	p := m.createProcess(fmt.Sprintf("%s: %s", stage, h.DisplayName()), h.Command)
+	opts := m.execOptions(h.WorkDir(m.getProjectDir()), h.TimeoutDuration())
+	opts.Env = append(opts.Env, h.EnvList()...)
+
+	if m.pluginContext != nil {
+		m.pluginContext.NotifyHookStarted(plugin.HookEvent{
+			Stage:     stage,
+			Name:      h.DisplayName(),
+			Command:   h.Command,
+			ProcessID: p.ID,
+		})
+	}
+	return p, m.runShell(p.ID, opts, h.Command)
+}
+
+// pipelineStarted hands the main command's process to the plugin waiting for it
+func (m *Model) pipelineStarted(msg processStartedMsg) {
+	pl, ok := m.pipelines[msg.processID]
+	if !ok || pl.reply == nil || pl.steps[pl.current].stage != "" {
+		return
+	}
+	pl.reply <- pluginActionResult{handle: &plugin.ProcessHandle{ProcessID: msg.processID, Exec: msg.exec}}
+	pl.reply = nil
+}
+
+// advancePipeline starts the step after p when p is part of a pipeline. A
+// failed hook stops the pipeline unless it is set to continue; a canceled
+// one always does.
+func (m *Model) advancePipeline(p *Process, err error) tea.Cmd {
+	pl, ok := m.pipelines[p.ID]
+	if !ok {
+		return nil
+	}
+	delete(m.pipelines, p.ID)
+
+	step := pl.steps[pl.current]
+	failed := p.Status != ProcessSuccess
+
+	if step.stage == "" {
+		if failed {
+			// Skip the post hooks. A plugin still waiting is only told when
+			// the command failed before it started; otherwise it already has
+			// the process and replyErr does nothing.
+			pl.replyErr(err)
+			return nil
+		}
+	} else {
+		aborted := failed && (p.Status == ProcessCancelled || !step.hook.ContinueOnFailure())
+		if m.pluginContext != nil {
+			m.pluginContext.NotifyHookFinished(plugin.HookEvent{
+				Stage:     step.stage,
+				Name:      step.hook.DisplayName(),
+				Command:   step.hook.Command,
+				ProcessID: p.ID,
+				Success:   !failed,
+				Error:     err,
+				Aborted:   aborted,
+			})
+		}
+		if aborted {
+			p.AddLog(fmt.Sprintf("✗ %s hook failed, stopping %s", step.stage, pl.action))
+			pl.replyErr(fmt.Errorf("%s hook %q failed: %v", step.stage, step.hook.DisplayName(), err))
+			return nil
+		}
+		if failed {
+			p.AddLog("Hook failed, continuing")
+		}
+	}
+
+	pl.current++
+	if pl.current >= len(pl.steps) {
+		return nil
+	}
+	return m.startStep(pl)
+}
+
+// dropPipeline forgets the pipeline of a process that was removed before it finished
+func (m *Model) dropPipeline(processID string) {
+	if pl, ok := m.pipelines[processID]; ok {
+		delete(m.pipelines, processID)
+		pl.replyErr(fmt.Errorf("%s was canceled", pl.action))
+	}
+}
+
+// latestPipeline returns the pipeline of the most recently created process, if any
+func (m *Model) latestPipeline() *pipeline {
+	if len(m.processes) == 0 {
+		return nil
+	}
+	return m.pipelines[m.processes[len(m.processes)-1].ID]
+}
+
+func (pl *pipeline) replyErr(err error) {
+	if pl.reply == nil {
+		return
+	}
+	if err == nil {
+		err = fmt.Errorf("%s did not start", pl.action)
+	}
+	pl.reply <- pluginActionResult{err: err}
+	pl.reply = nil
+}
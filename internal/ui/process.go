@@ -1,8 +1,9 @@
 package ui
 
 import (
-	"os/exec"
 	"time"
+
+	"github.com/icarus-itcs/lazycap/internal/cap"
 )
 
 // ProcessStatus represents the state of a process
@@ -17,16 +18,24 @@ const (
 
 // Process represents a running or completed command
 type Process struct {
-	ID         string
-	Name       string
-	Command    string
-	Status     ProcessStatus
-	StartTime  time.Time
-	EndTime    time.Time
-	Logs       []string
-	Cmd        *exec.Cmd
-	OutputChan chan string
-	Error      error
+	ID        string
+	Name      string
+	Command   string
+	Status    ProcessStatus
+	StartTime time.Time
+	EndTime   time.Time
+	Logs      []string
+	Exec      *cap.Execution
+	Error     error
+	Platform  string // Device platform for run processes
+	MaxLines  int    // Log lines to keep (0 = 5000)
+
+	RefreshDevices bool // Reload the device list when the process finishes
+
+	Parent   string   // Process this one was started for, e.g. the run a live reload dev server serves
+	Children []string // Processes started for this one; a parent and its children stop together
+
+	then []processStep // Steps still to run, each once the one before succeeds
 }
 
 // Duration returns how long the process has been running or ran
@@ -56,8 +65,11 @@ func (p *Process) StatusIcon() string {
 // AddLog adds a log line to the process
 func (p *Process) AddLog(line string) {
 	p.Logs = append(p.Logs, line)
-	// Keep max 5000 lines per process
-	if len(p.Logs) > 5000 {
-		p.Logs = p.Logs[len(p.Logs)-5000:]
+	maxLines := p.MaxLines
+	if maxLines <= 0 {
+		maxLines = 5000
+	}
+	if len(p.Logs) > maxLines {
+		p.Logs = p.Logs[len(p.Logs)-maxLines:]
 	}
 }
@@ -79,6 +79,9 @@ var (
 	offlineStyle = lipgloss.NewStyle().
 			Foreground(errorColor)
 
+	transitionStyle = lipgloss.NewStyle().
+			Foreground(warnColor)
+
 	successStyle = lipgloss.NewStyle().
 			Foreground(successColor)
 